@@ -46,6 +46,17 @@ func init() {
 	common.AddStringFlag(Command, "ui.schema", "ui-schema", "", "http", envPrefix+"_SCHEMA", "Server UI schema")
 	common.AddBoolFlag(Command, "ui.enable", "ui", "", true, envPrefix+"_UI", "Enable server to serve UI")
 	common.AddDurationFlag(Command, "enforcer.interval", "enforcer-interval", "", 60*time.Second, envPrefix+"_ENFORCER_INTERVAL", "Enforcer interval")
+	common.AddDurationFlag(Command, "enforcer.actiontimeout", "enforcer-action-timeout", "", 0, envPrefix+"_ENFORCER_ACTION_TIMEOUT", "Timeout for applying a single action (0 means no timeout)")
+	common.AddDurationFlag(Command, "enforcer.revisiontimeout", "enforcer-revision-timeout", "", 0, envPrefix+"_ENFORCER_REVISION_TIMEOUT", "Timeout for applying the whole revision (0 means no timeout)")
+	common.AddIntFlag(Command, "enforcer.blastradius.maxdeletes", "enforcer-max-deletes", "", 0, envPrefix+"_ENFORCER_MAX_DELETES", "Max number of component instances a single revision is allowed to delete (0 means no limit)")
+	common.AddIntFlag(Command, "enforcer.blastradius.maxdeletespercent", "enforcer-max-deletes-percent", "", 0, envPrefix+"_ENFORCER_MAX_DELETES_PERCENT", "Max percentage of component instances a single revision is allowed to delete (0 means no limit)")
+	common.AddBoolFlag(Command, "enforcer.maintenance.allowadditive", "enforcer-maintenance-allow-additive", "", false, envPrefix+"_ENFORCER_MAINTENANCE_ALLOW_ADDITIVE", "Allow purely additive changes to be applied outside of maintenance windows")
+	common.AddBoolFlag(Command, "enforcer.adopt", "enforcer-adopt", "", false, envPrefix+"_ENFORCER_ADOPT", "Adopt existing deployments into actual state instead of creating them")
+	common.AddDurationFlag(Command, "enforcer.adopttimeout", "enforcer-adopt-timeout", "", 60*time.Second, envPrefix+"_ENFORCER_ADOPT_TIMEOUT", "Timeout for inspecting existing deployments for adoption")
+	common.AddStringFlag(Command, "plugins.external.dir", "plugins-dir", "", "", envPrefix+"_PLUGINS_DIR", "Directory with external plugin executables")
+	common.AddDurationFlag(Command, "plugins.external.timeout", "plugins-timeout", "", 0, envPrefix+"_PLUGINS_TIMEOUT", "Timeout for a single call to external plugin (0 means no timeout)")
+	common.AddDurationFlag(Command, "drift.interval", "drift-interval", "", 5*time.Minute, envPrefix+"_DRIFT_INTERVAL", "Drift detection interval")
+	common.AddDurationFlag(Command, "drift.timeout", "drift-timeout", "", 60*time.Second, envPrefix+"_DRIFT_TIMEOUT", "Timeout for a single drift detection run")
 
 	Command.AddCommand(
 		version.NewVersionCommand(),
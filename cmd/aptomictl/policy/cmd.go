@@ -17,6 +17,8 @@ func NewCommand(cfg *config.Client) *cobra.Command {
 		newShowCommand(cfg),
 		newApplyCommand(cfg),
 		newDeleteCommand(cfg),
+		newSuspendCommand(cfg),
+		newResumeCommand(cfg),
 	)
 
 	return cmd
@@ -17,6 +17,7 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"time"
 )
 
@@ -177,7 +178,7 @@ func waitForApplyToFinish(attempts int, interval time.Duration, client client.Co
 			}
 		}
 
-		return rev.Status == engine.RevisionStatusCompleted || rev.Status == engine.RevisionStatusError
+		return rev.Status == engine.RevisionStatusCompleted || rev.Status == engine.RevisionStatusError || rev.Status == engine.RevisionStatusAborted || rev.Status == engine.RevisionStatusBlocked || rev.Status == engine.RevisionStatusScheduled || rev.Status == engine.RevisionStatusSuperseded
 	})
 
 	if !finished {
@@ -187,6 +188,25 @@ func waitForApplyToFinish(attempts int, interval time.Duration, client client.Co
 	} else if rev.Status == engine.RevisionStatusCompleted {
 		progressBar.Done(true)
 		fmt.Printf("Revision %d completed. Actions: %d succeeded, %d failed, %d skipped\n", rev.GetGeneration(), rev.Result.Success, rev.Result.Failed, rev.Result.Skipped)
+	} else if rev.Status == engine.RevisionStatusBlocked {
+		progressBar.Done(false)
+		fmt.Printf("Revision %d blocked by safety limits: %s\n", rev.GetGeneration(), strings.Join(rev.Blocked.Reasons, "; "))
+		fmt.Printf("Review the plan and run 'aptomictl revision override -g %d' to apply it anyway\n", rev.GetGeneration())
+		panic("blocked")
+	} else if rev.Status == engine.RevisionStatusScheduled {
+		progressBar.Done(false)
+		if rev.Scheduled.For.IsZero() {
+			fmt.Printf("Revision %d is outside of maintenance windows, which will never be open\n", rev.GetGeneration())
+		} else {
+			fmt.Printf("Revision %d is outside of maintenance windows, scheduled for %s\n", rev.GetGeneration(), rev.Scheduled.For)
+		}
+	} else if rev.Status == engine.RevisionStatusSuperseded {
+		progressBar.Done(false)
+		fmt.Printf("Revision %d has been superseded by a newer revision before it was applied\n", rev.GetGeneration())
+	} else if rev.Status == engine.RevisionStatusAborted {
+		progressBar.Done(false)
+		fmt.Printf("Revision %d aborted. Actions: %d succeeded, %d failed, %d skipped\n", rev.GetGeneration(), rev.Result.Success, rev.Result.Failed, rev.Result.Skipped)
+		panic("aborted")
 	} else if rev.Status == engine.RevisionStatusError {
 		progressBar.Done(false)
 		fmt.Printf("Revision %d failed\n", rev.GetGeneration())
@@ -13,6 +13,7 @@ import (
 func newDeleteCommand(cfg *config.Client) *cobra.Command {
 	paths := make([]string, 0)
 	var wait bool
+	var force bool
 	var waitInterval time.Duration
 	var waitAttempts int
 
@@ -28,7 +29,7 @@ func newDeleteCommand(cfg *config.Client) *cobra.Command {
 			}
 
 			client := rest.New(cfg, http.NewClient(cfg))
-			result, err := client.Policy().Delete(allObjects)
+			result, err := client.Policy().Delete(allObjects, force)
 			if err != nil {
 				panic(fmt.Sprintf("Error while deleting policy: %s", err))
 			}
@@ -51,6 +52,7 @@ func newDeleteCommand(cfg *config.Client) *cobra.Command {
 	if err := cmd.MarkFlagRequired("policyPaths"); err != nil {
 		panic(err)
 	}
+	cmd.Flags().BoolVar(&force, "force", false, "Force removal of protected objects (requires domain admin role)")
 	cmd.Flags().BoolVar(&wait, "wait", false, "Wait until first revision with updated policy will be fully deleted")
 	cmd.Flags().DurationVar(&waitInterval, "wait-interval", 2*time.Second, "Seconds to sleep between wait attempts")
 	cmd.Flags().IntVar(&waitAttempts, "wait-attempts", 150, "Number of attempts to do before failure while waiting")
@@ -0,0 +1,64 @@
+package policy
+
+import (
+	"fmt"
+	"github.com/Aptomi/aptomi/cmd/common"
+	"github.com/Aptomi/aptomi/pkg/client/rest"
+	"github.com/Aptomi/aptomi/pkg/client/rest/http"
+	"github.com/Aptomi/aptomi/pkg/config"
+	"github.com/spf13/cobra"
+	"time"
+)
+
+func newSuspendCommand(cfg *config.Client) *cobra.Command {
+	return newSuspendOrResumeCommand(cfg, "suspend", true)
+}
+
+func newResumeCommand(cfg *config.Client) *cobra.Command {
+	return newSuspendOrResumeCommand(cfg, "resume", false)
+}
+
+func newSuspendOrResumeCommand(cfg *config.Client, use string, suspended bool) *cobra.Command {
+	var ns string
+	var name string
+	var wait bool
+	var waitInterval time.Duration
+	var waitAttempts int
+
+	cmd := &cobra.Command{
+		Use:   use,
+		Short: fmt.Sprintf("%s dependency", use),
+		Long:  fmt.Sprintf("%s dependency long", use),
+
+		Run: func(cmd *cobra.Command, args []string) {
+			client := rest.New(cfg, http.NewClient(cfg))
+			result, err := client.Policy().Suspend(ns, name, suspended)
+			if err != nil {
+				panic(fmt.Sprintf("Error while trying to %s dependency: %s", use, err))
+			}
+
+			data, err := common.Format(cfg.Output, false, result)
+			if err != nil {
+				panic(fmt.Sprintf("Error while formating policy update result: %s", err))
+			}
+			fmt.Println(string(data))
+
+			if !wait {
+				return
+			}
+
+			waitForApplyToFinish(waitAttempts, waitInterval, client, result)
+		},
+	}
+
+	cmd.Flags().StringVarP(&ns, "namespace", "n", "main", "Namespace of the dependency")
+	cmd.Flags().StringVar(&name, "name", "", "Name of the dependency")
+	if err := cmd.MarkFlagRequired("name"); err != nil {
+		panic(err)
+	}
+	cmd.Flags().BoolVar(&wait, "wait", false, "Wait until first revision with updated policy will be fully applied")
+	cmd.Flags().DurationVar(&waitInterval, "wait-interval", 2*time.Second, "Seconds to sleep between wait attempts")
+	cmd.Flags().IntVar(&waitAttempts, "wait-attempts", 150, "Number of attempts to do before failure while waiting")
+
+	return cmd
+}
@@ -15,6 +15,8 @@ func NewCommand(cfg *config.Client) *cobra.Command {
 
 	cmd.AddCommand(
 		newShowCommand(cfg),
+		newAbortCommand(cfg),
+		newOverrideCommand(cfg),
 	)
 
 	return cmd
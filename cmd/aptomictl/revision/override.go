@@ -0,0 +1,38 @@
+package revision
+
+import (
+	"fmt"
+	"github.com/Aptomi/aptomi/pkg/client/rest"
+	"github.com/Aptomi/aptomi/pkg/client/rest/http"
+	"github.com/Aptomi/aptomi/pkg/config"
+	"github.com/Aptomi/aptomi/pkg/runtime"
+	"github.com/spf13/cobra"
+)
+
+func newOverrideCommand(cfg *config.Client) *cobra.Command {
+	var gen uint64
+
+	cmd := &cobra.Command{
+		Use:   "override",
+		Short: "revision override",
+		Long:  "revision override long",
+
+		Run: func(cmd *cobra.Command, args []string) {
+			if gen == 0 {
+				panic(fmt.Sprintf("Revision generation should be specified"))
+			}
+
+			result, err := rest.New(cfg, http.NewClient(cfg)).Revision().Override(runtime.Generation(gen))
+			if err != nil {
+				panic(fmt.Sprintf("Error while overriding revision: %s", err))
+			}
+
+			// todo(slukjanov): replace with -o yaml / json / etc handler
+			fmt.Println(result)
+		},
+	}
+
+	cmd.Flags().Uint64VarP(&gen, "generation", "g", 0, "Revision generation")
+
+	return cmd
+}
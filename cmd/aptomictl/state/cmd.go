@@ -15,6 +15,9 @@ func NewCommand(cfg *config.Client) *cobra.Command {
 
 	cmd.AddCommand(
 		newEnforceCommand(cfg),
+		newDriftCommand(cfg),
+		newOrphansCommand(cfg),
+		newGCCommand(cfg),
 	)
 
 	return cmd
@@ -0,0 +1,64 @@
+package state
+
+import (
+	"fmt"
+	"github.com/Aptomi/aptomi/cmd/common"
+	"github.com/Aptomi/aptomi/pkg/client/rest"
+	"github.com/Aptomi/aptomi/pkg/client/rest/http"
+	"github.com/Aptomi/aptomi/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+func newOrphansCommand(cfg *config.Client) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "orphans",
+		Short: "state orphans",
+		Long:  "state orphans long",
+
+		Run: func(cmd *cobra.Command, args []string) {
+			result, err := rest.New(cfg, http.NewClient(cfg)).State().Orphans()
+			if err != nil {
+				panic(fmt.Sprintf("Error while requesting orphaned deployments: %s", err))
+			}
+
+			data, err := common.Format(cfg.Output, false, result)
+			if err != nil {
+				panic(fmt.Sprintf("Error while formating orphaned deployments: %s", err))
+			}
+			fmt.Println(string(data))
+		},
+	}
+
+	return cmd
+}
+
+func newGCCommand(cfg *config.Client) *cobra.Command {
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "gc",
+		Short: "state gc",
+		Long:  "state gc long",
+
+		Run: func(cmd *cobra.Command, args []string) {
+			result, err := rest.New(cfg, http.NewClient(cfg)).State().DeleteOrphans(dryRun)
+			if err != nil {
+				panic(fmt.Sprintf("Error while deleting orphaned deployments: %s", err))
+			}
+
+			data, err := common.Format(cfg.Output, false, result)
+			if err != nil {
+				panic(fmt.Sprintf("Error while formating orphaned deployments: %s", err))
+			}
+			fmt.Println(string(data))
+
+			if result.DryRun {
+				fmt.Println("Dry run, nothing has been deleted. Run with --dry-run=false to delete orphaned deployments")
+			}
+		},
+	}
+
+	cmd.Flags().BoolVar(&dryRun, "dry-run", true, "Only show orphaned deployments, which would be deleted")
+
+	return cmd
+}
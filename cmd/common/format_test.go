@@ -3,6 +3,7 @@ package common
 import (
 	"github.com/Aptomi/aptomi/pkg/api"
 	"github.com/Aptomi/aptomi/pkg/config"
+	"github.com/Aptomi/aptomi/pkg/engine/apply/action"
 	"github.com/Aptomi/aptomi/pkg/engine/apply/action/component"
 	"github.com/Aptomi/aptomi/pkg/engine/resolve"
 	"github.com/Aptomi/aptomi/pkg/lang"
@@ -35,7 +36,7 @@ func TestFormat_Text(t *testing.T) {
 		// empty set of actions
 		result := &api.PolicyUpdateResult{
 			PolicyGeneration: 42,
-			Actions:          []string{},
+			Plan:             &action.PlanView{},
 		}
 		data, err := Format(cfg.Output, true, result)
 		assert.Nil(t, err, "Format should work without error")
@@ -54,17 +55,26 @@ func makePolicyUpdateResult(policyChanged bool) *api.PolicyUpdateResult {
 		&lang.Service{Metadata: lang.Metadata{Name: "service"}},
 		&lang.ServiceComponent{Name: "component"},
 	)
+	actions := []action.Base{
+		component.NewCreateAction(key.GetKey()),
+		component.NewUpdateAction(key.GetKey()),
+		component.NewDeleteAction(key.GetKey()),
+		component.NewDetachDependencyAction(key.GetKey(), "depId"),
+		component.NewAttachDependencyAction(key.GetKey(), "depId"),
+		component.NewEndpointsAction(key.GetKey()),
+	}
+	node := &action.GraphNodeView{Key: key.GetKey()}
+	for _, act := range actions {
+		node.Actions = append(node.Actions, &action.ActionView{
+			Kind:         act.GetKind(),
+			Name:         act.GetName(),
+			ComponentKey: key.GetKey(),
+		})
+	}
 	result := &api.PolicyUpdateResult{
 		PolicyGeneration: 42,
 		PolicyChanged:    policyChanged,
-		Actions: []string{
-			component.NewCreateAction(key.GetKey()).GetName(),
-			component.NewUpdateAction(key.GetKey()).GetName(),
-			component.NewDeleteAction(key.GetKey()).GetName(),
-			component.NewDetachDependencyAction(key.GetKey(), "depId").GetName(),
-			component.NewAttachDependencyAction(key.GetKey(), "depId").GetName(),
-			component.NewEndpointsAction(key.GetKey()).GetName(),
-		},
+		Plan:             &action.PlanView{Nodes: []*action.GraphNodeView{node}},
 	}
 	return result
 }
@@ -2,11 +2,13 @@ package api
 
 import (
 	"github.com/Aptomi/aptomi/pkg/api/codec"
+	"github.com/Aptomi/aptomi/pkg/engine"
 	"github.com/Aptomi/aptomi/pkg/external"
 	"github.com/Aptomi/aptomi/pkg/plugin"
 	"github.com/Aptomi/aptomi/pkg/runtime"
 	"github.com/Aptomi/aptomi/pkg/runtime/store"
 	"github.com/julienschmidt/httprouter"
+	"sync"
 )
 
 type coreAPI struct {
@@ -16,10 +18,21 @@ type coreAPI struct {
 	pluginRegistryFactory plugin.RegistryFactory
 	secret                string
 	policyChanged         chan bool
+	revisionAborter       RevisionAborter
+	revisionOverrider     RevisionOverrider
+	stateLock             sync.Locker
 }
 
-// Serve initializes everything needed by REST API and registers all API endpoints in the provided http router
-func Serve(router *httprouter.Router, store store.Core, externalData *external.Data, pluginRegistryFactory plugin.RegistryFactory, secret string, policyChanged chan bool) {
+// RevisionAborter aborts revision with the given generation, if it's currently being applied
+type RevisionAborter func(gen runtime.Generation) error
+
+// RevisionOverrider overrides safety limits for a blocked revision with the given generation and returns the updated
+// revision (nil if it doesn't exist)
+type RevisionOverrider func(gen runtime.Generation) (*engine.Revision, error)
+
+// Serve initializes everything needed by REST API and registers all API endpoints in the provided http router. State
+// lock is the one held by enforcer while changing actual state, so API could make changes to the cloud without racing it
+func Serve(router *httprouter.Router, store store.Core, externalData *external.Data, pluginRegistryFactory plugin.RegistryFactory, secret string, policyChanged chan bool, revisionAborter RevisionAborter, revisionOverrider RevisionOverrider, stateLock sync.Locker) {
 	contentTypeHandler := codec.NewContentTypeHandler(runtime.NewRegistry().Append(Objects...))
 	api := &coreAPI{
 		contentType:           contentTypeHandler,
@@ -28,6 +41,9 @@ func Serve(router *httprouter.Router, store store.Core, externalData *external.D
 		pluginRegistryFactory: pluginRegistryFactory,
 		secret:                secret,
 		policyChanged:         policyChanged,
+		revisionAborter:       revisionAborter,
+		revisionOverrider:     revisionOverrider,
+		stateLock:             stateLock,
 	}
 	api.serve(router)
 }
@@ -50,7 +66,9 @@ func (api *coreAPI) serve(router *httprouter.Router) {
 
 	// update policy
 	router.POST("/api/v1/policy", auth(api.handlePolicyUpdate))
+	router.POST("/api/v1/policy/force/:force", auth(api.handlePolicyUpdate))
 	router.DELETE("/api/v1/policy", auth(api.handlePolicyDelete))
+	router.DELETE("/api/v1/policy/force/:force", auth(api.handlePolicyDelete))
 
 	// policy & object diagrams
 	router.GET("/api/v1/policy/diagram/object/:ns/:kind/:name", auth(api.handleObjectDiagram))
@@ -58,9 +76,10 @@ func (api *coreAPI) serve(router *httprouter.Router) {
 	router.GET("/api/v1/policy/diagram/mode/:mode/gen/:gen", auth(api.handlePolicyDiagram))
 	router.GET("/api/v1/policy/diagram/compare/mode/:mode/gen/:gen/genBase/:genBase", auth(api.handlePolicyDiagramCompare))
 
-	// retrieve dependency along with its status
+	// retrieve dependency along with its status, suspend or resume it
 	router.GET("/api/v1/policy/dependency/:ns/:name/status", auth(api.handleDependencyStatusGet))
 	router.GET("/api/v1/policy/dependency/:ns/:name/resources", auth(api.handleDependencyResourcesGet))
+	router.POST("/api/v1/policy/dependency/:ns/:name/suspend/:suspended", auth(api.handleDependencySuspend))
 
 	// retrieve endpoints (all + by dependency)
 	router.GET("/api/v1/endpoints", api.handleEndpointsGet)
@@ -70,12 +89,28 @@ func (api *coreAPI) serve(router *httprouter.Router) {
 	router.GET("/api/v1/revision", auth(api.handleRevisionGet))
 	router.GET("/api/v1/revision/gen/:gen", auth(api.handleRevisionGet))
 
+	// abort revision which is currently being applied
+	router.POST("/api/v1/revision/gen/:gen/abort", auth(api.handleRevisionAbort))
+
+	// allow revision blocked by safety limits to proceed
+	router.POST("/api/v1/revision/gen/:gen/override", auth(api.handleRevisionOverride))
+
 	// retrieve revision(s) (for a given policy)
 	router.GET("/api/v1/revision/policy/:policy", auth(api.handleRevisionGetByPolicy))
 	router.GET("/api/v1/revisions/policy/:policy", auth(api.handleRevisionsGetByPolicy))
 
 	router.DELETE("/api/v1/actualstate", auth(api.handleActualStateReset))
 
+	// retrieve drift detected between actual state and the cloud
+	router.GET("/api/v1/actualstate/drift", auth(api.handleActualStateDriftGet))
+
+	// retrieve and delete orphaned deployments, which don't have corresponding component instances in actual state
+	router.GET("/api/v1/actualstate/orphans", auth(api.handleOrphansGet))
+	router.DELETE("/api/v1/actualstate/orphans/dryrun/:dryrun", auth(api.handleOrphansDelete))
+
+	// retrieve cluster types and code types supported by registered plugins
+	router.GET("/api/v1/plugins/types", auth(api.handlePluginTypesGet))
+
 	// return aptomi version
 	router.GET("/version", api.handleVersion)
 	router.GET("/api/v1/version", api.handleVersion)
@@ -2,6 +2,7 @@ package api
 
 import (
 	"fmt"
+	"github.com/Aptomi/aptomi/pkg/engine"
 	"github.com/Aptomi/aptomi/pkg/engine/resolve"
 	"github.com/Aptomi/aptomi/pkg/event"
 	"github.com/Aptomi/aptomi/pkg/lang"
@@ -9,10 +10,14 @@ import (
 	"github.com/Aptomi/aptomi/pkg/runtime"
 	"github.com/julienschmidt/httprouter"
 	"net/http"
+	"strconv"
 )
 
 type dependencyStatusWrapper struct {
 	Data interface{}
+
+	// Progress is the rollout progress of dependency component instances
+	Progress *engine.DependencyProgress
 }
 
 func (g *dependencyStatusWrapper) GetKind() string {
@@ -57,11 +62,89 @@ func (api *coreAPI) handleDependencyStatusGet(writer http.ResponseWriter, reques
 	}
 	if foundRefs {
 		status = "Active"
+	} else if dependency.IsSuspended() {
+		status = "Suspended"
 	} else {
 		status = "Inactive"
 	}
 
-	api.contentType.WriteOne(writer, request, &dependencyStatusWrapper{Data: status})
+	// calculate rollout progress based on the latest revision
+	revision, err := api.store.GetRevision(runtime.LastGen)
+	if err != nil {
+		panic(fmt.Sprintf("Can't load revision to get dependency progress: %s", err))
+	}
+
+	// desired state of the dependency is taken from the revision, so policy isn't resolved on every request
+	progress := engine.GetDependencyProgress(depKey, revision, actualState)
+
+	api.contentType.WriteOne(writer, request, &dependencyStatusWrapper{Data: status, Progress: progress})
+}
+
+func (api *coreAPI) handleDependencySuspend(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+	suspended, err := strconv.ParseBool(params.ByName("suspended"))
+	if err != nil {
+		panic(fmt.Sprintf("error while parsing suspended flag: %s", err))
+	}
+
+	user := api.getUserRequired(request)
+
+	policy, _, err := api.store.GetPolicy(runtime.LastGen)
+	if err != nil {
+		panic(fmt.Sprintf("error while getting requested policy: %s", err))
+	}
+
+	ns := params.ByName("ns")
+	kind := lang.DependencyObject.Kind
+	name := params.ByName("name")
+
+	obj, err := policy.GetObject(kind, name, ns)
+	if err != nil {
+		panic(fmt.Sprintf("error while getting object %s/%s/%s from policy: %s", ns, kind, name, err))
+	}
+	if obj == nil {
+		api.contentType.WriteOneWithStatus(writer, request, nil, http.StatusNotFound)
+		return
+	}
+
+	// dependency gets updated in policy with all its configuration preserved, only suspended flag is changed
+	dependency := *obj.(*lang.Dependency)
+	dependency.Suspended = suspended
+
+	errManage := policy.View(user).ManageObject(&dependency)
+	if errManage != nil {
+		panic(fmt.Sprintf("Error while updating dependency: %s", errManage))
+	}
+
+	// suspension destroys component instances of the dependency, so protected dependencies can't be suspended
+	if suspended {
+		if errProtected := api.checkProtected(policy, user, &dependency, false); errProtected != nil {
+			panic(fmt.Sprintf("Error while suspending dependency: %s", errProtected))
+		}
+	}
+
+	// validate policy with the updated dependency replacing the stored one
+	policy.RemoveObject(&dependency)
+	errAdd := policy.AddObject(&dependency)
+	if errAdd != nil {
+		panic(fmt.Sprintf("Error while updating dependency: %s", errAdd))
+	}
+
+	err = policy.Validate()
+	if err != nil {
+		panic(fmt.Sprintf("Updated policy is invalid: %s", err))
+	}
+
+	changed, policyData, err := api.store.UpdatePolicy([]lang.Base{&dependency}, user.Name)
+	if err != nil {
+		panic(fmt.Sprintf("Error while updating policy: %s", err))
+	}
+
+	api.getPolicyUpdateResult(writer, request, changed, policyData)
+
+	if changed {
+		// signal to the channel that policy has changed, that will trigger the enforcement right away
+		api.policyChanged <- true
+	}
 }
 
 type dependencyResourcesWrapper struct {
@@ -112,7 +195,7 @@ func (api *coreAPI) handleDependencyResourcesGet(writer http.ResponseWriter, req
 			}
 
 			eventLog := event.NewLog("resources", false)
-			instanceResources, resErr := codePlugin.Resources(instance.GetDeployName(), instance.CalculatedCodeParams, eventLog)
+			instanceResources, resErr := codePlugin.Resources(request.Context(), instance.GetDeployName(), instance.CalculatedCodeParams, eventLog)
 			if resErr != nil {
 				panic(fmt.Sprintf("Error while getting deployment resources for component instance %s: %s", instance.GetKey(), resErr))
 			}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"fmt"
+	"github.com/Aptomi/aptomi/pkg/engine/resolve"
+	"github.com/Aptomi/aptomi/pkg/runtime"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// DriftReportObject is an informational data structure with Kind and Constructor for DriftReport
+var DriftReportObject = &runtime.Info{
+	Kind:        "drift-report",
+	Constructor: func() runtime.Object { return &DriftReport{} },
+}
+
+// DriftReport represents drift detected for component instances in actual state
+type DriftReport struct {
+	runtime.TypeKind `yaml:",inline"`
+
+	// Instances is a map from component instance key to its last detected drift
+	Instances map[string]*resolve.DriftStatus
+}
+
+// GetDefaultColumns returns default set of columns to be displayed
+func (report *DriftReport) GetDefaultColumns() []string {
+	return []string{"Drifted Instances"}
+}
+
+// AsColumns returns DriftReport representation as columns
+func (report *DriftReport) AsColumns() map[string]string {
+	result := make(map[string]string)
+
+	keys := []string{}
+	for key := range report.Instances {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	drifted := []string{}
+	for _, key := range keys {
+		drifted = append(drifted, fmt.Sprintf("%s: %s", key, report.Instances[key].Message))
+	}
+
+	if len(drifted) > 0 {
+		result["Drifted Instances"] = strings.Join(drifted, "\n")
+	} else {
+		result["Drifted Instances"] = "(none)"
+	}
+
+	return result
+}
+
+func (api *coreAPI) handleActualStateDriftGet(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+	actualState, err := api.store.GetActualState()
+	if err != nil {
+		panic(fmt.Sprintf("Can't load actual state to get drift: %s", err))
+	}
+
+	instances := make(map[string]*resolve.DriftStatus)
+	for key, instance := range actualState.ComponentInstanceMap {
+		if instance.Drift != nil {
+			instances[key] = instance.Drift
+		}
+	}
+
+	api.contentType.WriteOne(writer, request, &DriftReport{
+		TypeKind:  DriftReportObject.GetTypeKind(),
+		Instances: instances,
+	})
+}
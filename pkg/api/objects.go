@@ -11,6 +11,9 @@ var (
 	// Objects is a list of all objects used in API
 	Objects = runtime.AppendAll([]*runtime.Info{
 		EndpointsObject,
+		DriftReportObject,
+		OrphanReportObject,
+		PluginTypesObject,
 		PolicyUpdateResultObject,
 		AuthSuccessObject,
 		AuthRequestObject,
@@ -0,0 +1,145 @@
+package api
+
+import (
+	"fmt"
+	"github.com/Aptomi/aptomi/pkg/engine"
+	"github.com/Aptomi/aptomi/pkg/engine/orphan"
+	"github.com/Aptomi/aptomi/pkg/event"
+	"github.com/Aptomi/aptomi/pkg/runtime"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+	"strings"
+)
+
+// OrphanReportObject is an informational data structure with Kind and Constructor for OrphanReport
+var OrphanReportObject = &runtime.Info{
+	Kind:        "orphan-report",
+	Constructor: func() runtime.Object { return &OrphanReport{} },
+}
+
+// OrphanReport represents orphaned deployments found in the clusters and, if requested, results of their deletion
+type OrphanReport struct {
+	runtime.TypeKind `yaml:",inline"`
+
+	// Orphans is a list of orphaned deployments
+	Orphans []*orphan.Orphan
+
+	// DryRun is true if orphaned deployments were not actually deleted
+	DryRun bool
+
+	// Errors is a map from deploy name to error, which happened while deleting orphaned deployment
+	Errors map[string]string
+}
+
+// GetDefaultColumns returns default set of columns to be displayed
+func (report *OrphanReport) GetDefaultColumns() []string {
+	return []string{"Orphaned Deployments"}
+}
+
+// AsColumns returns OrphanReport representation as columns
+func (report *OrphanReport) AsColumns() map[string]string {
+	result := make(map[string]string)
+
+	orphans := []string{}
+	for _, o := range report.Orphans {
+		str := fmt.Sprintf("%s/%s: %s", o.Cluster, o.CodeType, o.DeployName)
+		if err, failed := report.Errors[o.DeployName]; failed {
+			str += " (error: " + err + ")"
+		}
+		orphans = append(orphans, str)
+	}
+
+	if len(orphans) > 0 {
+		result["Orphaned Deployments"] = strings.Join(orphans, "\n")
+	} else {
+		result["Orphaned Deployments"] = "(none)"
+	}
+
+	return result
+}
+
+func (api *coreAPI) handleOrphansGet(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+	api.writeOrphanReport(writer, request, true)
+}
+
+func (api *coreAPI) handleOrphansDelete(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+	user := api.getUserRequired(request)
+	policy, _, err := api.store.GetPolicy(runtime.LastGen)
+	if err != nil {
+		panic(fmt.Sprintf("error while getting policy: %s", err))
+	}
+
+	// orphaned deployments could be anywhere in the clusters, so only domain admins are allowed to delete them
+	err = policy.View(user).ManageDomain()
+	if err != nil {
+		panic(fmt.Sprintf("can't delete orphaned deployments: %s", err))
+	}
+
+	// component instances which are being created right now don't exist in actual state yet, so they shouldn't be
+	// treated as orphans while any revision is in progress
+	err = api.checkNoRevisionInProgress()
+	if err != nil {
+		panic(fmt.Sprintf("can't delete orphaned deployments: %s", err))
+	}
+
+	// enforcer holds state lock while applying revision, so it can't start applying the next one in the meantime
+	api.stateLock.Lock()
+	defer api.stateLock.Unlock()
+
+	api.writeOrphanReport(writer, request, params.ByName("dryrun") == "true")
+}
+
+// checkNoRevisionInProgress returns an error if any revision is being applied. As planner supersedes all pending
+// revisions, only the last two could be in progress
+func (api *coreAPI) checkNoRevisionInProgress() error {
+	gen := runtime.LastGen
+	for i := 0; i < 2; i++ {
+		revision, err := api.store.GetRevision(gen)
+		if err != nil {
+			return fmt.Errorf("error while getting revision: %s", err)
+		}
+		if revision == nil {
+			return nil
+		}
+		if revision.Status == engine.RevisionStatusInProgress {
+			return fmt.Errorf("revision %d is in progress", revision.GetGeneration())
+		}
+		if revision.GetGeneration() <= runtime.FirstGen {
+			return nil
+		}
+		gen = revision.GetGeneration() - 1
+	}
+
+	return nil
+}
+
+func (api *coreAPI) writeOrphanReport(writer http.ResponseWriter, request *http.Request, dryRun bool) {
+	policy, _, err := api.store.GetPolicy(runtime.LastGen)
+	if err != nil {
+		panic(fmt.Sprintf("error while getting policy: %s", err))
+	}
+
+	actualState, err := api.store.GetActualState()
+	if err != nil {
+		panic(fmt.Sprintf("Can't load actual state to find orphaned deployments: %s", err))
+	}
+
+	eventLog := event.NewLog("api-orphans", true)
+	finder := orphan.NewFinder(policy, actualState, api.pluginRegistryFactory(), eventLog)
+
+	report := &OrphanReport{
+		TypeKind: OrphanReportObject.GetTypeKind(),
+		Orphans:  finder.Find(request.Context()),
+		DryRun:   dryRun,
+		Errors:   make(map[string]string),
+	}
+
+	for _, o := range report.Orphans {
+		err = finder.Delete(request.Context(), o, dryRun)
+		if err != nil {
+			report.Errors[o.DeployName] = err.Error()
+		}
+	}
+
+	api.contentType.WriteOne(writer, request, report)
+}
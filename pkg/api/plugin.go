@@ -0,0 +1,51 @@
+package api
+
+import (
+	"github.com/Aptomi/aptomi/pkg/runtime"
+	"github.com/Aptomi/aptomi/pkg/util"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+	"strings"
+)
+
+// PluginTypesObject is an informational data structure with Kind and Constructor for PluginTypes
+var PluginTypesObject = &runtime.Info{
+	Kind:        "plugin-types",
+	Constructor: func() runtime.Object { return &PluginTypes{} },
+}
+
+// PluginTypes represents cluster types and code types, for which plugins are registered on the server
+type PluginTypes struct {
+	runtime.TypeKind `yaml:",inline"`
+
+	// Types is a map from cluster type to the list of code types supported for it
+	Types map[string][]string
+}
+
+// GetDefaultColumns returns default set of columns to be displayed
+func (types *PluginTypes) GetDefaultColumns() []string {
+	return []string{"Cluster Type", "Code Types"}
+}
+
+// AsColumns returns PluginTypes representation as columns
+func (types *PluginTypes) AsColumns() map[string]string {
+	result := make(map[string]string)
+
+	clusterTypes := util.GetSortedStringKeys(types.Types)
+	codeTypes := []string{}
+	for _, clusterType := range clusterTypes {
+		codeTypes = append(codeTypes, strings.Join(types.Types[clusterType], ", "))
+	}
+
+	result["Cluster Type"] = strings.Join(clusterTypes, "\n")
+	result["Code Types"] = strings.Join(codeTypes, "\n")
+
+	return result
+}
+
+func (api *coreAPI) handlePluginTypesGet(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+	api.contentType.WriteOne(writer, request, &PluginTypes{
+		TypeKind: PluginTypesObject.GetTypeKind(),
+		Types:    api.pluginRegistryFactory().SupportedTypes(),
+	})
+}
@@ -70,13 +70,13 @@ var PolicyUpdateResultObject = &runtime.Info{
 	Constructor: func() runtime.Object { return &PolicyUpdateResult{} },
 }
 
-// PolicyUpdateResult represents results for the policy update request (estimated list of actions to be executed to
+// PolicyUpdateResult represents results for the policy update request (estimated plan of actions to be executed to
 // update existing actual state to the desired state)
 type PolicyUpdateResult struct {
 	runtime.TypeKind `yaml:",inline"`
 	PolicyGeneration runtime.Generation
 	PolicyChanged    bool
-	Actions          []string
+	Plan             *action.PlanView
 }
 
 // GetDefaultColumns returns default set of columns to be displayed
@@ -93,9 +93,9 @@ func (result *PolicyUpdateResult) AsColumns() map[string]string {
 		policyChangesStr = fmt.Sprintf("Gen %d (none)", result.PolicyGeneration)
 	}
 	var instanceChangesStr string
-	filteredActions := filterImportantActionKeys(result.Actions)
-	if len(filteredActions) > 0 {
-		instanceChangesStr = strings.Join(filteredActions, "\n")
+	importantActions := getImportantActions(result.Plan)
+	if len(importantActions) > 0 {
+		instanceChangesStr = strings.Join(importantActions, "\n")
 	} else {
 		instanceChangesStr = "(none)"
 	}
@@ -105,25 +105,24 @@ func (result *PolicyUpdateResult) AsColumns() map[string]string {
 	}
 }
 
-func filterImportantActionKeys(actions []string) []string {
+func getImportantActions(plan *action.PlanView) []string {
 	filtered := make([]string, 0)
+	if plan == nil {
+		return filtered
+	}
 
-	// remove #root, keep only create/update/delete actions
+	// keep only create/update/delete actions
 	importantActionKinds := map[string]string{
 		component.CreateActionObject.Kind: "[+]",
 		component.UpdateActionObject.Kind: "[*]",
 		component.DeleteActionObject.Kind: "[-]",
 	}
 
-	for _, action := range actions {
-		if strings.HasSuffix(action, "#root") {
-			action = strings.TrimSuffix(action, "#root")
-		}
-
-		for kind, kindShort := range importantActionKinds {
-			if strings.HasPrefix(action, kind+runtime.KeySeparator) {
-				actionStr := kindShort + " " + strings.TrimPrefix(action, kind+runtime.KeySeparator)
-				filtered = append(filtered, actionStr)
+	for _, node := range plan.Nodes {
+		for _, act := range node.Actions {
+			if kindShort, ok := importantActionKinds[act.Kind]; ok {
+				// remove #root
+				filtered = append(filtered, kindShort+" "+strings.TrimSuffix(act.ComponentKey, "#root"))
 			}
 		}
 	}
@@ -178,6 +177,7 @@ func filterImportantActionKeys(actions []string) []string {
 
 func (api *coreAPI) handlePolicyUpdate(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
 	objects := api.readLang(request)
+	force, _ := strconv.ParseBool(params.ByName("force"))
 
 	user := api.getUserRequired(request)
 
@@ -187,6 +187,13 @@ func (api *coreAPI) handlePolicyUpdate(writer http.ResponseWriter, request *http
 		panic(fmt.Sprintf("Error while loading current policy: %s", err))
 	}
 	for _, obj := range objects {
+		// protection could only be removed from objects if it's explicitly forced by a user with sufficient role
+		if protectable, ok := obj.(lang.Protectable); ok && !protectable.IsProtected() {
+			if errProtected := api.checkProtected(policy, user, obj, force); errProtected != nil {
+				panic(fmt.Sprintf("Error while adding updated object to policy: %s", errProtected))
+			}
+		}
+
 		errAdd := policy.AddObject(obj)
 		if errAdd != nil {
 			panic(fmt.Sprintf("Error while adding updated object to policy: %s", errAdd))
@@ -233,6 +240,7 @@ func (api *coreAPI) handlePolicyUpdate(writer http.ResponseWriter, request *http
 
 func (api *coreAPI) handlePolicyDelete(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
 	objects := api.readLang(request)
+	force, _ := strconv.ParseBool(params.ByName("force"))
 
 	user := api.getUserRequired(request)
 
@@ -246,6 +254,12 @@ func (api *coreAPI) handlePolicyDelete(writer http.ResponseWriter, request *http
 		if errManage != nil {
 			panic(fmt.Sprintf("Error while removing object from policy: %s", errManage))
 		}
+
+		// protected objects could only be removed if it's explicitly forced by a user with sufficient role
+		if errProtected := api.checkProtected(currentPolicy, user, obj, force); errProtected != nil {
+			panic(fmt.Sprintf("Error while removing object from policy: %s", errProtected))
+		}
+
 		currentPolicy.RemoveObject(obj)
 	}
 
@@ -277,6 +291,26 @@ func (api *coreAPI) handlePolicyDelete(writer http.ResponseWriter, request *http
 	}
 }
 
+func (api *coreAPI) checkProtected(policy *lang.Policy, user *lang.User, obj lang.Base, force bool) error {
+	// check the object stored in the policy, as protection could be omitted in the object being removed
+	existing, err := policy.GetObject(obj.GetKind(), obj.GetName(), obj.GetNamespace())
+	if err != nil || existing == nil {
+		// object is not present in the policy, so there is nothing to protect
+		return nil
+	}
+
+	protectable, ok := existing.(lang.Protectable)
+	if !ok || !protectable.IsProtected() {
+		return nil
+	}
+
+	if !force {
+		return fmt.Errorf("object '%s/%s/%s' is protected, its removal or unprotection should be forced", obj.GetNamespace(), obj.GetKind(), obj.GetName())
+	}
+
+	return policy.View(user).ManageProtectedObject(obj)
+}
+
 func (api *coreAPI) getPolicyUpdateResult(writer http.ResponseWriter, request *http.Request, changed bool, policyData *engine.PolicyData) {
 	desiredPolicyGen := policyData.GetGeneration()
 	desiredPolicy, _, err := api.store.GetPolicy(desiredPolicyGen)
@@ -296,21 +330,16 @@ func (api *coreAPI) getPolicyUpdateResult(writer http.ResponseWriter, request *h
 	// todo: add request id to the event log scope
 	eventLog := event.NewLog("api-policy-update", true)
 	resolver := resolve.NewPolicyResolver(desiredPolicy, api.externalData, eventLog)
+	resolver.SetActualState(actualState)
 	desiredState := resolver.ResolveAllDependencies()
 	stateDiff := diff.NewPolicyResolutionDiff(desiredState, actualState)
 
 	// TODO: we need to start showing dependency status in API result, as well as links/cmds to view logs
 
-	actions := []string{}
-	_ = stateDiff.ActionPlan.Apply(action.WrapSequential(func(act action.Base) error {
-		actions = append(actions, act.GetName())
-		return nil
-	}), action.NewApplyResultUpdaterImpl())
-
 	api.contentType.WriteOne(writer, request, &PolicyUpdateResult{
 		TypeKind:         PolicyUpdateResultObject.GetTypeKind(),
 		PolicyGeneration: desiredPolicyGen,
 		PolicyChanged:    changed,
-		Actions:          actions,
+		Plan:             stateDiff.GetPlanView(),
 	})
 }
@@ -27,6 +27,58 @@ func (api *coreAPI) handleRevisionGet(writer http.ResponseWriter, request *http.
 	}
 }
 
+func (api *coreAPI) handleRevisionAbort(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+	user := api.getUserRequired(request)
+	policy, _, err := api.store.GetPolicy(runtime.LastGen)
+	if err != nil {
+		panic(fmt.Sprintf("error while getting policy: %s", err))
+	}
+
+	// revision covers the whole domain, so only domain admins are allowed to abort it
+	err = policy.View(user).ManageDomain()
+	if err != nil {
+		panic(fmt.Sprintf("can't abort revision: %s", err))
+	}
+
+	gen := runtime.ParseGeneration(params.ByName("gen"))
+
+	err = api.revisionAborter(gen)
+	if err != nil {
+		panic(fmt.Sprintf("error while aborting revision: %s", err))
+	}
+
+	api.handleRevisionGet(writer, request, params)
+}
+
+func (api *coreAPI) handleRevisionOverride(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+	user := api.getUserRequired(request)
+	policy, _, err := api.store.GetPolicy(runtime.LastGen)
+	if err != nil {
+		panic(fmt.Sprintf("error while getting policy: %s", err))
+	}
+
+	// safety limits are configured for the whole domain, so only domain admins are allowed to override them
+	err = policy.View(user).ManageDomain()
+	if err != nil {
+		panic(fmt.Sprintf("can't override revision: %s", err))
+	}
+
+	revision, err := api.revisionOverrider(runtime.ParseGeneration(params.ByName("gen")))
+	if err != nil {
+		panic(fmt.Sprintf("error while overriding revision: %s", err))
+	}
+
+	if revision == nil {
+		api.contentType.WriteOneWithStatus(writer, request, nil, http.StatusNotFound)
+		return
+	}
+
+	api.contentType.WriteOne(writer, request, revision)
+
+	// signal to the channel that revision has been overridden, that will trigger the enforcement right away
+	api.policyChanged <- true
+}
+
 func (api *coreAPI) handleRevisionGetByPolicy(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
 	policyGen := params.ByName("policy")
 
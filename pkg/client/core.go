@@ -14,14 +14,16 @@ type Core interface {
 	Revision() Revision
 	State() State
 	User() User
+	Plugins() Plugins
 	Version() Version
 }
 
 // Policy is the interface for managing Policy
 type Policy interface {
 	Show(gen runtime.Generation) (*engine.PolicyData, error)
-	Apply([]runtime.Object) (*api.PolicyUpdateResult, error)
-	Delete([]runtime.Object) (*api.PolicyUpdateResult, error)
+	Apply(updated []runtime.Object, force bool) (*api.PolicyUpdateResult, error)
+	Delete(objects []runtime.Object, force bool) (*api.PolicyUpdateResult, error)
+	Suspend(ns string, name string, suspended bool) (*api.PolicyUpdateResult, error)
 }
 
 // Endpoints is the interface for getting info about endpoints
@@ -33,11 +35,16 @@ type Endpoints interface {
 type Revision interface {
 	Show(gen runtime.Generation) (*engine.Revision, error)
 	ShowByPolicy(policyGen runtime.Generation) (*engine.Revision, error)
+	Abort(gen runtime.Generation) (*engine.Revision, error)
+	Override(gen runtime.Generation) (*engine.Revision, error)
 }
 
-// State is the interface for resetting Actual State
+// State is the interface for resetting Actual State, getting info about its drift and orphaned deployments
 type State interface {
 	Reset() (*engine.Revision, error)
+	Drift() (*api.DriftReport, error)
+	Orphans() (*api.OrphanReport, error)
+	DeleteOrphans(dryRun bool) (*api.OrphanReport, error)
 }
 
 // User is the interface for auth and user management
@@ -45,6 +52,11 @@ type User interface {
 	Login(username, password string) (*api.AuthSuccess, error)
 }
 
+// Plugins is the interface for getting info about plugins registered on the server
+type Plugins interface {
+	Types() (*api.PluginTypes, error)
+}
+
 // Version is the interface for getting current server version
 type Version interface {
 	Show() (*version.BuildInfo, error)
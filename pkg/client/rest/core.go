@@ -36,6 +36,10 @@ func (client *coreClient) User() client.User {
 	return &userClient{client.cfg, client.httpClient}
 }
 
+func (client *coreClient) Plugins() client.Plugins {
+	return &pluginsClient{client.cfg, client.httpClient}
+}
+
 func (client *coreClient) Version() client.Version {
 	return &versionClient{client.cfg, client.httpClient}
 }
@@ -0,0 +1,26 @@
+package rest
+
+import (
+	"fmt"
+	"github.com/Aptomi/aptomi/pkg/api"
+	"github.com/Aptomi/aptomi/pkg/client/rest/http"
+	"github.com/Aptomi/aptomi/pkg/config"
+)
+
+type pluginsClient struct {
+	cfg        *config.Client
+	httpClient http.Client
+}
+
+func (client *pluginsClient) Types() (*api.PluginTypes, error) {
+	response, err := client.httpClient.GET("/plugins/types", api.PluginTypesObject)
+	if err != nil {
+		return nil, err
+	}
+
+	if serverError, ok := response.(*api.ServerError); ok {
+		return nil, fmt.Errorf("server error: %s", serverError.Error)
+	}
+
+	return response.(*api.PluginTypes), nil
+}
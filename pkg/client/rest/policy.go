@@ -27,8 +27,8 @@ func (client *policyClient) Show(gen runtime.Generation) (*engine.PolicyData, er
 	return response.(*engine.PolicyData), nil
 }
 
-func (client *policyClient) Apply(updated []runtime.Object) (*api.PolicyUpdateResult, error) {
-	response, err := client.httpClient.POSTSlice("/policy", api.PolicyUpdateResultObject, updated)
+func (client *policyClient) Apply(updated []runtime.Object, force bool) (*api.PolicyUpdateResult, error) {
+	response, err := client.httpClient.POSTSlice(fmt.Sprintf("/policy/force/%t", force), api.PolicyUpdateResultObject, updated)
 	if err != nil {
 		return nil, err
 	}
@@ -40,8 +40,21 @@ func (client *policyClient) Apply(updated []runtime.Object) (*api.PolicyUpdateRe
 	return response.(*api.PolicyUpdateResult), nil
 }
 
-func (client *policyClient) Delete(updated []runtime.Object) (*api.PolicyUpdateResult, error) {
-	response, err := client.httpClient.DELETESlice("/policy", api.PolicyUpdateResultObject, updated)
+func (client *policyClient) Delete(updated []runtime.Object, force bool) (*api.PolicyUpdateResult, error) {
+	response, err := client.httpClient.DELETESlice(fmt.Sprintf("/policy/force/%t", force), api.PolicyUpdateResultObject, updated)
+	if err != nil {
+		return nil, err
+	}
+
+	if serverError, ok := response.(*api.ServerError); ok {
+		return nil, fmt.Errorf("server error: %s", serverError.Error)
+	}
+
+	return response.(*api.PolicyUpdateResult), nil
+}
+
+func (client *policyClient) Suspend(ns string, name string, suspended bool) (*api.PolicyUpdateResult, error) {
+	response, err := client.httpClient.POST(fmt.Sprintf("/policy/dependency/%s/%s/suspend/%t", ns, name, suspended), api.PolicyUpdateResultObject, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -40,3 +40,29 @@ func (client *revisionClient) ShowByPolicy(policyGen runtime.Generation) (*engin
 
 	return response.(*engine.Revision), nil
 }
+
+func (client *revisionClient) Abort(gen runtime.Generation) (*engine.Revision, error) {
+	response, err := client.httpClient.POST(fmt.Sprintf("/revision/gen/%d/abort", gen), engine.RevisionObject, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if serverError, ok := response.(*api.ServerError); ok {
+		return nil, fmt.Errorf("server error: %s", serverError.Error)
+	}
+
+	return response.(*engine.Revision), nil
+}
+
+func (client *revisionClient) Override(gen runtime.Generation) (*engine.Revision, error) {
+	response, err := client.httpClient.POST(fmt.Sprintf("/revision/gen/%d/override", gen), engine.RevisionObject, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if serverError, ok := response.(*api.ServerError); ok {
+		return nil, fmt.Errorf("server error: %s", serverError.Error)
+	}
+
+	return response.(*engine.Revision), nil
+}
@@ -1,6 +1,8 @@
 package rest
 
 import (
+	"fmt"
+	"github.com/Aptomi/aptomi/pkg/api"
 	"github.com/Aptomi/aptomi/pkg/client/rest/http"
 	"github.com/Aptomi/aptomi/pkg/config"
 	"github.com/Aptomi/aptomi/pkg/engine"
@@ -19,3 +21,42 @@ func (client *stateClient) Reset() (*engine.Revision, error) {
 
 	return revision.(*engine.Revision), nil
 }
+
+func (client *stateClient) Drift() (*api.DriftReport, error) {
+	response, err := client.httpClient.GET("/actualstate/drift", api.DriftReportObject)
+	if err != nil {
+		return nil, err
+	}
+
+	if serverError, ok := response.(*api.ServerError); ok {
+		return nil, fmt.Errorf("server error: %s", serverError.Error)
+	}
+
+	return response.(*api.DriftReport), nil
+}
+
+func (client *stateClient) Orphans() (*api.OrphanReport, error) {
+	response, err := client.httpClient.GET("/actualstate/orphans", api.OrphanReportObject)
+	if err != nil {
+		return nil, err
+	}
+
+	if serverError, ok := response.(*api.ServerError); ok {
+		return nil, fmt.Errorf("server error: %s", serverError.Error)
+	}
+
+	return response.(*api.OrphanReport), nil
+}
+
+func (client *stateClient) DeleteOrphans(dryRun bool) (*api.OrphanReport, error) {
+	response, err := client.httpClient.DELETE(fmt.Sprintf("/actualstate/orphans/dryrun/%t", dryRun), api.OrphanReportObject)
+	if err != nil {
+		return nil, err
+	}
+
+	if serverError, ok := response.(*api.ServerError); ok {
+		return nil, fmt.Errorf("server error: %s", serverError.Error)
+	}
+
+	return response.(*api.OrphanReport), nil
+}
@@ -0,0 +1,58 @@
+package config
+
+import "time"
+
+const (
+	// HookStageBefore means that hook gets called before the action
+	HookStageBefore = "before"
+
+	// HookStageAfter means that hook gets called after the action
+	HookStageAfter = "after"
+)
+
+// Hook represents a webhook, which gets called before or after create/update/delete actions on matching component
+// instances (e.g. "before deleting any database instance, POST to backup service and wait for 200")
+type Hook struct {
+	// Name is a hook name, used for logging
+	Name string `validate:"required"`
+
+	// Stage is when hook gets called, "before" or "after" the action
+	Stage string `validate:"required,eq=before|eq=after"`
+
+	// Actions is a list of actions (create, update, delete) hook gets called for. If empty, it's called for all of them
+	Actions []string `validate:"dive,eq=create|eq=update|eq=delete"`
+
+	// Service is a name of the service hook gets called for. If empty, it's called for all services
+	Service string `validate:"-"`
+
+	// Component is a name of the service component hook gets called for. If empty, it's called for all components
+	Component string `validate:"-"`
+
+	// URL is where JSON payload will be POSTed to. Hook is considered failed if it doesn't respond with 2xx status
+	URL string `validate:"required,url"`
+
+	// Timeout is how long to wait for hook to respond (no timeout if zero)
+	Timeout time.Duration `validate:"-"`
+}
+
+// Matches returns true if hook should be called at a given stage of the action for a given component
+func (hook *Hook) Matches(stage string, action string, service string, component string) bool {
+	if hook.Stage != stage {
+		return false
+	}
+	if len(hook.Service) > 0 && hook.Service != service {
+		return false
+	}
+	if len(hook.Component) > 0 && hook.Component != component {
+		return false
+	}
+	if len(hook.Actions) == 0 {
+		return true
+	}
+	for _, hookAction := range hook.Actions {
+		if hookAction == action {
+			return true
+		}
+	}
+	return false
+}
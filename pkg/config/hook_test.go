@@ -0,0 +1,17 @@
+package config
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestConfigHookMatches(t *testing.T) {
+	hook := &Hook{Stage: HookStageBefore, Actions: []string{"delete"}, Service: "database"}
+	assert.True(t, hook.Matches(HookStageBefore, "delete", "database", "mysql"), "Hook should match action on the given service")
+	assert.False(t, hook.Matches(HookStageAfter, "delete", "database", "mysql"), "Hook should not match another stage")
+	assert.False(t, hook.Matches(HookStageBefore, "create", "database", "mysql"), "Hook should not match another action")
+	assert.False(t, hook.Matches(HookStageBefore, "delete", "web", "nginx"), "Hook should not match another service")
+
+	hook = &Hook{Stage: HookStageAfter}
+	assert.True(t, hook.Matches(HookStageAfter, "update", "web", "nginx"), "Hook without filters should match all actions")
+}
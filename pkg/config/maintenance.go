@@ -0,0 +1,69 @@
+package config
+
+import (
+	"github.com/Aptomi/aptomi/pkg/util/cron"
+	"time"
+)
+
+// Maintenance represents maintenance windows for enforcer. Outside of them enforcer still resolves policy and records
+// plans as scheduled revisions, but doesn't apply any changes until windows get open
+type Maintenance struct {
+	// Windows is a list of maintenance windows. If empty, changes could be applied at any time
+	Windows []MaintenanceWindow `validate:"dive"`
+
+	// AllowAdditive allows purely additive changes (e.g. creating new component instances or attaching new
+	// dependencies) to be applied outside of maintenance windows
+	AllowAdditive bool `validate:"-"`
+}
+
+// MaintenanceWindow represents a recurring period of time when changes are allowed to be applied (e.g. every
+// Saturday at 2am for 4 hours). Window without clusters applies to all clusters, which don't have their own windows.
+// Window could be assigned to a cluster either by listing it in clusters or by "maintenance-window" cluster label
+type MaintenanceWindow struct {
+	// Name is a window name, which could be referenced from cluster labels
+	Name string `validate:"required"`
+
+	// Schedule is a cron-like expression defining when window starts (e.g. "0 2 * * 6")
+	Schedule string `validate:"required,cron"`
+
+	// Duration is how long window stays open after it starts
+	Duration time.Duration `validate:"required"`
+
+	// Clusters is a list of clusters window applies to
+	Clusters []string `validate:"-"`
+}
+
+// IsOpen returns true if maintenance window is open at a given time
+func (window *MaintenanceWindow) IsOpen(t time.Time) bool {
+	schedule, err := cron.Parse(window.Schedule)
+	if err != nil {
+		return false
+	}
+
+	// window is open if it started within the last duration
+	start := schedule.Next(t.Add(-window.Duration))
+	return !start.IsZero() && !start.After(t)
+}
+
+// NextOpen returns the first time starting from a given one, when maintenance window is open. If window will never
+// be open, zero time is returned
+func (window *MaintenanceWindow) NextOpen(t time.Time) time.Time {
+	if window.IsOpen(t) {
+		return t
+	}
+	schedule, err := cron.Parse(window.Schedule)
+	if err != nil {
+		return time.Time{}
+	}
+	return schedule.Next(t)
+}
+
+// AppliesTo returns true if maintenance window has been explicitly assigned to a given cluster
+func (window *MaintenanceWindow) AppliesTo(cluster string) bool {
+	for _, name := range window.Clusters {
+		if name == cluster {
+			return true
+		}
+	}
+	return false
+}
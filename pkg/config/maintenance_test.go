@@ -0,0 +1,26 @@
+package config
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestConfigMaintenanceWindow(t *testing.T) {
+	// every Saturday at 2am for 4 hours
+	window := &MaintenanceWindow{Name: "weekend", Schedule: "0 2 * * 6", Duration: 4 * time.Hour}
+
+	saturday := time.Date(2017, 11, 4, 0, 0, 0, 0, time.UTC)
+	assert.False(t, window.IsOpen(saturday.Add(time.Hour)), "Window should be closed before it starts")
+	assert.True(t, window.IsOpen(saturday.Add(2*time.Hour)), "Window should be open when it starts")
+	assert.True(t, window.IsOpen(saturday.Add(5*time.Hour+59*time.Minute)), "Window should be open until duration is over")
+	assert.False(t, window.IsOpen(saturday.Add(6*time.Hour)), "Window should be closed after duration is over")
+
+	assert.Equal(t, saturday.Add(3*time.Hour), window.NextOpen(saturday.Add(3*time.Hour)), "Next open time should be now if window is open")
+	assert.Equal(t, saturday.Add(2*time.Hour), window.NextOpen(saturday), "Next open time should be when window starts")
+	assert.Equal(t, saturday.AddDate(0, 0, 7).Add(2*time.Hour), window.NextOpen(saturday.Add(6*time.Hour)), "Next open time should be on the next week")
+
+	assert.False(t, window.AppliesTo("cluster-prod"), "Window without clusters should not be assigned to any cluster")
+	window.Clusters = []string{"cluster-prod"}
+	assert.True(t, window.AppliesTo("cluster-prod"), "Window should be assigned to the listed cluster")
+}
@@ -4,9 +4,16 @@ import "time"
 
 // Plugins represents configs for all plugins
 type Plugins struct {
-	K8s    K8s
-	K8sRaw K8sRaw
-	Helm   Helm
+	// Types is a map from cluster type to the list of code types, for which plugins should be enabled. If it's empty,
+	// all types provided by built-in and external plugins are enabled
+	Types map[string][]string
+
+	K8s      K8s
+	K8sRaw   K8sRaw
+	Helm     Helm
+	Webhook  Webhook
+	External External
+	Local    Local
 }
 
 // K8s represents config for Kubernetes cluster plugin
@@ -17,9 +24,68 @@ type K8s struct {
 // K8sRaw represents config for Kubernetes Raw code plugin
 type K8sRaw struct {
 	DataNamespace string
+
+	// WaitTimeout is how long to wait for workloads to become ready, if waitForReady is enabled in code params
+	WaitTimeout time.Duration
 }
 
 // Helm represents configs for Helm code plugin
 type Helm struct {
 	Timeout time.Duration
+
+	// Tillerless enables rendering charts locally and applying rendered manifests directly instead of using Tiller
+	// for all clusters, unless it's overridden in the cluster config
+	Tillerless bool
+
+	// DataNamespace is a namespace, where state of the releases is stored in tillerless mode
+	DataNamespace string
+
+	// ChartsDir is a directory with local charts (directories or archives), which could be referenced from code
+	// params using relative path. If it's empty, local charts are disabled
+	ChartsDir string
+
+	// ChartsCacheDir is a directory, where charts downloaded from repositories are cached between enforcement cycles
+	ChartsCacheDir string
+
+	// SecretsUser is a name under which credentials for chart repositories are stored in the secret loader. Its
+	// secrets are keyed by repository host and credential name (e.g. "charts.example.com/password")
+	SecretsUser string
+}
+
+// Webhook represents configs for Webhook code plugin
+type Webhook struct {
+	// Timeout is a timeout for a single HTTP request to the webhook
+	Timeout time.Duration
+
+	// Retries is a number of times failed request gets retried (network errors and 5xx responses are retried only)
+	Retries int
+
+	// RetryDelay is a delay between retries
+	RetryDelay time.Duration
+
+	// PollInterval is an interval between checks of asynchronous operation status
+	PollInterval time.Duration
+
+	// MaxPollDuration is how long to wait for asynchronous operation to complete, before giving up on it
+	MaxPollDuration time.Duration
+
+	// SecretsUser is a name under which webhook auth headers are stored in the secret loader. Its secrets are a map
+	// from webhook URL host to the value of Authorization header sent to that host
+	SecretsUser string
+}
+
+// External represents configs for external plugins, which are discovered as executables in a given directory
+type External struct {
+	// Dir is a directory with external plugin executables. If it's empty, external plugins are disabled
+	Dir string
+
+	// Timeout is a timeout for a single call to external plugin (0 means no timeout)
+	Timeout time.Duration
+}
+
+// Local represents configs for local cluster plugin
+type Local struct {
+	// StateDir is a directory, where PIDs of the started processes are persisted, so processes left running by the
+	// previous server run could be stopped on startup
+	StateDir string
 }
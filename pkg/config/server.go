@@ -12,6 +12,8 @@ type Server struct {
 	Users                UserSources     `validate:"required"`
 	SecretsDir           string          `validate:"omitempty,dir"` // secrets is not a first-class citizen yet, so it's not required
 	Enforcer             Enforcer        `validate:"required"`
+	Drift                Drift           `validate:"-"`
+	Hooks                []Hook          `validate:"dive"`
 	DomainAdminOverrides map[string]bool `validate:"-"`
 	Auth                 ServerAuth      `validate:"-"`
 }
@@ -39,6 +41,46 @@ type Enforcer struct {
 	Disabled  bool          `validate:"-"`
 	Noop      bool          `validate:"-"`
 	NoopSleep time.Duration `validate:"-"`
+
+	// Adopt enables adoption of deployments, which already exist in the cloud, into actual state instead of creating them
+	Adopt bool `validate:"-"`
+
+	// AdoptTimeout limits how long existing deployments could be inspected for adoption (enforcer interval if zero)
+	AdoptTimeout time.Duration `validate:"-"`
+
+	// ActionTimeout limits how long a single action could be applied for (no limit if zero)
+	ActionTimeout time.Duration `validate:"-"`
+
+	// RevisionTimeout limits how long the whole revision could be applied for (no limit if zero)
+	RevisionTimeout time.Duration `validate:"-"`
+
+	// BlastRadius defines safety limits for destructive revisions
+	BlastRadius BlastRadius `validate:"-"`
+
+	// Maintenance defines maintenance windows, outside of which changes are not applied
+	Maintenance Maintenance `validate:"omitempty"`
+}
+
+// BlastRadius represents safety limits, which prevent enforcer from applying revisions that destroy too much at once.
+// Revision which exceeds them (or deletes anything in a protected cluster) gets blocked until it's explicitly overridden
+type BlastRadius struct {
+	// MaxDeletes is the max number of component instances a revision is allowed to delete (no limit if zero)
+	MaxDeletes int `validate:"-"`
+
+	// MaxDeletesPercent is the max percentage of component instances in actual state a revision is allowed to delete
+	// (no limit if zero)
+	MaxDeletesPercent int `validate:"-"`
+}
+
+// Drift represents configs for Drift detection background process that periodically checks that component instances
+// recorded in actual state are still running in the cloud and match their parameters.
+type Drift struct {
+	Interval time.Duration `validate:"-"`
+	Disabled bool          `validate:"-"`
+	Repair   bool          `validate:"-"`
+
+	// Timeout limits how long a single drift detection run could inspect the cloud for (drift interval if zero)
+	Timeout time.Duration `validate:"-"`
 }
 
 // ServerAuth represents server auth config
@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"github.com/Aptomi/aptomi/pkg/util/cron"
 	english "github.com/go-playground/locales/en"
 	"github.com/go-playground/universal-translator"
 	"gopkg.in/go-playground/validator.v9"
@@ -38,6 +39,7 @@ func NewValidator(config Base) *Validator {
 	// independent validators
 	_ = result.RegisterValidation("dir", validateDir)
 	_ = result.RegisterValidation("file", validateFile)
+	_ = result.RegisterValidation("cron", validateCron)
 
 	// default translations
 	eng := english.New()
@@ -61,6 +63,10 @@ func NewValidator(config Base) *Validator {
 			tag:         "file",
 			translation: fmt.Sprintf("{0} must point to an existing file, but found '{1}'"),
 		},
+		{
+			tag:         "cron",
+			translation: fmt.Sprintf("{0} must be a valid cron-like schedule, but found '{1}'"),
+		},
 	}
 	for _, t := range translations {
 		err = result.RegisterTranslation(t.tag, trans, registrationFunc(t.tag, t.translation), translateFunc)
@@ -131,3 +137,9 @@ func validateFile(fl validator.FieldLevel) bool {
 	}
 	return false
 }
+
+// checks if a given string is a valid cron-like schedule
+func validateCron(fl validator.FieldLevel) bool {
+	_, err := cron.Parse(fl.Field().String())
+	return err == nil
+}
@@ -8,10 +8,11 @@ import (
 )
 
 type testStruct struct {
-	Host     string `validate:"required,hostname|ip"`
-	Port     int    `validate:"required,min=1,max=65535"`
-	SomeDir  string `validate:"required,dir"`
-	SomeFile string `validate:"omitempty,file"`
+	Host         string `validate:"required,hostname|ip"`
+	Port         int    `validate:"required,min=1,max=65535"`
+	SomeDir      string `validate:"required,dir"`
+	SomeFile     string `validate:"omitempty,file"`
+	SomeSchedule string `validate:"omitempty,cron"`
 }
 
 func (t *testStruct) IsDebug() bool {
@@ -120,6 +121,24 @@ func TestConfigValidation(t *testing.T) {
 			},
 			false,
 		},
+		{
+			&testStruct{
+				Host:         "0.0.0.0",
+				Port:         80,
+				SomeDir:      "/tmp",
+				SomeSchedule: "0 2 * * 6",
+			},
+			true,
+		},
+		{
+			&testStruct{
+				Host:         "0.0.0.0",
+				Port:         80,
+				SomeDir:      "/tmp",
+				SomeSchedule: "0 25 * * 6",
+			},
+			false,
+		},
 	}
 	for _, test := range tests {
 		val := NewValidator(test.config)
@@ -0,0 +1,131 @@
+package adopt
+
+import (
+	"context"
+	"fmt"
+	"github.com/Aptomi/aptomi/pkg/engine/actual"
+	"github.com/Aptomi/aptomi/pkg/engine/drift"
+	"github.com/Aptomi/aptomi/pkg/engine/resolve"
+	"github.com/Aptomi/aptomi/pkg/event"
+	"github.com/Aptomi/aptomi/pkg/lang"
+	"github.com/Aptomi/aptomi/pkg/plugin"
+	"time"
+)
+
+// Adopter records code component instances from desired state, which deployments already exist in the cloud, into
+// actual state
+type Adopter struct {
+	desiredPolicy      *lang.Policy
+	desiredState       *resolve.PolicyResolution
+	actualState        *resolve.PolicyResolution
+	actualStateUpdater actual.StateUpdater
+	plugins            plugin.Registry
+	eventLog           *event.Log
+}
+
+// NewAdopter creates a new Adopter
+func NewAdopter(desiredPolicy *lang.Policy, desiredState *resolve.PolicyResolution, actualState *resolve.PolicyResolution, actualStateUpdater actual.StateUpdater, plugins plugin.Registry, eventLog *event.Log) *Adopter {
+	return &Adopter{
+		desiredPolicy:      desiredPolicy,
+		desiredState:       desiredState,
+		actualState:        actualState,
+		actualStateUpdater: actualStateUpdater,
+		plugins:            plugins,
+		eventLog:           eventLog,
+	}
+}
+
+// Adopt goes over all code component instances, which are present in desired state, but missing in actual state, and
+// asks the corresponding code plugins whether deployments with the same names already exist in the cloud. Existing
+// deployments get recorded into actual state (both in memory and via state updater), so that engine won't try to
+// create them. If an adopted deployment doesn't match code params, it gets marked as drifted and will be updated
+// by the engine. Once context is done, the rest of component instances are left unchecked. Returns a set of adopted
+// component instance keys.
+func (adopter *Adopter) Adopt(ctx context.Context) map[string]bool {
+	result := make(map[string]bool)
+
+	for key, instance := range adopter.desiredState.ComponentInstanceMap {
+		if !instance.IsCode {
+			continue
+		}
+		if _, exists := adopter.actualState.ComponentInstanceMap[key]; exists {
+			continue
+		}
+
+		if ctx.Err() != nil {
+			adopter.eventLog.LogError(fmt.Errorf("adoption interrupted, component instance '%s' and the rest are not adopted: %s", key, ctx.Err()))
+			break
+		}
+
+		adopted, err := adopter.adopt(ctx, instance)
+		if err != nil {
+			adopter.eventLog.LogError(fmt.Errorf("error while adopting component instance '%s': %s", key, err))
+			continue
+		}
+		if adopted {
+			result[key] = true
+		}
+	}
+
+	return result
+}
+
+// adopt checks whether deployment exists for a given component instance and, if it does, records a copy of
+// component instance into actual state
+func (adopter *Adopter) adopt(ctx context.Context, instance *resolve.ComponentInstance) (bool, error) {
+	codePlugin, err := drift.CodePluginFor(instance, adopter.desiredPolicy, adopter.plugins)
+	if err != nil {
+		return false, err
+	}
+	if codePlugin == nil {
+		return false, nil
+	}
+
+	inspector, ok := codePlugin.(plugin.DeploymentInspector)
+	if !ok {
+		return false, nil
+	}
+
+	deployName := instance.GetDeployName()
+	status, err := inspector.Inspect(ctx, deployName, instance.CalculatedCodeParams, adopter.eventLog)
+	if err != nil {
+		return false, err
+	}
+	if !status.Exists {
+		return false, nil
+	}
+
+	// copy instance, so actual state won't share it with desired state
+	adopted := instance.MakeCopy()
+	adopted.UpdateTimes(time.Now(), time.Now())
+
+	// if deployment doesn't match code params, engine should update it
+	if !status.Matches {
+		adopted.Drift = &resolve.DriftStatus{
+			Exists:     true,
+			Message:    status.Message,
+			DetectedAt: time.Now(),
+			Repair:     true,
+		}
+	}
+
+	endpoints, err := codePlugin.Endpoints(ctx, deployName, instance.CalculatedCodeParams, adopter.eventLog)
+	if err != nil {
+		adopter.eventLog.LogError(fmt.Errorf("error while getting endpoints for adopted component instance '%s': %s", instance.GetKey(), err))
+	} else {
+		adopted.Endpoints = endpoints
+	}
+
+	adopter.actualState.ComponentInstanceMap[instance.GetKey()] = adopted
+	err = adopter.actualStateUpdater.Save(adopted)
+	if err != nil {
+		return false, fmt.Errorf("error while updating actual state: %s", err)
+	}
+
+	adopter.eventLog.WithFields(event.Fields{
+		"componentKey": instance.GetKey(),
+		"matches":      status.Matches,
+	}).Infof("Adopted existing deployment %s for component instance %s", deployName, instance.GetKey())
+
+	return true, nil
+}
@@ -0,0 +1,132 @@
+package adopt
+
+import (
+	"context"
+	"github.com/Aptomi/aptomi/pkg/config"
+	"github.com/Aptomi/aptomi/pkg/engine/actual"
+	"github.com/Aptomi/aptomi/pkg/engine/diff"
+	"github.com/Aptomi/aptomi/pkg/engine/resolve"
+	"github.com/Aptomi/aptomi/pkg/event"
+	"github.com/Aptomi/aptomi/pkg/lang"
+	"github.com/Aptomi/aptomi/pkg/lang/builder"
+	"github.com/Aptomi/aptomi/pkg/plugin"
+	"github.com/Aptomi/aptomi/pkg/plugin/fake"
+	"github.com/Aptomi/aptomi/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestAdoptNothingExists(t *testing.T) {
+	b := makePolicyBuilder()
+	desiredState := resolvePolicy(t, b)
+	actualState := resolve.NewPolicyResolution(false)
+
+	adopted := NewAdopter(b.Policy(), desiredState, actualState, actual.NewNoOpActionStateUpdater(), mockRegistry(&plugin.DeploymentStatus{}), event.NewLog("test-adopt", false)).Adopt(context.Background())
+
+	assert.Equal(t, 0, len(adopted), "Nothing should be adopted")
+	assert.Equal(t, 0, len(actualState.ComponentInstanceMap), "Actual state should remain empty")
+}
+
+func TestAdoptExistingDeployment(t *testing.T) {
+	b := makePolicyBuilder()
+	desiredState := resolvePolicy(t, b)
+	actualState := resolve.NewPolicyResolution(false)
+
+	adopted := NewAdopter(b.Policy(), desiredState, actualState, actual.NewNoOpActionStateUpdater(), mockRegistry(&plugin.DeploymentStatus{Exists: true, Matches: true}), event.NewLog("test-adopt", false)).Adopt(context.Background())
+
+	assert.Equal(t, 1, len(adopted), "Code component instance should be adopted")
+	for key := range adopted {
+		instance := actualState.ComponentInstanceMap[key]
+		if assert.NotNil(t, instance, "Adopted component instance should be in actual state") {
+			assert.True(t, instance != desiredState.ComponentInstanceMap[key], "Adopted component instance should be a copy")
+			assert.Nil(t, instance.Drift, "Adopted component instance should not be drifted")
+			assert.False(t, instance.CreatedAt.IsZero(), "Adopted component instance should have creation time")
+		}
+	}
+
+	// engine should create only service (root) instance and not touch adopted code component instance
+	stateDiff := diff.NewPolicyResolutionDiff(desiredState, actualState)
+	assert.Equal(t, uint32(2), stateDiff.ActionPlan.NumberOfActions(), "Only service instance should be created and attached")
+}
+
+func TestAdoptChangedDeployment(t *testing.T) {
+	b := makePolicyBuilder()
+	desiredState := resolvePolicy(t, b)
+	actualState := resolve.NewPolicyResolution(false)
+
+	adopted := NewAdopter(b.Policy(), desiredState, actualState, actual.NewNoOpActionStateUpdater(), mockRegistry(&plugin.DeploymentStatus{Exists: true}), event.NewLog("test-adopt", false)).Adopt(context.Background())
+
+	assert.Equal(t, 1, len(adopted), "Code component instance should be adopted")
+	for key := range adopted {
+		instance := actualState.ComponentInstanceMap[key]
+		if assert.NotNil(t, instance.Drift, "Adopted component instance should be marked as drifted") {
+			assert.True(t, instance.Drift.Repair, "Adopted component instance should be repaired")
+		}
+	}
+}
+
+/*
+	Helpers
+*/
+
+type inspectorPlugin struct {
+	plugin.CodePlugin
+	status *plugin.DeploymentStatus
+}
+
+func (p *inspectorPlugin) Inspect(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) (*plugin.DeploymentStatus, error) {
+	return p.status, nil
+}
+
+func makePolicyBuilder() *builder.PolicyBuilder {
+	b := builder.NewPolicyBuilder()
+
+	// create a service
+	service := b.AddService()
+	b.AddServiceComponent(service,
+		b.CodeComponent(
+			util.NestedParameterMap{"param": "value"},
+			nil,
+		),
+	)
+	contract := b.AddContract(service, b.CriteriaTrue())
+
+	// add rule to set cluster
+	clusterObj := b.AddCluster()
+	b.AddRule(b.CriteriaTrue(), b.RuleActions(lang.NewLabelOperationsSetSingleLabel(lang.LabelCluster, clusterObj.Name)))
+
+	// add dependency
+	b.AddDependency(b.AddUser(), contract)
+
+	return b
+}
+
+func resolvePolicy(t *testing.T, b *builder.PolicyBuilder) *resolve.PolicyResolution {
+	t.Helper()
+	eventLog := event.NewLog("test-resolve", false)
+	resolver := resolve.NewPolicyResolver(b.Policy(), b.External(), eventLog)
+	result := resolver.ResolveAllDependencies()
+	if !assert.True(t, result.AllDependenciesResolvedSuccessfully(), "All dependencies should be resolved successfully") {
+		hook := &event.HookConsole{}
+		eventLog.Save(hook)
+		t.FailNow()
+	}
+
+	return result
+}
+
+func mockRegistry(status *plugin.DeploymentStatus) plugin.Registry {
+	clusterTypes := make(map[string]plugin.ClusterPluginConstructor)
+	codeTypes := make(map[string]map[string]plugin.CodePluginConstructor)
+
+	clusterTypes["kubernetes"] = func(cluster *lang.Cluster, cfg config.Plugins) (plugin.ClusterPlugin, error) {
+		return fake.NewNoOpClusterPlugin(0), nil
+	}
+
+	codeTypes["kubernetes"] = make(map[string]plugin.CodePluginConstructor)
+	codeTypes["kubernetes"]["helm"] = func(cluster plugin.ClusterPlugin, cfg config.Plugins) (plugin.CodePlugin, error) {
+		return &inspectorPlugin{CodePlugin: fake.NewNoOpCodePlugin(0), status: status}, nil
+	}
+
+	return plugin.NewRegistry(config.Plugins{}, clusterTypes, codeTypes)
+}
@@ -0,0 +1,5 @@
+// Package adopt implements adoption of deployments, which already exist in the cloud, into actual state. When Aptomi
+// gets introduced into an existing environment, some of the code component instances from desired state may already
+// be running (e.g. Helm releases with the same names). Instead of trying to create them again and failing on name
+// conflicts, adopter will record such component instances in actual state as if they were created by Aptomi.
+package adopt
@@ -15,12 +15,16 @@ type GraphNode struct {
 
 	// Main actions which have to be executed sequentially. If one fails, the rest will not be executed
 	Actions []Base
+
+	// Reasons is a map from action name to the reason why this action has been generated
+	Reasons map[string]*Reason
 }
 
 // NewGraphNode creates a new GraphNode of apply actions
 func NewGraphNode(key string) *GraphNode {
 	return &GraphNode{
-		Key: key,
+		Key:     key,
+		Reasons: make(map[string]*Reason),
 	}
 }
 
@@ -30,9 +34,9 @@ func (node *GraphNode) AddBefore(that *GraphNode) {
 	that.BeforeRev = append(that.BeforeRev, node)
 }
 
-// AddAction adds an action to the list of main actions. If avoidDuplicates is true, then duplicate actions will not be
-// added (e.g. update action)
-func (node *GraphNode) AddAction(action Base, avoidDuplicates bool) {
+// AddAction adds an action to the list of main actions, along with the reason why it has been generated. If
+// avoidDuplicates is true, then duplicate actions will not be added (e.g. update action)
+func (node *GraphNode) AddAction(action Base, reason *Reason, avoidDuplicates bool) {
 	add := true
 	if avoidDuplicates {
 		// go over existing actions and make sure we don't add duplicates
@@ -46,5 +50,6 @@ func (node *GraphNode) AddAction(action Base, avoidDuplicates bool) {
 
 	if add {
 		node.Actions = append(node.Actions, action)
+		node.Reasons[action.GetName()] = reason
 	}
 }
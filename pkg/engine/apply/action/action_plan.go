@@ -1,6 +1,7 @@
 package action
 
 import (
+	"context"
 	"sync"
 )
 
@@ -28,21 +29,39 @@ func (plan *Plan) GetActionGraphNode(key string) *GraphNode {
 	return result
 }
 
-// Apply applies the action plan. It may call fn in multiple go routines, executing the plan in parallel
-func (plan *Plan) Apply(fn ApplyFunction, resultUpdater ApplyResultUpdater) *ApplyResult {
+// Apply applies the action plan. It may call fn in multiple go routines, executing the plan in parallel. If ctx gets
+// cancelled, all actions which haven't been started yet will be marked as skipped and apply will be marked as aborted
+// (if at least one action got skipped because of that)
+func (plan *Plan) Apply(ctx context.Context, fn ApplyFunction, resultUpdater ApplyResultUpdater) *ApplyResult {
 	// update total number of actions and start the revision
 	resultUpdater.SetTotal(plan.NumberOfActions())
 
+	// all component instances with actions are pending
+	keys := []string{}
+	for key, node := range plan.NodeMap {
+		if len(node.Actions) > 0 {
+			keys = append(keys, key)
+		}
+	}
+	resultUpdater.SetComponentStatus(ComponentStatusPending, keys...)
+
 	// apply the plan and calculate result (success/failed/skipped actions)
-	plan.applyInternal(fn, resultUpdater)
+	aborted := plan.applyInternal(ctx, fn, resultUpdater)
+
+	// tell results updater that apply has been aborted
+	if aborted {
+		resultUpdater.Abort()
+	}
 
 	// tell results updater that we are done and return the results
 	return resultUpdater.Done()
 }
 
-// Apply applies the action plan. It may call fn in multiple go routines, executing the plan in parallel
-func (plan *Plan) applyInternal(fn ApplyFunction, resultUpdater ApplyResultUpdater) {
+// Apply applies the action plan. It may call fn in multiple go routines, executing the plan in parallel. Returns true
+// if at least one action has been skipped because ctx was done
+func (plan *Plan) applyInternal(ctx context.Context, fn ApplyFunction, resultUpdater ApplyResultUpdater) bool {
 	deg := make(map[string]int)
+	aborted := false
 	wasError := make(map[string]error)
 	queue := make(chan string, len(plan.NodeMap))
 	mutex := &sync.RWMutex{}
@@ -66,7 +85,7 @@ func (plan *Plan) applyInternal(fn ApplyFunction, resultUpdater ApplyResultUpdat
 			// Take element off the queue, apply the block of actions and put into queue 0-degree nodes which are waiting on us
 			go func(key string) {
 				defer wg.Done()
-				plan.applyActions(key, fn, queue, deg, wasError, mutex, resultUpdater)
+				plan.applyActions(ctx, key, fn, queue, deg, wasError, &aborted, mutex, resultUpdater)
 			}(key)
 		}
 		done.Done()
@@ -80,10 +99,12 @@ func (plan *Plan) applyInternal(fn ApplyFunction, resultUpdater ApplyResultUpdat
 
 	// Wait for the go routine to finish
 	done.Wait()
+
+	return aborted
 }
 
 // This function applies a block of actions and updates nodes which are waiting on this node
-func (plan *Plan) applyActions(key string, fn ApplyFunction, queue chan string, deg map[string]int, wasError map[string]error, mutex *sync.RWMutex, resultUpdater ApplyResultUpdater) {
+func (plan *Plan) applyActions(ctx context.Context, key string, fn ApplyFunction, queue chan string, deg map[string]int, wasError map[string]error, aborted *bool, mutex *sync.RWMutex, resultUpdater ApplyResultUpdater) {
 	// locate the node
 	node := plan.NodeMap[key]
 
@@ -92,8 +113,18 @@ func (plan *Plan) applyActions(key string, fn ApplyFunction, queue chan string,
 	mutex.RLock()
 	foundErr := wasError[key]
 	mutex.RUnlock()
+	failed := false
+	if len(node.Actions) > 0 && foundErr == nil && ctx.Err() == nil {
+		resultUpdater.SetComponentStatus(ComponentStatusApplying, key)
+	}
 	for _, action := range node.Actions {
-		// if an error happened before, all subsequent actions are getting marked as skipped
+		// if an error happened before or apply has been aborted, all subsequent actions are getting marked as skipped
+		if foundErr == nil && ctx.Err() != nil {
+			foundErr = ctx.Err()
+			mutex.Lock()
+			*aborted = true
+			mutex.Unlock()
+		}
 		if foundErr != nil {
 			// fmt.Println("skipped ", action.GetName())
 			resultUpdater.AddSkipped()
@@ -104,6 +135,7 @@ func (plan *Plan) applyActions(key string, fn ApplyFunction, queue chan string,
 				// fmt.Println("failed ", action.GetName())
 				resultUpdater.AddFailed()
 				foundErr = err
+				failed = true
 			} else {
 				// fmt.Println("success ", action.GetName())
 				resultUpdater.AddSuccess()
@@ -111,6 +143,17 @@ func (plan *Plan) applyActions(key string, fn ApplyFunction, queue chan string,
 		}
 	}
 
+	// update status of the component instance
+	if len(node.Actions) > 0 {
+		if failed {
+			resultUpdater.SetComponentStatus(ComponentStatusFailed, key)
+		} else if foundErr != nil {
+			resultUpdater.SetComponentStatus(ComponentStatusSkipped, key)
+		} else {
+			resultUpdater.SetComponentStatus(ComponentStatusSucceeded, key)
+		}
+	}
+
 	// mark our node as failed, if we encountered an error
 	if foundErr != nil {
 		mutex.Lock()
@@ -144,7 +187,7 @@ func (plan *Plan) NumberOfActions() uint32 {
 	resultUpdater := NewApplyResultUpdaterImpl()
 
 	// apply the plan and calculate result (success/failed/skipped actions)
-	plan.applyInternal(Noop(), resultUpdater)
+	plan.applyInternal(context.Background(), Noop(), resultUpdater)
 
 	// return the number of success actions (all of them will be success due to Noop() action)
 	return resultUpdater.Result.Success
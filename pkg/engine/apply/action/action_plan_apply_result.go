@@ -2,15 +2,46 @@ package action
 
 import (
 	"fmt"
+	"sync"
 	"sync/atomic"
 )
 
+const (
+	// ComponentStatusPending means that actions for component instance haven't been started yet
+	ComponentStatusPending = "pending"
+	// ComponentStatusApplying means that actions for component instance are being applied
+	ComponentStatusApplying = "applying"
+	// ComponentStatusSucceeded means that all actions for component instance have been applied successfully
+	ComponentStatusSucceeded = "succeeded"
+	// ComponentStatusFailed means that one of the actions for component instance has failed
+	ComponentStatusFailed = "failed"
+	// ComponentStatusSkipped means that actions for component instance have been skipped because of an upstream
+	// failure or because apply has been aborted
+	ComponentStatusSkipped = "skipped"
+)
+
 // ApplyResult is a result of applying actions
 type ApplyResult struct {
 	Success uint32
 	Failed  uint32
 	Skipped uint32
 	Total   uint32
+
+	// Aborted is true if apply has been aborted before all actions were processed
+	Aborted bool
+
+	// Components is a map from component instance key to the status of applying its actions
+	Components map[string]string `yaml:",omitempty"`
+}
+
+// SetComponentStatus sets status for the given component instances
+func (result *ApplyResult) SetComponentStatus(status string, keys ...string) {
+	if result.Components == nil {
+		result.Components = make(map[string]string)
+	}
+	for _, key := range keys {
+		result.Components[key] = status
+	}
 }
 
 // ApplyResultUpdater is an interface for handling revision progress stats (# of processed actions) when applying action plan
@@ -19,12 +50,15 @@ type ApplyResultUpdater interface {
 	AddSuccess()
 	AddFailed()
 	AddSkipped()
+	SetComponentStatus(status string, keys ...string)
+	Abort()
 	Done() *ApplyResult
 }
 
 // ApplyResultUpdaterImpl is a default thread-safe implementation of ApplyResultUpdater
 type ApplyResultUpdaterImpl struct {
 	Result *ApplyResult
+	mutex  sync.Mutex
 }
 
 // NewApplyResultUpdaterImpl creates a new default thread-safe implementation ApplyResultUpdaterImpl of ApplyResultUpdater
@@ -54,6 +88,18 @@ func (updater *ApplyResultUpdaterImpl) AddSkipped() {
 	atomic.AddUint32(&updater.Result.Skipped, 1)
 }
 
+// SetComponentStatus safely sets status for the given component instances
+func (updater *ApplyResultUpdaterImpl) SetComponentStatus(status string, keys ...string) {
+	updater.mutex.Lock()
+	defer updater.mutex.Unlock()
+	updater.Result.SetComponentStatus(status, keys...)
+}
+
+// Abort marks apply as aborted
+func (updater *ApplyResultUpdaterImpl) Abort() {
+	updater.Result.Aborted = true
+}
+
 // Done does nothing except doing an integrity check for default implementation
 func (updater *ApplyResultUpdaterImpl) Done() *ApplyResult {
 	if updater.Result.Success+updater.Result.Failed+updater.Result.Skipped != updater.Result.Total {
@@ -0,0 +1,99 @@
+package action
+
+const (
+	// ReasonNewConsumer means that a new consumer appeared for a component instance, which didn't exist before
+	ReasonNewConsumer = "new-consumer"
+
+	// ReasonParamsChanged means that code parameters of a component instance have changed
+	ReasonParamsChanged = "params-changed"
+
+	// ReasonPluginDataChanged means that data for plugins of a component instance has changed (e.g. ingress has been
+	// allowed or rejected by a rule), so deployment has to be reconfigured
+	ReasonPluginDataChanged = "plugin-data-changed"
+
+	// ReasonDeployNameChanged means that deploy name of a component instance has been migrated to the deploy name
+	// template, so deployment has to be moved under the new name
+	ReasonDeployNameChanged = "deploy-name-changed"
+
+	// ReasonStateChanged means that labels or discovery parameters of a component instance have changed, so it has to
+	// be refreshed in actual state
+	ReasonStateChanged = "state-changed"
+
+	// ReasonComponentChanged means that one of the components within a service instance has changed
+	ReasonComponentChanged = "component-changed"
+
+	// ReasonDriftRepair means that a deployment drifted away in the cloud and has to be repaired
+	ReasonDriftRepair = "drift-repair"
+
+	// ReasonConsumerAttached means that a new consumer has been attached to an existing component instance
+	ReasonConsumerAttached = "consumer-attached"
+
+	// ReasonConsumerDetached means that a consumer has been detached from a component instance
+	ReasonConsumerDetached = "consumer-detached"
+
+	// ReasonLastConsumerDetached means that the last consumer has been detached from a component instance
+	ReasonLastConsumerDetached = "last-consumer-detached"
+
+	// ReasonDeploymentChanged means that a deployment has been created or updated, so its endpoints have to be refreshed
+	ReasonDeploymentChanged = "deployment-changed"
+)
+
+// Reason explains why an action has been generated
+type Reason struct {
+	// Kind is a kind of reason (e.g. new consumer appeared, parameters changed, last consumer detached)
+	Kind string
+
+	// Diff is a human-readable difference between previous and new code parameters, if they have changed
+	Diff string `yaml:",omitempty"`
+}
+
+// NewReason creates a new Reason of a given kind
+func NewReason(kind string) *Reason {
+	return &Reason{Kind: kind}
+}
+
+// PlanView is a structured representation of an action plan, which could be serialized and returned via API. It
+// allows clients to render a preview of changes without parsing action names
+type PlanView struct {
+	// Nodes is a list of action graph nodes which have actions, sorted by key
+	Nodes []*GraphNodeView
+}
+
+// GraphNodeView is a structured representation of an action graph node
+type GraphNodeView struct {
+	// Key is unique identifier of the node (component instance key)
+	Key string
+
+	// Before is a list of keys of nodes, which have to be processed before this node
+	Before []string `yaml:",omitempty"`
+
+	// Actions is a list of actions which will be executed sequentially for this node
+	Actions []*ActionView
+}
+
+// ActionView is a structured representation of an action
+type ActionView struct {
+	// Kind is an action kind
+	Kind string
+
+	// Name is an action name
+	Name string
+
+	// ComponentKey is a key of component instance this action is being applied to
+	ComponentKey string
+
+	// Cluster is a name of the cluster where component instance is deployed
+	Cluster string `yaml:",omitempty"`
+
+	// Reason is the reason why action has been generated
+	Reason *Reason `yaml:",omitempty"`
+}
+
+// NumberOfActions returns the total number of actions in the plan view
+func (view *PlanView) NumberOfActions() int {
+	result := 0
+	for _, node := range view.Nodes {
+		result += len(node.Actions)
+	}
+	return result
+}
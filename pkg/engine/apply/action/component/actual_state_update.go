@@ -34,6 +34,12 @@ func updateActualStateFromDesired(componentKey string, context *action.Context,
 		panic(fmt.Sprintf("component instance not found in desired state: %s", componentKey))
 	}
 
+	// deployment keeps running under the name recorded in actual state, until it gets moved by the update action
+	if instanceActual != nil {
+		instance.DeployName = instanceActual.DeployName
+		instance.DeploySuffix = instanceActual.DeploySuffix
+	}
+
 	// modify create/update times, copy it over to the actual state
 	instance.UpdateTimes(timeCreated, timeUpdated)
 	context.ActualState.ComponentInstanceMap[componentKey] = instance
@@ -38,6 +38,14 @@ func (a *CreateAction) Apply(context *action.Context) error {
 		return fmt.Errorf("unable to deploy component instance '%s': %s", a.ComponentKey, err)
 	}
 
+	// deployment is always created under the desired name, even if it's being re-created for existing instance. desired
+	// state keeps blue/green suffix of existing instance, so deployment gets re-created under the same name
+	if instanceActual := context.ActualState.ComponentInstanceMap[a.ComponentKey]; instanceActual != nil {
+		instance := context.DesiredState.ComponentInstanceMap[a.ComponentKey]
+		instanceActual.DeployName = instance.DeployName
+		instanceActual.DeploySuffix = instance.DeploySuffix
+	}
+
 	// update actual state
 	return updateActualStateFromDesired(a.ComponentKey, context, true, true, true)
 }
@@ -84,5 +92,10 @@ func (a *CreateAction) processDeployment(context *action.Context) error {
 		return err
 	}
 
-	return plugin.Create(instance.GetDeployName(), instance.CalculatedCodeParams, context.EventLog)
+	err = plugin.Create(context.Ctx, instance.GetDeployName(), instance.CalculatedCodeParams, context.EventLog)
+	if err != nil {
+		return err
+	}
+
+	return applyIngressPolicy(context, plugin, instance, instance.GetDeployName())
 }
@@ -19,6 +19,9 @@ type DeleteAction struct {
 	runtime.TypeKind `yaml:",inline"`
 	*action.Metadata
 	ComponentKey string
+
+	// Protected means that component instance is protected, so action will fail instead of destroying it
+	Protected bool
 }
 
 // NewDeleteAction creates new DeleteAction
@@ -30,8 +33,20 @@ func NewDeleteAction(componentKey string) *DeleteAction {
 	}
 }
 
+// NewProtectedDeleteAction creates new DeleteAction for a protected component instance, which always fails
+func NewProtectedDeleteAction(componentKey string) *DeleteAction {
+	result := NewDeleteAction(componentKey)
+	result.Protected = true
+	return result
+}
+
 // Apply applies the action
 func (a *DeleteAction) Apply(context *action.Context) error {
+	// protected component instances should never be destroyed
+	if a.Protected {
+		return fmt.Errorf("refusing to delete protected component instance '%s'", a.ComponentKey)
+	}
+
 	// delete from cloud
 	err := a.processDeployment(context)
 	if err != nil {
@@ -84,5 +99,5 @@ func (a *DeleteAction) processDeployment(context *action.Context) error {
 		return err
 	}
 
-	return plugin.Destroy(instance.GetDeployName(), instance.CalculatedCodeParams, context.EventLog)
+	return plugin.Destroy(context.Ctx, instance.GetDeployName(), instance.CalculatedCodeParams, context.EventLog)
 }
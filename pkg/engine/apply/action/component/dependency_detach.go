@@ -23,6 +23,7 @@ type DetachDependencyAction struct {
 // NewDetachDependencyAction creates new DetachDependencyAction
 func NewDetachDependencyAction(componentKey string, dependencyID string) *DetachDependencyAction {
 	return &DetachDependencyAction{
+		TypeKind:     DetachDependencyActionObject.GetTypeKind(),
 		Metadata:     action.NewMetadata(DetachDependencyActionObject.Kind, componentKey, dependencyID),
 		ComponentKey: componentKey,
 		DependencyID: dependencyID,
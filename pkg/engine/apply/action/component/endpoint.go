@@ -88,7 +88,7 @@ func (a *EndpointsAction) processEndpoints(context *action.Context) error {
 		return err
 	}
 
-	endpoints, err := plugin.Endpoints(instance.GetDeployName(), instance.CalculatedCodeParams, context.EventLog)
+	endpoints, err := plugin.Endpoints(context.Ctx, instance.GetDeployName(), instance.CalculatedCodeParams, context.EventLog)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,39 @@
+package component
+
+import (
+	"fmt"
+	"github.com/Aptomi/aptomi/pkg/engine/apply/action"
+	"github.com/Aptomi/aptomi/pkg/engine/resolve"
+	"github.com/Aptomi/aptomi/pkg/plugin"
+	"strings"
+)
+
+// applyIngressPolicy restricts ingress traffic to the deployment of a given component instance according to rules, if
+// code plugin is able to do it
+func applyIngressPolicy(context *action.Context, codePlugin plugin.CodePlugin, instance *resolve.ComponentInstance, deployName string) error {
+	manager, ok := codePlugin.(plugin.IngressPolicyManager)
+	if !ok {
+		return nil
+	}
+
+	policy := &plugin.IngressPolicy{AllowIngress: instance.IsIngressAllowed()}
+	if !policy.AllowIngress {
+		// ingress policies only select deployments within the same cluster (and its namespace), so consumers running
+		// elsewhere can't be allowed and would be silently blocked
+		if otherClusters := instance.GetIngressFromOtherClusters(); len(otherClusters) > 0 {
+			return fmt.Errorf("can't reject ingress traffic to deployment %s, as it's consumed by deployments in other clusters: %s", deployName, strings.Join(otherClusters, ", "))
+		}
+
+		// deployments of other component instances could be running under blue/green deploy names as well
+		for _, deployName := range instance.GetAllowedIngressFrom() {
+			policy.AllowedFrom = append(policy.AllowedFrom, deployName, deployName+resolve.DeploySuffixBlue, deployName+resolve.DeploySuffixGreen)
+		}
+	}
+
+	err := manager.ApplyIngressPolicy(context.Ctx, deployName, policy, context.EventLog)
+	if err != nil {
+		return fmt.Errorf("error while applying ingress policy to deployment %s: %s", deployName, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package component
+
+import (
+	"fmt"
+	"github.com/Aptomi/aptomi/pkg/engine/apply/action"
+	"github.com/Aptomi/aptomi/pkg/event"
+	"github.com/Aptomi/aptomi/pkg/lang"
+	"github.com/Aptomi/aptomi/pkg/runtime"
+)
+
+// ReconfigureActionObject is an informational data structure with Kind and Constructor for the action
+var ReconfigureActionObject = &runtime.Info{
+	Kind:        "action-component-reconfigure",
+	Constructor: func() runtime.Object { return &ReconfigureAction{} },
+}
+
+// ReconfigureAction is a action which gets called when data for plugins of an existing component instance has changed
+// (e.g. ingress has been allowed or rejected, or the list of component instances allowed to send traffic to it has
+// changed). Deployment itself doesn't change, only data for plugins gets re-applied and component instance gets
+// refreshed in actual state
+type ReconfigureAction struct {
+	runtime.TypeKind `yaml:",inline"`
+	*action.Metadata
+	ComponentKey string
+}
+
+// NewReconfigureAction creates new ReconfigureAction
+func NewReconfigureAction(componentKey string) *ReconfigureAction {
+	return &ReconfigureAction{
+		TypeKind:     ReconfigureActionObject.GetTypeKind(),
+		Metadata:     action.NewMetadata(ReconfigureActionObject.Kind, componentKey),
+		ComponentKey: componentKey,
+	}
+}
+
+// Apply applies the action
+func (a *ReconfigureAction) Apply(context *action.Context) error {
+	// re-apply data for plugins in the cloud
+	err := a.processDeployment(context)
+	if err != nil {
+		return fmt.Errorf("unable to reconfigure component instance '%s': %s", a.ComponentKey, err)
+	}
+
+	// preserve data which is not a part of desired state, as it was retrieved from the cloud
+	instanceActual := context.ActualState.ComponentInstanceMap[a.ComponentKey]
+	instance := context.DesiredState.ComponentInstanceMap[a.ComponentKey]
+	if instanceActual != nil && instance != nil {
+		instance.Endpoints = instanceActual.Endpoints
+		instance.Drift = instanceActual.Drift
+	}
+
+	// update actual state
+	return updateActualStateFromDesired(a.ComponentKey, context, false, false, false)
+}
+
+func (a *ReconfigureAction) processDeployment(context *action.Context) error {
+	instance := context.DesiredState.ComponentInstanceMap[a.ComponentKey]
+	serviceObj, err := context.DesiredPolicy.GetObject(lang.ServiceObject.Kind, instance.Metadata.Key.ServiceName, instance.Metadata.Key.Namespace)
+	if err != nil {
+		return err
+	}
+	component := serviceObj.(*lang.Service).GetComponentsMap()[instance.Metadata.Key.ComponentName]
+
+	if component == nil || component.Code == nil {
+		return nil
+	}
+
+	context.EventLog.WithFields(event.Fields{
+		"componentKey": instance.Metadata.Key,
+		"component":    component.Name,
+		"data":         instance.DataForPlugins,
+	}).Info("Reconfiguring a running component instance: " + instance.GetKey())
+
+	clusterName := instance.GetCluster()
+	if len(clusterName) <= 0 {
+		return fmt.Errorf("policy doesn't specify deployment target for component instance")
+	}
+
+	clusterObj, err := context.DesiredPolicy.GetObject(lang.ClusterObject.Kind, clusterName, runtime.SystemNS)
+	if err != nil {
+		return err
+	}
+	if clusterObj == nil {
+		return fmt.Errorf("cluster '%s' in not present in policy", clusterName)
+	}
+	cluster := clusterObj.(*lang.Cluster)
+
+	codePlugin, err := context.Plugins.ForCodeType(cluster, component.Code.Type)
+	if err != nil {
+		return err
+	}
+
+	// deployment is running under the name recorded in actual state, which may have blue/green suffix
+	instanceActual := context.ActualState.ComponentInstanceMap[a.ComponentKey]
+	if instanceActual == nil {
+		return fmt.Errorf("component instance doesn't exist in actual state")
+	}
+
+	// ingress policy is the only data for plugins, which is applied by code plugins separately from deployment
+	return applyIngressPolicy(context, codePlugin, instance, instanceActual.GetDeployName())
+}
@@ -0,0 +1,47 @@
+package component
+
+import (
+	"github.com/Aptomi/aptomi/pkg/engine/apply/action"
+	"github.com/Aptomi/aptomi/pkg/event"
+	"github.com/Aptomi/aptomi/pkg/runtime"
+)
+
+// RefreshActionObject is an informational data structure with Kind and Constructor for the action
+var RefreshActionObject = &runtime.Info{
+	Kind:        "action-component-refresh",
+	Constructor: func() runtime.Object { return &RefreshAction{} },
+}
+
+// RefreshAction is a action which gets called when data of an existing component instance has changed (e.g. labels or
+// discovery parameters), but nothing has to be changed in the cloud. It only refreshes component instance in actual state
+type RefreshAction struct {
+	runtime.TypeKind `yaml:",inline"`
+	*action.Metadata
+	ComponentKey string
+}
+
+// NewRefreshAction creates new RefreshAction
+func NewRefreshAction(componentKey string) *RefreshAction {
+	return &RefreshAction{
+		TypeKind:     RefreshActionObject.GetTypeKind(),
+		Metadata:     action.NewMetadata(RefreshActionObject.Kind, componentKey),
+		ComponentKey: componentKey,
+	}
+}
+
+// Apply applies the action
+func (a *RefreshAction) Apply(context *action.Context) error {
+	context.EventLog.WithFields(event.Fields{
+		"componentKey": a.ComponentKey,
+	}).Debug("Refreshing component instance in actual state: " + a.ComponentKey)
+
+	// preserve data which is not a part of desired state, as it was retrieved from the cloud
+	instanceActual := context.ActualState.ComponentInstanceMap[a.ComponentKey]
+	instance := context.DesiredState.ComponentInstanceMap[a.ComponentKey]
+	if instanceActual != nil && instance != nil {
+		instance.Endpoints = instanceActual.Endpoints
+		instance.Drift = instanceActual.Drift
+	}
+
+	return updateActualStateFromDesired(a.ComponentKey, context, false, false, false)
+}
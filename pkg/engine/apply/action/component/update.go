@@ -3,9 +3,12 @@ package component
 import (
 	"fmt"
 	"github.com/Aptomi/aptomi/pkg/engine/apply/action"
+	"github.com/Aptomi/aptomi/pkg/engine/resolve"
 	"github.com/Aptomi/aptomi/pkg/event"
 	"github.com/Aptomi/aptomi/pkg/lang"
+	"github.com/Aptomi/aptomi/pkg/plugin"
 	"github.com/Aptomi/aptomi/pkg/runtime"
+	"time"
 )
 
 // UpdateActionObject is an informational data structure with Kind and Constructor for the action
@@ -59,10 +62,12 @@ func (a *UpdateAction) processDeployment(context *action.Context) error {
 		return nil
 	}
 
+	strategy := component.GetUpdateStrategy()
 	context.EventLog.WithFields(event.Fields{
 		"componentKey": instance.Metadata.Key,
 		"component":    component.Name,
 		"code":         instance.CalculatedCodeParams,
+		"strategy":     strategy,
 	}).Info("Updating a running component instance: " + instance.GetKey())
 
 	clusterName := instance.GetCluster()
@@ -79,10 +84,132 @@ func (a *UpdateAction) processDeployment(context *action.Context) error {
 	}
 	cluster := clusterObj.(*lang.Cluster)
 
-	plugin, err := context.Plugins.ForCodeType(cluster, component.Code.Type)
+	codePlugin, err := context.Plugins.ForCodeType(cluster, component.Code.Type)
 	if err != nil {
 		return err
 	}
 
-	return plugin.Update(instance.GetDeployName(), instance.CalculatedCodeParams, context.EventLog)
+	// deployment is running under the name recorded in actual state, which may have blue/green suffix
+	instanceActual := context.ActualState.ComponentInstanceMap[a.ComponentKey]
+	if instanceActual == nil {
+		return fmt.Errorf("component instance doesn't exist in actual state")
+	}
+
+	err = a.updateDeployment(context, codePlugin, strategy, instance, instanceActual)
+	if err != nil {
+		return err
+	}
+
+	// actual state always refers to the deployment running after update
+	return applyIngressPolicy(context, codePlugin, instance, instanceActual.GetDeployName())
+}
+
+// updateDeployment updates running deployment according to a given strategy
+func (a *UpdateAction) updateDeployment(context *action.Context, codePlugin plugin.CodePlugin, strategy string, instance *resolve.ComponentInstance, instanceActual *resolve.ComponentInstance) error {
+	switch strategy {
+	case lang.UpdateStrategyRecreate:
+		return a.recreate(context, codePlugin, instance, instanceActual)
+	case lang.UpdateStrategyBlueGreen:
+		return a.replace(context, codePlugin, instance, instanceActual, instanceActual.GetNextDeploySuffix())
+	}
+
+	// deployment can't be renamed in-place, so it gets replaced by a new one created under the new name
+	if instanceActual.GetDeployBaseName() != instance.GetDeployBaseName() {
+		return a.replace(context, codePlugin, instance, instanceActual, instanceActual.DeploySuffix)
+	}
+
+	return codePlugin.Update(context.Ctx, instanceActual.GetDeployName(), instance.CalculatedCodeParams, context.EventLog)
+}
+
+// recreate destroys running deployment and then creates it again with new parameters. If creation fails, component
+// instance gets marked as missing in the cloud, so it will be created again by the next revision
+func (a *UpdateAction) recreate(context *action.Context, codePlugin plugin.CodePlugin, instance *resolve.ComponentInstance, instanceActual *resolve.ComponentInstance) error {
+	oldDeployName := instanceActual.GetDeployName()
+	err := codePlugin.Destroy(context.Ctx, oldDeployName, instanceActual.CalculatedCodeParams, context.EventLog)
+	if err != nil {
+		return fmt.Errorf("error while destroying deployment %s: %s", oldDeployName, err)
+	}
+
+	// new deployment is always created under the desired name without blue/green suffix
+	newDeployName := instance.GetDeployBaseName()
+	err = codePlugin.Create(context.Ctx, newDeployName, instance.CalculatedCodeParams, context.EventLog)
+	if err != nil {
+		instanceActual.Drift = &resolve.DriftStatus{
+			Message:    fmt.Sprintf("deployment %s has been destroyed, but failed to be created again as %s", oldDeployName, newDeployName),
+			DetectedAt: time.Now(),
+			Repair:     true,
+		}
+		saveErr := updateComponentInActualState(a.ComponentKey, context)
+		if saveErr != nil {
+			return fmt.Errorf("error while creating deployment %s: %s (%s)", newDeployName, err, saveErr)
+		}
+		return fmt.Errorf("error while creating deployment %s: %s", newDeployName, err)
+	}
+
+	instanceActual.DeployName = instance.DeployName
+	instanceActual.DeploySuffix = ""
+
+	return nil
+}
+
+// replace creates a new deployment under the desired name with a given suffix alongside the running one, switches
+// actual state and endpoints to the new deployment once it's ready, and then destroys the old one. If the new
+// deployment fails to become ready, it gets destroyed, while the old one keeps running
+func (a *UpdateAction) replace(context *action.Context, codePlugin plugin.CodePlugin, instance *resolve.ComponentInstance, instanceActual *resolve.ComponentInstance, deploySuffix string) error {
+	oldDeployName := instanceActual.GetDeployName()
+	oldParams := instanceActual.CalculatedCodeParams
+	newDeployName := instance.GetDeployBaseName() + deploySuffix
+
+	err := codePlugin.Create(context.Ctx, newDeployName, instance.CalculatedCodeParams, context.EventLog)
+	if err != nil {
+		return a.abandon(context, codePlugin, instance, newDeployName, fmt.Errorf("error while creating new deployment %s: %s", newDeployName, err))
+	}
+
+	// make sure new deployment is ready, if plugin is able to check it
+	if checker, ok := codePlugin.(plugin.ReadinessChecker); ok {
+		err = checker.WaitForReady(context.Ctx, newDeployName, instance.CalculatedCodeParams, context.EventLog)
+		if err != nil {
+			return a.abandon(context, codePlugin, instance, newDeployName, fmt.Errorf("new deployment %s is not ready: %s", newDeployName, err))
+		}
+	}
+
+	endpoints, err := codePlugin.Endpoints(context.Ctx, newDeployName, instance.CalculatedCodeParams, context.EventLog)
+	if err != nil {
+		return a.abandon(context, codePlugin, instance, newDeployName, fmt.Errorf("error while getting endpoints for new deployment %s: %s", newDeployName, err))
+	}
+
+	// switch to the new deployment in actual state, so it will be used even if destroying the old one fails
+	instanceActual.DeployName = instance.DeployName
+	instanceActual.DeploySuffix = deploySuffix
+	instanceActual.Endpoints = endpoints
+	err = updateComponentInActualState(a.ComponentKey, context)
+	if err != nil {
+		return err
+	}
+
+	context.EventLog.WithFields(event.Fields{
+		"componentKey": instance.Metadata.Key,
+	}).Infof("Switched component instance %s from deployment %s to %s", instance.GetKey(), oldDeployName, newDeployName)
+
+	err = codePlugin.Destroy(context.Ctx, oldDeployName, oldParams, context.EventLog)
+	if err != nil {
+		return fmt.Errorf("error while destroying old deployment %s: %s", oldDeployName, err)
+	}
+
+	return nil
+}
+
+// abandon destroys new deployment, which failed to replace the running one, so it won't be left behind and could be
+// created again on retry. Original error is returned, extended with the destroy error if any
+func (a *UpdateAction) abandon(context *action.Context, codePlugin plugin.CodePlugin, instance *resolve.ComponentInstance, newDeployName string, err error) error {
+	context.EventLog.WithFields(event.Fields{
+		"componentKey": instance.Metadata.Key,
+	}).Warningf("Destroying new deployment %s of component instance %s, as it failed to replace the running one", newDeployName, instance.GetKey())
+
+	destroyErr := codePlugin.Destroy(context.Ctx, newDeployName, instance.CalculatedCodeParams, context.EventLog)
+	if destroyErr != nil {
+		return fmt.Errorf("%s (error while destroying new deployment: %s)", err, destroyErr)
+	}
+
+	return err
 }
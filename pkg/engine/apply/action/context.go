@@ -1,6 +1,7 @@
 package action
 
 import (
+	"context"
 	"github.com/Aptomi/aptomi/pkg/engine/actual"
 	"github.com/Aptomi/aptomi/pkg/engine/resolve"
 	"github.com/Aptomi/aptomi/pkg/event"
@@ -10,8 +11,10 @@ import (
 )
 
 // Context is a data struct that will be passed into all state update actions, giving actions access to desired
-// policy/state, and actual state and a way to updatae it, list of plugins, event log, etc
+// policy/state, and actual state and a way to updatae it, list of plugins, event log, etc. Ctx gets cancelled when
+// action times out or revision gets aborted, it should be passed into all plugin calls
 type Context struct {
+	Ctx                context.Context
 	DesiredPolicy      *lang.Policy
 	DesiredState       *resolve.PolicyResolution
 	ActualState        *resolve.PolicyResolution
@@ -22,11 +25,12 @@ type Context struct {
 }
 
 // NewContext creates a new instance of Context
-func NewContext(desiredPolicy *lang.Policy, desiredState *resolve.PolicyResolution,
+func NewContext(ctx context.Context, desiredPolicy *lang.Policy, desiredState *resolve.PolicyResolution,
 	actualState *resolve.PolicyResolution, actualStateUpdater actual.StateUpdater, externalData *external.Data,
 	plugins plugin.Registry, eventLog *event.Log) *Context {
 
 	return &Context{
+		Ctx:                ctx,
 		DesiredPolicy:      desiredPolicy,
 		DesiredState:       desiredState,
 		ActualState:        actualState,
@@ -36,3 +40,10 @@ func NewContext(desiredPolicy *lang.Policy, desiredState *resolve.PolicyResoluti
 		EventLog:           eventLog,
 	}
 }
+
+// WithCtx returns a copy of Context with a given ctx
+func (actionContext *Context) WithCtx(ctx context.Context) *Context {
+	result := *actionContext
+	result.Ctx = ctx
+	return &result
+}
@@ -1,6 +1,7 @@
 package apply
 
 import (
+	"context"
 	"fmt"
 	"github.com/Aptomi/aptomi/pkg/engine/actual"
 	"github.com/Aptomi/aptomi/pkg/engine/apply/action"
@@ -411,6 +412,8 @@ func RunEngine(b *testing.B, testName string, desiredPolicy *lang.Policy, extern
 		actions,
 		event.NewLog("test-apply", false),
 		action.NewApplyResultUpdaterImpl(),
+		0,
+		nil,
 	)
 	actualState = applyAndCheckBenchmark(b, applier, action.ApplyResult{Success: applier.actionPlan.NumberOfActions(), Failed: 0, Skipped: 0})
 
@@ -433,6 +436,8 @@ func RunEngine(b *testing.B, testName string, desiredPolicy *lang.Policy, extern
 		actions,
 		event.NewLog("test-apply", false),
 		action.NewApplyResultUpdaterImpl(),
+		0,
+		nil,
 	)
 	_ = applyAndCheckBenchmark(b, applier, action.ApplyResult{Success: applier.actionPlan.NumberOfActions(), Failed: 0, Skipped: 0})
 
@@ -441,7 +446,7 @@ func RunEngine(b *testing.B, testName string, desiredPolicy *lang.Policy, extern
 
 func applyAndCheckBenchmark(b *testing.B, apply *EngineApply, expectedResult action.ApplyResult) *resolve.PolicyResolution {
 	b.Helper()
-	actualState, result := apply.Apply()
+	actualState, result := apply.Apply(context.Background())
 
 	t := &testing.T{}
 	ok := assert.Equal(t, expectedResult.Success, result.Success, "Number of successfully executed actions")
@@ -1,15 +1,18 @@
 package apply
 
 import (
+	"context"
 	"fmt"
 	"github.com/Aptomi/aptomi/pkg/engine/actual"
 	"github.com/Aptomi/aptomi/pkg/engine/apply/action"
+	"github.com/Aptomi/aptomi/pkg/engine/apply/hook"
 	"github.com/Aptomi/aptomi/pkg/engine/resolve"
 	"github.com/Aptomi/aptomi/pkg/event"
 	"github.com/Aptomi/aptomi/pkg/external"
 	"github.com/Aptomi/aptomi/pkg/lang"
 	"github.com/Aptomi/aptomi/pkg/plugin"
 	"runtime/debug"
+	"time"
 )
 
 // EngineApply executes actions to get from an actual state to desired state
@@ -30,12 +33,18 @@ type EngineApply struct {
 
 	// Result/progress updater
 	updater action.ApplyResultUpdater
+
+	// Timeout for a single action (no timeout if it's zero)
+	actionTimeout time.Duration
+
+	// Hooks which get called before and after actions (no hooks if it's nil)
+	hooks *hook.Runner
 }
 
 // NewEngineApply creates an instance of EngineApply
 // todo(slukjanov): make sure that plugins are created once per revision, b/c we need to cache only for single policy, when it changed some credentials could change as well
 // todo(slukjanov): run cleanup on all plugins after apply done for the revision
-func NewEngineApply(desiredPolicy *lang.Policy, desiredState *resolve.PolicyResolution, actualState *resolve.PolicyResolution, actualStateUpdater actual.StateUpdater, externalData *external.Data, plugins plugin.Registry, actionPlan *action.Plan, eventLog *event.Log, updater action.ApplyResultUpdater) *EngineApply {
+func NewEngineApply(desiredPolicy *lang.Policy, desiredState *resolve.PolicyResolution, actualState *resolve.PolicyResolution, actualStateUpdater actual.StateUpdater, externalData *external.Data, plugins plugin.Registry, actionPlan *action.Plan, eventLog *event.Log, updater action.ApplyResultUpdater, actionTimeout time.Duration, hooks *hook.Runner) *EngineApply {
 	return &EngineApply{
 		desiredPolicy:      desiredPolicy,
 		desiredState:       desiredState,
@@ -46,6 +55,8 @@ func NewEngineApply(desiredPolicy *lang.Policy, desiredState *resolve.PolicyReso
 		actionPlan:         actionPlan,
 		eventLog:           eventLog,
 		updater:            updater,
+		actionTimeout:      actionTimeout,
+		hooks:              hooks,
 	}
 }
 
@@ -56,9 +67,13 @@ func NewEngineApply(desiredPolicy *lang.Policy, desiredState *resolve.PolicyReso
 // As actions get executed, they will instantiate/update/delete components according to the resolved
 // policy, as well as configure the underlying cloud components appropriately. In case of errors (e.g. cloud is not
 // available), actual state may not be equal to desired state after performing all the actions.
-func (apply *EngineApply) Apply() (*resolve.PolicyResolution, *action.ApplyResult) {
+//
+// If ctx gets cancelled (e.g. revision has been aborted or timed out), all remaining actions will be skipped and result
+// will be marked as aborted.
+func (apply *EngineApply) Apply(ctx context.Context) (*resolve.PolicyResolution, *action.ApplyResult) {
 	// process all actions
-	context := action.NewContext(
+	actionContext := action.NewContext(
+		ctx,
 		apply.desiredPolicy,
 		apply.desiredState,
 		apply.actualState,
@@ -75,8 +90,8 @@ func (apply *EngineApply) Apply() (*resolve.PolicyResolution, *action.ApplyResul
 	// (2) Restrict the number of parallel actions per cluster (make sure plugin can take care of that)
 	// (3) Ensure that plugins are "thread-safe"
 	// (4) Ensure that when we are updating states (e.g. Desired -> Actual), this is also "thread-safe"
-	result := apply.actionPlan.Apply(action.WrapSequential(func(act action.Base) error {
-		err := apply.executeAction(act, context)
+	result := apply.actionPlan.Apply(ctx, action.WrapSequential(func(act action.Base) error {
+		err := apply.executeAction(act, actionContext)
 		if err != nil {
 			err = fmt.Errorf("error while applying action '%s': %s", act, err)
 			apply.eventLog.LogError(err)
@@ -88,7 +103,7 @@ func (apply *EngineApply) Apply() (*resolve.PolicyResolution, *action.ApplyResul
 	return apply.actualState, result
 }
 
-func (apply *EngineApply) executeAction(action action.Base, context *action.Context) (errResult error) {
+func (apply *EngineApply) executeAction(action action.Base, actionContext *action.Context) (errResult error) {
 	// make sure we are converting panics into errors
 	defer func() {
 		if err := recover(); err != nil {
@@ -96,5 +111,29 @@ func (apply *EngineApply) executeAction(action action.Base, context *action.Cont
 		}
 	}()
 
-	return action.Apply(context)
+	// limit action execution time, if requested
+	if apply.actionTimeout > 0 {
+		ctx, cancel := context.WithTimeout(actionContext.Ctx, apply.actionTimeout)
+		defer cancel()
+		actionContext = actionContext.WithCtx(ctx)
+	}
+
+	// call hooks before the action, their failure means action failure
+	err := apply.hooks.Before(action, actionContext)
+	if err != nil {
+		return err
+	}
+
+	err = action.Apply(actionContext)
+	if err != nil {
+		return err
+	}
+
+	// call hooks after the action, their failure is only logged as action has already been applied
+	err = apply.hooks.After(action, actionContext)
+	if err != nil {
+		apply.eventLog.LogWarning(err)
+	}
+
+	return nil
 }
@@ -1,9 +1,12 @@
 package apply
 
 import (
+	"context"
+	"fmt"
 	"github.com/Aptomi/aptomi/pkg/config"
 	"github.com/Aptomi/aptomi/pkg/engine/actual"
 	"github.com/Aptomi/aptomi/pkg/engine/apply/action"
+	"github.com/Aptomi/aptomi/pkg/engine/apply/action/component"
 	"github.com/Aptomi/aptomi/pkg/engine/diff"
 	"github.com/Aptomi/aptomi/pkg/engine/resolve"
 	"github.com/Aptomi/aptomi/pkg/event"
@@ -15,6 +18,7 @@ import (
 	"github.com/Aptomi/aptomi/pkg/runtime"
 	"github.com/Aptomi/aptomi/pkg/util"
 	"github.com/stretchr/testify/assert"
+	"sync"
 	"testing"
 	"time"
 )
@@ -38,6 +42,8 @@ func TestApplyComponentCreateSuccess(t *testing.T) {
 		diff.NewPolicyResolutionDiff(desired.resolution(), actualState).ActionPlan,
 		event.NewLog("test-apply", false),
 		action.NewApplyResultUpdaterImpl(),
+		0,
+		nil,
 	)
 
 	// check actual state
@@ -67,6 +73,7 @@ func checkApplyComponentCreateFail(t *testing.T, failAsPanic bool) {
 	desired := newTestData(t, makePolicyBuilder())
 
 	// process all actions (and make component fail deployment)
+	updater := action.NewApplyResultUpdaterImpl()
 	applier := NewEngineApply(
 		desired.policy(),
 		desired.resolution(),
@@ -76,7 +83,9 @@ func checkApplyComponentCreateFail(t *testing.T, failAsPanic bool) {
 		mockRegistry(false, failAsPanic),
 		diff.NewPolicyResolutionDiff(desired.resolution(), actualState).ActionPlan,
 		event.NewLog("test-apply", false),
-		action.NewApplyResultUpdaterImpl(),
+		updater,
+		0,
+		nil,
 	)
 	// check actual state
 	assert.Equal(t, 0, len(actualState.ComponentInstanceMap), "Actual state should be empty")
@@ -84,6 +93,14 @@ func checkApplyComponentCreateFail(t *testing.T, failAsPanic bool) {
 	// check for errors
 	actualState = applyAndCheck(t, applier, action.ApplyResult{Success: 0, Failed: 1, Skipped: 4})
 
+	// check that failed component instance is reported, while the rest are skipped because of upstream failure
+	statusCnt := make(map[string]int)
+	for _, status := range updater.Result.Components {
+		statusCnt[status]++
+	}
+	assert.Equal(t, 1, statusCnt[action.ComponentStatusFailed], "Failed component instance should be reported")
+	assert.Equal(t, len(updater.Result.Components), statusCnt[action.ComponentStatusFailed]+statusCnt[action.ComponentStatusSkipped], "The rest of component instances should be skipped")
+
 	// check that actual state didn't get updated
 	assert.Equal(t, 0, len(actualState.ComponentInstanceMap), "Actual state should not be touched by apply()")
 }
@@ -111,6 +128,8 @@ func TestDiffHasUpdatedComponentsAndCheckTimes(t *testing.T) {
 		diff.NewPolicyResolutionDiff(desired.resolution(), actualState).ActionPlan,
 		event.NewLog("test-apply", false),
 		action.NewApplyResultUpdaterImpl(),
+		0,
+		nil,
 	)
 
 	// Check that policy apply finished with expected results
@@ -154,6 +173,8 @@ func TestDiffHasUpdatedComponentsAndCheckTimes(t *testing.T) {
 		diff.NewPolicyResolutionDiff(desiredNext.resolution(), actualState).ActionPlan,
 		event.NewLog("test-apply", false),
 		action.NewApplyResultUpdaterImpl(),
+		0,
+		nil,
 	)
 
 	// Check that policy apply finished with expected results
@@ -190,6 +211,8 @@ func TestDiffHasUpdatedComponentsAndCheckTimes(t *testing.T) {
 		diff.NewPolicyResolutionDiff(desiredNextAfterUpdate.resolution(), actualState).ActionPlan,
 		event.NewLog("test-apply", false),
 		action.NewApplyResultUpdaterImpl(),
+		0,
+		nil,
 	)
 
 	// Check that policy apply finished with expected results
@@ -228,6 +251,8 @@ func TestDeletePolicyObjectsWhileComponentInstancesAreStillRunningFails(t *testi
 		diff.NewPolicyResolutionDiff(generated.resolution(), actualState).ActionPlan,
 		event.NewLog("test-apply", false),
 		action.NewApplyResultUpdaterImpl(),
+		0,
+		nil,
 	)
 
 	// Check that policy apply finished with expected results
@@ -249,6 +274,8 @@ func TestDeletePolicyObjectsWhileComponentInstancesAreStillRunningFails(t *testi
 		diff.NewPolicyResolutionDiff(reset.resolution(), actualState).ActionPlan,
 		event.NewLog("test-apply", false),
 		action.NewApplyResultUpdaterImpl(),
+		0,
+		nil,
 	)
 
 	// delete/detach, delete/detach, endpoints/endpoints - 6 actions failed in total
@@ -256,6 +283,175 @@ func TestDeletePolicyObjectsWhileComponentInstancesAreStillRunningFails(t *testi
 	assert.Equal(t, 2, len(actualState.ComponentInstanceMap), "Actual state should be intact after actions failing")
 }
 
+func TestApplyAborted(t *testing.T) {
+	// resolve empty policy
+	empty := newTestData(t, builder.NewPolicyBuilder())
+	actualState := empty.resolution()
+
+	// resolve full policy
+	desired := newTestData(t, makePolicyBuilder())
+
+	applier := NewEngineApply(
+		desired.policy(),
+		desired.resolution(),
+		actualState,
+		actual.NewNoOpActionStateUpdater(),
+		desired.external(),
+		mockRegistry(true, false),
+		diff.NewPolicyResolutionDiff(desired.resolution(), actualState).ActionPlan,
+		event.NewLog("test-apply", false),
+		action.NewApplyResultUpdaterImpl(),
+		0,
+		nil,
+	)
+
+	// abort apply before it even started
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// check that all actions have been skipped
+	actualState, result := applier.Apply(ctx)
+	assert.Equal(t, action.ApplyResult{Success: 0, Failed: 0, Skipped: 5, Total: 5, Aborted: true}, action.ApplyResult{Success: result.Success, Failed: result.Failed, Skipped: result.Skipped, Total: result.Total, Aborted: result.Aborted}, "All actions should be skipped when apply is aborted")
+	for key, status := range result.Components {
+		assert.Equal(t, action.ComponentStatusSkipped, status, "Component instance %s should be skipped when apply is aborted", key)
+	}
+	assert.Equal(t, 0, len(actualState.ComponentInstanceMap), "Actual state should be empty after aborted apply")
+
+	// apply with nothing to skip shouldn't be marked as aborted
+	applier = NewEngineApply(
+		desired.policy(),
+		desired.resolution(),
+		desired.resolution(),
+		actual.NewNoOpActionStateUpdater(),
+		desired.external(),
+		mockRegistry(true, false),
+		diff.NewPolicyResolutionDiff(desired.resolution(), desired.resolution()).ActionPlan,
+		event.NewLog("test-apply", false),
+		action.NewApplyResultUpdaterImpl(),
+		0,
+		nil,
+	)
+	_, result = applier.Apply(ctx)
+	assert.Equal(t, uint32(0), result.Skipped, "No actions should be skipped when there is nothing to apply")
+	assert.False(t, result.Aborted, "Apply should not be marked as aborted when no actions were skipped")
+}
+
+func TestApplyActionTimeout(t *testing.T) {
+	// resolve empty policy
+	empty := newTestData(t, builder.NewPolicyBuilder())
+	actualState := empty.resolution()
+
+	// resolve full policy
+	desired := newTestData(t, makePolicyBuilder())
+
+	// code plugin is much slower than action timeout
+	applier := NewEngineApply(
+		desired.policy(),
+		desired.resolution(),
+		actualState,
+		actual.NewNoOpActionStateUpdater(),
+		desired.external(),
+		mockRegistrySlow(time.Minute),
+		diff.NewPolicyResolutionDiff(desired.resolution(), actualState).ActionPlan,
+		event.NewLog("test-apply", false),
+		action.NewApplyResultUpdaterImpl(),
+		10*time.Millisecond,
+		nil,
+	)
+
+	// check that component creation timed out, while apply itself hasn't been aborted
+	_, result := applier.Apply(context.Background())
+	assert.True(t, result.Failed > 0, "Code component creation should fail due to action timeout")
+	assert.Equal(t, result.Total, result.Success+result.Failed+result.Skipped, "All actions should be processed")
+	assert.False(t, result.Aborted, "Apply should not be aborted due to action timeout")
+}
+
+func TestApplyUpdateStrategyFailure(t *testing.T) {
+	for _, tc := range []struct {
+		strategy   string
+		failCreate bool
+		failReady  bool
+	}{
+		{strategy: lang.UpdateStrategyBlueGreen, failCreate: true},
+		{strategy: lang.UpdateStrategyBlueGreen, failReady: true},
+		{strategy: lang.UpdateStrategyRecreate, failCreate: true},
+	} {
+		b := makePolicyBuilder()
+		service := b.Policy().GetObjectsByKind(lang.ServiceObject.Kind)[0].(*lang.Service)
+		service.Components[0].UpdateStrategy = tc.strategy
+
+		// create component instance
+		empty := newTestData(t, builder.NewPolicyBuilder())
+		desired := newTestData(t, b)
+		applier := NewEngineApply(
+			desired.policy(),
+			desired.resolution(),
+			empty.resolution(),
+			actual.NewNoOpActionStateUpdater(),
+			desired.external(),
+			mockRegistry(true, false),
+			diff.NewPolicyResolutionDiff(desired.resolution(), empty.resolution()).ActionPlan,
+			event.NewLog("test-apply", false),
+			action.NewApplyResultUpdaterImpl(),
+			0,
+			nil,
+		)
+		actualState := applyAndCheck(t, applier, action.ApplyResult{Success: 5, Failed: 0, Skipped: 0})
+
+		var instance *resolve.ComponentInstance
+		for _, ci := range actualState.ComponentInstanceMap {
+			if ci.IsCode {
+				instance = ci
+			}
+		}
+		oldDeployName := instance.GetDeployName()
+
+		// change code params, so component instance gets updated according to its strategy, which fails
+		for _, dependency := range b.Policy().GetObjectsByKind(lang.DependencyObject.Kind) {
+			dependency.(*lang.Dependency).Labels["param"] = "value2"
+		}
+		desiredNext := newTestData(t, b)
+		codePlugin := &strategyCodePlugin{CodePlugin: fake.NewNoOpCodePlugin(0), failCreate: tc.failCreate, failReady: tc.failReady}
+		applier = NewEngineApply(
+			desiredNext.policy(),
+			desiredNext.resolution(),
+			actualState,
+			actual.NewNoOpActionStateUpdater(),
+			desiredNext.external(),
+			mockRegistryWithCodePlugin(codePlugin),
+			diff.NewPolicyResolutionDiff(desiredNext.resolution(), actualState).ActionPlan,
+			event.NewLog("test-apply", false),
+			action.NewApplyResultUpdaterImpl(),
+			0,
+			nil,
+		)
+		actualState, result := applier.Apply(context.Background())
+		assert.True(t, result.Failed > 0, "Update with %s strategy should fail", tc.strategy)
+
+		instance = actualState.ComponentInstanceMap[instance.GetKey()]
+		if tc.strategy == lang.UpdateStrategyBlueGreen {
+			// new deployment is destroyed, while the old one keeps running and stays in actual state
+			newDeployName := instance.GetDeployBaseName() + resolve.DeploySuffixGreen
+			assert.Equal(t, []string{newDeployName}, codePlugin.created, "New deployment should be created alongside the old one")
+			assert.Equal(t, []string{newDeployName}, codePlugin.destroyed, "New deployment should be destroyed if it fails to replace the old one")
+			assert.Equal(t, oldDeployName, instance.GetDeployName(), "Actual state should keep referring to the old deployment")
+			assert.Nil(t, instance.Drift, "Old deployment should not be marked as drifted")
+		} else {
+			// old deployment is destroyed, so component instance gets marked as missing and created again by the next revision
+			assert.Equal(t, []string{oldDeployName}, codePlugin.destroyed, "Old deployment should be destroyed")
+			if assert.NotNil(t, instance.Drift, "Component instance should be marked as drifted") {
+				assert.False(t, instance.Drift.Exists, "Component instance should be marked as missing in the cloud")
+				assert.True(t, instance.Drift.Repair, "Component instance should be repaired")
+			}
+			nextPlan := diff.NewPolicyResolutionDiff(desiredNext.resolution(), actualState).ActionPlan
+			node := nextPlan.NodeMap[instance.GetKey()]
+			if assert.NotNil(t, node, "Component instance should have actions in the next revision") {
+				assert.IsType(t, &component.CreateAction{}, node.Actions[0], "Component instance should be created by the next revision")
+			}
+		}
+	}
+}
+
 /*
 	Helpers
 */
@@ -329,7 +525,7 @@ func resolvePolicy(t *testing.T, b *builder.PolicyBuilder) *resolve.PolicyResolu
 
 func applyAndCheck(t *testing.T, apply *EngineApply, expectedResult action.ApplyResult) *resolve.PolicyResolution {
 	t.Helper()
-	actualState, result := apply.Apply()
+	actualState, result := apply.Apply(context.Background())
 
 	ok := assert.Equal(t, expectedResult.Success, result.Success, "Number of successfully executed actions")
 	ok = ok && assert.Equal(t, expectedResult.Failed, result.Failed, "Number of failed actions")
@@ -386,3 +582,70 @@ func mockRegistry(applySuccess, failAsPanic bool) plugin.Registry {
 
 	return plugin.NewRegistry(config.Plugins{}, clusterTypes, codeTypes)
 }
+
+func mockRegistrySlow(sleepTime time.Duration) plugin.Registry {
+	clusterTypes := make(map[string]plugin.ClusterPluginConstructor)
+	codeTypes := make(map[string]map[string]plugin.CodePluginConstructor)
+
+	clusterTypes["kubernetes"] = func(cluster *lang.Cluster, cfg config.Plugins) (plugin.ClusterPlugin, error) {
+		return fake.NewNoOpClusterPlugin(0), nil
+	}
+
+	codeTypes["kubernetes"] = make(map[string]plugin.CodePluginConstructor)
+	codeTypes["kubernetes"]["helm"] = func(cluster plugin.ClusterPlugin, cfg config.Plugins) (plugin.CodePlugin, error) {
+		return fake.NewNoOpCodePlugin(sleepTime), nil
+	}
+
+	return plugin.NewRegistry(config.Plugins{}, clusterTypes, codeTypes)
+}
+
+func mockRegistryWithCodePlugin(codePlugin plugin.CodePlugin) plugin.Registry {
+	clusterTypes := make(map[string]plugin.ClusterPluginConstructor)
+	codeTypes := make(map[string]map[string]plugin.CodePluginConstructor)
+
+	clusterTypes["kubernetes"] = func(cluster *lang.Cluster, cfg config.Plugins) (plugin.ClusterPlugin, error) {
+		return fake.NewNoOpClusterPlugin(0), nil
+	}
+
+	codeTypes["kubernetes"] = make(map[string]plugin.CodePluginConstructor)
+	codeTypes["kubernetes"]["helm"] = func(cluster plugin.ClusterPlugin, cfg config.Plugins) (plugin.CodePlugin, error) {
+		return codePlugin, nil
+	}
+
+	return plugin.NewRegistry(config.Plugins{}, clusterTypes, codeTypes)
+}
+
+// strategyCodePlugin is a fake code plugin, which records created and destroyed deployments and fails creation or
+// readiness check on demand
+type strategyCodePlugin struct {
+	plugin.CodePlugin
+	mutex      sync.Mutex
+	failCreate bool
+	failReady  bool
+	created    []string
+	destroyed  []string
+}
+
+func (p *strategyCodePlugin) Create(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.created = append(p.created, deployName)
+	if p.failCreate {
+		return fmt.Errorf("create failed by plugin mock for component '%s'", deployName)
+	}
+	return nil
+}
+
+func (p *strategyCodePlugin) Destroy(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.destroyed = append(p.destroyed, deployName)
+	return nil
+}
+
+func (p *strategyCodePlugin) WaitForReady(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) error {
+	if p.failReady {
+		return fmt.Errorf("deployment '%s' is not ready", deployName)
+	}
+	return nil
+}
@@ -0,0 +1,3 @@
+// Package hook implements webhooks, which get called before and after create/update/delete actions on matching
+// component instances during apply. Hooks are defined in server config.
+package hook
@@ -0,0 +1,140 @@
+package hook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/Aptomi/aptomi/pkg/config"
+	"github.com/Aptomi/aptomi/pkg/engine/apply/action"
+	"github.com/Aptomi/aptomi/pkg/engine/apply/action/component"
+	"github.com/Aptomi/aptomi/pkg/runtime"
+	"github.com/Aptomi/aptomi/pkg/util"
+	"net/http"
+)
+
+// Payload is a JSON payload which gets POSTed to the hook
+type Payload struct {
+	Hook         string                  `json:"hook"`
+	Stage        string                  `json:"stage"`
+	Action       string                  `json:"action"`
+	ComponentKey string                  `json:"componentKey"`
+	Cluster      string                  `json:"cluster"`
+	Params       util.NestedParameterMap `json:"params"`
+	Revision     runtime.Generation      `json:"revision"`
+}
+
+// Runner calls hooks for actions of a given revision
+type Runner struct {
+	hooks    []config.Hook
+	revision runtime.Generation
+	client   *http.Client
+}
+
+// NewRunner creates a new Runner, which calls given hooks for actions of a given revision
+func NewRunner(hooks []config.Hook, revision runtime.Generation) *Runner {
+	return &Runner{
+		hooks:    hooks,
+		revision: revision,
+		client:   &http.Client{},
+	}
+}
+
+// Before calls all hooks which have to be called before a given action. If one of them fails, an error is returned
+// and the action should not be applied
+func (runner *Runner) Before(act action.Base, actionContext *action.Context) error {
+	return runner.run(config.HookStageBefore, act, actionContext)
+}
+
+// After calls all hooks which have to be called after a given action has been applied
+func (runner *Runner) After(act action.Base, actionContext *action.Context) error {
+	return runner.run(config.HookStageAfter, act, actionContext)
+}
+
+func (runner *Runner) run(stage string, act action.Base, actionContext *action.Context) error {
+	// it's ok to have no hooks at all
+	if runner == nil || len(runner.hooks) == 0 {
+		return nil
+	}
+
+	actionName, componentKey := getTarget(act)
+	if len(actionName) == 0 {
+		return nil
+	}
+
+	// deleted component instance is present in actual state only
+	instance := actionContext.DesiredState.ComponentInstanceMap[componentKey]
+	if instance == nil {
+		instance = actionContext.ActualState.ComponentInstanceMap[componentKey]
+	}
+	if instance == nil {
+		return nil
+	}
+
+	for idx := range runner.hooks {
+		hook := &runner.hooks[idx]
+		if !hook.Matches(stage, actionName, instance.Metadata.Key.ServiceName, instance.Metadata.Key.ComponentName) {
+			continue
+		}
+
+		err := runner.call(actionContext.Ctx, hook, &Payload{
+			Hook:         hook.Name,
+			Stage:        stage,
+			Action:       actionName,
+			ComponentKey: componentKey,
+			Cluster:      instance.GetCluster(),
+			Params:       instance.CalculatedCodeParams,
+			Revision:     runner.revision,
+		})
+		if err != nil {
+			return fmt.Errorf("hook '%s' failed %s %s of component instance '%s': %s", hook.Name, stage, actionName, componentKey, err)
+		}
+	}
+
+	return nil
+}
+
+func (runner *Runner) call(ctx context.Context, hook *config.Hook, payload *Payload) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("error while encoding payload: %s", err)
+	}
+
+	if hook.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, hook.Timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := runner.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response status: %s", resp.Status)
+	}
+
+	return nil
+}
+
+// Returns action name (create, update or delete) and component key for a given action. If hooks are not supported
+// for the action, empty action name will be returned
+func getTarget(act action.Base) (string, string) {
+	switch a := act.(type) {
+	case *component.CreateAction:
+		return "create", a.ComponentKey
+	case *component.UpdateAction:
+		return "update", a.ComponentKey
+	case *component.DeleteAction:
+		return "delete", a.ComponentKey
+	}
+	return "", ""
+}
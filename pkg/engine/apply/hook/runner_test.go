@@ -0,0 +1,139 @@
+package hook
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/Aptomi/aptomi/pkg/config"
+	"github.com/Aptomi/aptomi/pkg/engine/actual"
+	"github.com/Aptomi/aptomi/pkg/engine/apply/action"
+	"github.com/Aptomi/aptomi/pkg/engine/apply/action/component"
+	"github.com/Aptomi/aptomi/pkg/engine/resolve"
+	"github.com/Aptomi/aptomi/pkg/event"
+	"github.com/Aptomi/aptomi/pkg/lang"
+	"github.com/Aptomi/aptomi/pkg/lang/builder"
+	"github.com/Aptomi/aptomi/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestHookBeforeAction(t *testing.T) {
+	recorder := &payloadRecorder{}
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		payload := &Payload{}
+		err := json.NewDecoder(request.Body).Decode(payload)
+		assert.Nil(t, err, "Hook payload should be valid JSON")
+		recorder.add(payload)
+	}))
+	defer server.Close()
+
+	actionContext, instance := makeActionContext(t)
+	runner := NewRunner([]config.Hook{
+		{Name: "backup", Stage: config.HookStageBefore, Actions: []string{"delete"}, URL: server.URL},
+		{Name: "notify", Stage: config.HookStageAfter, URL: server.URL},
+	}, 42)
+
+	// hook should not be called for other actions
+	err := runner.Before(component.NewCreateAction(instance.GetKey()), actionContext)
+	assert.Nil(t, err, "Hooks should be called without errors")
+	assert.Equal(t, 0, len(recorder.get()), "Hook should not be called for create action")
+
+	// hook should be called for delete action
+	err = runner.Before(component.NewDeleteAction(instance.GetKey()), actionContext)
+	assert.Nil(t, err, "Hooks should be called without errors")
+	payloads := recorder.get()
+	if assert.Equal(t, 1, len(payloads), "Hook should be called for delete action") {
+		assert.Equal(t, "backup", payloads[0].Hook, "Hook name should be sent")
+		assert.Equal(t, "delete", payloads[0].Action, "Action should be sent")
+		assert.Equal(t, instance.GetKey(), payloads[0].ComponentKey, "Component key should be sent")
+		assert.Equal(t, instance.GetCluster(), payloads[0].Cluster, "Cluster should be sent")
+		assert.Equal(t, "value", payloads[0].Params["param"], "Code params should be sent")
+		assert.EqualValues(t, 42, payloads[0].Revision, "Revision should be sent")
+	}
+
+	// hook without action filter should be called after any action
+	err = runner.After(component.NewUpdateAction(instance.GetKey()), actionContext)
+	assert.Nil(t, err, "Hooks should be called without errors")
+	assert.Equal(t, 2, len(recorder.get()), "Hook should be called after update action")
+}
+
+func TestHookFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	actionContext, instance := makeActionContext(t)
+	runner := NewRunner([]config.Hook{
+		{Name: "backup", Stage: config.HookStageBefore, URL: server.URL},
+	}, 42)
+
+	err := runner.Before(component.NewDeleteAction(instance.GetKey()), actionContext)
+	assert.NotNil(t, err, "Hook failure should be reported")
+
+	// nil runner should do nothing
+	var empty *Runner
+	assert.Nil(t, empty.Before(component.NewDeleteAction(instance.GetKey()), actionContext), "Nil runner should not call any hooks")
+}
+
+/*
+	Helpers
+*/
+
+// payloadRecorder collects payloads received by the test hook server, which handles requests in separate goroutines
+type payloadRecorder struct {
+	mu       sync.Mutex
+	payloads []*Payload
+}
+
+func (recorder *payloadRecorder) add(payload *Payload) {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	recorder.payloads = append(recorder.payloads, payload)
+}
+
+func (recorder *payloadRecorder) get() []*Payload {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	return append([]*Payload{}, recorder.payloads...)
+}
+
+func makeActionContext(t *testing.T) (*action.Context, *resolve.ComponentInstance) {
+	t.Helper()
+	b := builder.NewPolicyBuilder()
+
+	// create a service
+	service := b.AddService()
+	b.AddServiceComponent(service,
+		b.CodeComponent(
+			util.NestedParameterMap{"param": "value"},
+			nil,
+		),
+	)
+	contract := b.AddContract(service, b.CriteriaTrue())
+
+	// add rule to set cluster
+	clusterObj := b.AddCluster()
+	b.AddRule(b.CriteriaTrue(), b.RuleActions(lang.NewLabelOperationsSetSingleLabel(lang.LabelCluster, clusterObj.Name)))
+
+	// add dependency
+	b.AddDependency(b.AddUser(), contract)
+
+	eventLog := event.NewLog("test-hook", false)
+	desiredState := resolve.NewPolicyResolver(b.Policy(), b.External(), eventLog).ResolveAllDependencies()
+	if !assert.True(t, desiredState.AllDependenciesResolvedSuccessfully(), "All dependencies should be resolved successfully") {
+		t.FailNow()
+	}
+
+	var codeInstance *resolve.ComponentInstance
+	for _, instance := range desiredState.ComponentInstanceMap {
+		if instance.IsCode {
+			codeInstance = instance
+		}
+	}
+
+	actionContext := action.NewContext(context.Background(), b.Policy(), desiredState, resolve.NewPolicyResolution(false), actual.NewNoOpActionStateUpdater(), b.External(), nil, eventLog)
+	return actionContext, codeInstance
+}
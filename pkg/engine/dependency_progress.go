@@ -0,0 +1,96 @@
+package engine
+
+import (
+	"github.com/Aptomi/aptomi/pkg/engine/apply/action"
+	"github.com/Aptomi/aptomi/pkg/engine/resolve"
+	"github.com/Aptomi/aptomi/pkg/runtime"
+	"sort"
+)
+
+// DependencyProgress represents rollout progress of a dependency, i.e. statuses of all component instances it
+// relies on
+type DependencyProgress struct {
+	// Revision is the generation of the revision progress is based on
+	Revision runtime.Generation
+
+	// Components is a map from component instance key to its rollout status (pending, applying, succeeded, failed or
+	// skipped)
+	Components map[string]string
+
+	// Ready is true if all component instances of the dependency have been successfully deployed
+	Ready bool
+}
+
+// RecordDependencyComponents records keys of component instances of every dependency in desired state into the
+// revision, so that dependency progress could be calculated later without resolving policy again
+func (revision *Revision) RecordDependencyComponents(desiredState *resolve.PolicyResolution) {
+	revision.DependencyComponents = make(map[string][]string)
+	for key, instance := range desiredState.ComponentInstanceMap {
+		for depKey := range instance.DependencyKeys {
+			revision.DependencyComponents[depKey] = append(revision.DependencyComponents[depKey], key)
+		}
+	}
+	for _, keys := range revision.DependencyComponents {
+		sort.Strings(keys)
+	}
+}
+
+// GetDependencyProgress calculates rollout progress for the dependency with the given key. Component instances of the
+// dependency are taken from desired state recorded in the latest revision and from actual state using their
+// dependency keys, while their statuses are taken from the latest revision and its plan of actions. Component
+// instances, which are not affected by the revision, are considered succeeded if they exist in actual state and
+// pending otherwise
+func GetDependencyProgress(depKey string, revision *Revision, actualState *resolve.PolicyResolution) *DependencyProgress {
+	result := &DependencyProgress{
+		Components: make(map[string]string),
+	}
+
+	// collect component instances of the dependency
+	if revision != nil {
+		for _, key := range revision.DependencyComponents[depKey] {
+			result.Components[key] = action.ComponentStatusPending
+		}
+	}
+	for key, instance := range actualState.ComponentInstanceMap {
+		if _, ok := instance.DependencyKeys[depKey]; ok {
+			result.Components[key] = action.ComponentStatusPending
+		}
+	}
+
+	// component instances in the plan of revision, which is not completed yet, are pending
+	planned := make(map[string]bool)
+	if revision != nil {
+		result.Revision = revision.GetGeneration()
+		if revision.Plan != nil && (revision.IsPending() || revision.Status == RevisionStatusInProgress) {
+			for _, node := range revision.Plan.Nodes {
+				planned[node.Key] = true
+			}
+		}
+	}
+
+	for key := range result.Components {
+		if status, ok := revision.getComponentStatus(key); ok {
+			result.Components[key] = status
+		} else if _, exists := actualState.ComponentInstanceMap[key]; exists && !planned[key] {
+			result.Components[key] = action.ComponentStatusSucceeded
+		}
+	}
+
+	result.Ready = len(result.Components) > 0
+	for _, status := range result.Components {
+		if status != action.ComponentStatusSucceeded {
+			result.Ready = false
+		}
+	}
+
+	return result
+}
+
+// getComponentStatus returns status of the component instance recorded while applying revision
+func (revision *Revision) getComponentStatus(key string) (string, bool) {
+	if revision == nil || revision.Result == nil {
+		return "", false
+	}
+	status, ok := revision.Result.Components[key]
+	return status, ok
+}
@@ -0,0 +1,79 @@
+package engine
+
+import (
+	"github.com/Aptomi/aptomi/pkg/engine/apply/action"
+	"github.com/Aptomi/aptomi/pkg/engine/resolve"
+	"github.com/Aptomi/aptomi/pkg/event"
+	"github.com/Aptomi/aptomi/pkg/lang"
+	"github.com/Aptomi/aptomi/pkg/lang/builder"
+	"github.com/Aptomi/aptomi/pkg/runtime"
+	"github.com/Aptomi/aptomi/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDependencyProgress(t *testing.T) {
+	b := builder.NewPolicyBuilder()
+
+	// create a service
+	service := b.AddService()
+	b.AddServiceComponent(service,
+		b.CodeComponent(
+			util.NestedParameterMap{"param": "value"},
+			nil,
+		),
+	)
+	contract := b.AddContract(service, b.CriteriaTrue())
+
+	// add rule to set cluster
+	clusterObj := b.AddCluster()
+	b.AddRule(b.CriteriaTrue(), b.RuleActions(lang.NewLabelOperationsSetSingleLabel(lang.LabelCluster, clusterObj.Name)))
+
+	// add dependency
+	dependency := b.AddDependency(b.AddUser(), contract)
+	depKey := runtime.KeyForStorable(dependency)
+
+	desiredState := resolve.NewPolicyResolver(b.Policy(), b.External(), event.NewLog("test-progress", false)).ResolveAllDependencies()
+	if !assert.True(t, desiredState.AllDependenciesResolvedSuccessfully(), "All dependencies should be resolved successfully") {
+		t.FailNow()
+	}
+	emptyState := resolve.NewPolicyResolution(false)
+
+	// revision is planned, but not applied yet
+	revision := NewRevision(runtime.FirstGen, runtime.FirstGen)
+	revision.RecordDependencyComponents(desiredState)
+	revision.Plan = &action.PlanView{}
+	keys := []string{}
+	for key := range desiredState.ComponentInstanceMap {
+		keys = append(keys, key)
+		revision.Plan.Nodes = append(revision.Plan.Nodes, &action.GraphNodeView{Key: key})
+	}
+	progress := GetDependencyProgress(depKey, revision, emptyState)
+	assert.Equal(t, len(keys), len(progress.Components), "All component instances of dependency should be reported")
+	for _, status := range progress.Components {
+		assert.Equal(t, action.ComponentStatusPending, status, "Component instance should be pending")
+	}
+	assert.False(t, progress.Ready, "Dependency should not be ready")
+
+	// revision has failed
+	revision.Status = RevisionStatusCompleted
+	revision.Result.SetComponentStatus(action.ComponentStatusSkipped, keys...)
+	revision.Result.SetComponentStatus(action.ComponentStatusFailed, keys[0])
+	progress = GetDependencyProgress(depKey, revision, emptyState)
+	assert.Equal(t, action.ComponentStatusFailed, progress.Components[keys[0]], "Component instance should be failed")
+	assert.False(t, progress.Ready, "Dependency should not be ready")
+
+	// everything has been deployed by previous revisions
+	revisionNext := NewRevision(runtime.FirstGen.Next(), runtime.FirstGen)
+	revisionNext.RecordDependencyComponents(desiredState)
+	progress = GetDependencyProgress(depKey, revisionNext, desiredState)
+	for _, status := range progress.Components {
+		assert.Equal(t, action.ComponentStatusSucceeded, status, "Component instance should be succeeded")
+	}
+	assert.True(t, progress.Ready, "Dependency should be ready")
+
+	// unknown dependency
+	progress = GetDependencyProgress("unknown", revision, desiredState)
+	assert.Empty(t, progress.Components, "Unknown dependency should have no component instances")
+	assert.False(t, progress.Ready, "Unknown dependency should not be ready")
+}
@@ -0,0 +1,62 @@
+package diff
+
+import (
+	"fmt"
+	"github.com/Aptomi/aptomi/pkg/config"
+	"github.com/Aptomi/aptomi/pkg/engine/apply/action/component"
+	"github.com/Aptomi/aptomi/pkg/lang"
+	"github.com/Aptomi/aptomi/pkg/runtime"
+	"sort"
+)
+
+// CheckBlastRadius verifies that the action plan doesn't exceed given safety limits. It returns a list of violations,
+// which is empty if plan is safe to apply. Plan is considered unsafe if it deletes more than the allowed number or
+// percentage of code component instances in actual state, or if it deletes anything in protected clusters
+func (diff *PolicyResolutionDiff) CheckBlastRadius(policy *lang.Policy, limits config.BlastRadius) []string {
+	// count deployed code component instances, and the ones to be deleted
+	total := 0
+	for _, instance := range diff.Prev.ComponentInstanceMap {
+		if instance.IsCode {
+			total++
+		}
+	}
+
+	deleted := 0
+	protected := make(map[string]int)
+	for key, node := range diff.ActionPlan.NodeMap {
+		instance := diff.Prev.ComponentInstanceMap[key]
+		if instance == nil || !instance.IsCode {
+			continue
+		}
+		for _, act := range node.Actions {
+			if _, ok := act.(*component.DeleteAction); !ok {
+				continue
+			}
+			deleted++
+
+			clusterObj, err := policy.GetObject(lang.ClusterObject.Kind, instance.GetCluster(), runtime.SystemNS)
+			if err == nil && clusterObj != nil && clusterObj.(*lang.Cluster).IsProtected() {
+				protected[instance.GetCluster()]++
+			}
+		}
+	}
+
+	result := []string{}
+	if limits.MaxDeletes > 0 && deleted > limits.MaxDeletes {
+		result = append(result, fmt.Sprintf("%d component instances will be deleted, while the limit is %d", deleted, limits.MaxDeletes))
+	}
+	if limits.MaxDeletesPercent > 0 && total > 0 && deleted*100 > limits.MaxDeletesPercent*total {
+		result = append(result, fmt.Sprintf("%d out of %d component instances will be deleted, while the limit is %d%%", deleted, total, limits.MaxDeletesPercent))
+	}
+
+	clusters := []string{}
+	for cluster := range protected {
+		clusters = append(clusters, cluster)
+	}
+	sort.Strings(clusters)
+	for _, cluster := range clusters {
+		result = append(result, fmt.Sprintf("%d component instances will be deleted in protected cluster '%s'", protected[cluster], cluster))
+	}
+
+	return result
+}
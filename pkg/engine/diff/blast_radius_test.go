@@ -0,0 +1,37 @@
+package diff
+
+import (
+	"github.com/Aptomi/aptomi/pkg/config"
+	"github.com/Aptomi/aptomi/pkg/lang"
+	"github.com/Aptomi/aptomi/pkg/lang/builder"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDiffBlastRadius(t *testing.T) {
+	b := makePolicyBuilder()
+
+	// add dependency
+	d1 := b.AddDependency(b.AddUser(), b.Policy().GetObjectsByKind(lang.ContractObject.Kind)[0].(*lang.Contract))
+	d1.Labels["param"] = "value1"
+	resolvedPrev := resolvePolicy(t, b)
+
+	// resolve empty policy, so everything gets deleted
+	resolvedEmpty := resolvePolicy(t, builder.NewPolicyBuilder())
+	diff := NewPolicyResolutionDiff(resolvedEmpty, resolvedPrev)
+
+	// no limits
+	assert.Empty(t, diff.CheckBlastRadius(b.Policy(), config.BlastRadius{}), "Plan should be allowed without limits")
+
+	// limit on the number of deletes
+	assert.Empty(t, diff.CheckBlastRadius(b.Policy(), config.BlastRadius{MaxDeletes: 1}), "Plan should be allowed within the limit")
+	assert.Len(t, diff.CheckBlastRadius(b.Policy(), config.BlastRadius{MaxDeletes: 1, MaxDeletesPercent: 50}), 1, "Plan should be blocked if it deletes too much of actual state")
+
+	// protected cluster
+	cluster := b.Policy().GetObjectsByKind(lang.ClusterObject.Kind)[0].(*lang.Cluster)
+	cluster.Labels = map[string]string{lang.ClusterLabelProtected: "true"}
+	assert.Len(t, diff.CheckBlastRadius(b.Policy(), config.BlastRadius{}), 1, "Plan should be blocked if it deletes anything in protected cluster")
+
+	// nothing is deleted, so protected cluster is fine
+	assert.Empty(t, NewPolicyResolutionDiff(resolvedPrev, resolvedEmpty).CheckBlastRadius(b.Policy(), config.BlastRadius{MaxDeletes: 1}), "Plan without deletes should be allowed")
+}
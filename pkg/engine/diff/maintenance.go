@@ -0,0 +1,97 @@
+package diff
+
+import (
+	"github.com/Aptomi/aptomi/pkg/config"
+	"github.com/Aptomi/aptomi/pkg/engine/apply/action"
+	"github.com/Aptomi/aptomi/pkg/engine/apply/action/component"
+	"github.com/Aptomi/aptomi/pkg/lang"
+	"github.com/Aptomi/aptomi/pkg/runtime"
+	"time"
+)
+
+// CheckMaintenance verifies that the action plan could be applied at a given time according to maintenance windows
+// of the affected clusters. It returns true if plan could be applied right away. Otherwise it returns false along
+// with the time plan is scheduled for, i.e. when windows are open for all affected clusters (or zero time, if some
+// of them will never be open). If additive changes are allowed, clusters with additive actions only are not affected
+func (diff *PolicyResolutionDiff) CheckMaintenance(policy *lang.Policy, maintenance config.Maintenance, now time.Time) (time.Time, bool) {
+	if len(maintenance.Windows) <= 0 {
+		return now, true
+	}
+
+	// find all clusters affected by the plan
+	clusters := make(map[string]bool)
+	for key, node := range diff.ActionPlan.NodeMap {
+		for _, act := range node.Actions {
+			if maintenance.AllowAdditive && isAdditive(act) {
+				continue
+			}
+			if cluster := diff.getCluster(key); len(cluster) > 0 {
+				clusters[cluster] = true
+			}
+		}
+	}
+
+	// plan is scheduled for the latest time when windows get open among all affected clusters
+	scheduledFor := now
+	for cluster := range clusters {
+		next := nextMaintenanceWindow(policy, maintenance, cluster, now)
+		if next.IsZero() {
+			return next, false
+		}
+		if next.After(scheduledFor) {
+			scheduledFor = next
+		}
+	}
+
+	return scheduledFor, !scheduledFor.After(now)
+}
+
+// Returns the first time starting from a given one, when one of maintenance windows is open for a given cluster
+func nextMaintenanceWindow(policy *lang.Policy, maintenance config.Maintenance, cluster string, now time.Time) time.Time {
+	// windows could be assigned to the cluster via label, as well as in config
+	assigned := make(map[string]bool)
+	clusterObj, err := policy.GetObject(lang.ClusterObject.Kind, cluster, runtime.SystemNS)
+	if err == nil && clusterObj != nil {
+		for _, name := range clusterObj.(*lang.Cluster).GetMaintenanceWindows() {
+			assigned[name] = true
+		}
+	}
+
+	// if cluster has its own windows, only they should be used. otherwise windows without clusters apply
+	own := []config.MaintenanceWindow{}
+	common := []config.MaintenanceWindow{}
+	for _, window := range maintenance.Windows {
+		if assigned[window.Name] || window.AppliesTo(cluster) {
+			own = append(own, window)
+		} else if len(window.Clusters) <= 0 {
+			common = append(common, window)
+		}
+	}
+	windows := own
+	if len(windows) <= 0 {
+		windows = common
+	}
+
+	// no windows mean that changes could be applied at any time
+	if len(windows) <= 0 {
+		return now
+	}
+
+	var result time.Time
+	for _, window := range windows {
+		next := window.NextOpen(now)
+		if !next.IsZero() && (result.IsZero() || next.Before(result)) {
+			result = next
+		}
+	}
+	return result
+}
+
+// Returns true if action doesn't modify or delete anything that already exists
+func isAdditive(act action.Base) bool {
+	switch act.(type) {
+	case *component.CreateAction, *component.AttachDependencyAction, *component.EndpointsAction, *component.RefreshAction:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package diff
+
+import (
+	"github.com/Aptomi/aptomi/pkg/config"
+	"github.com/Aptomi/aptomi/pkg/lang"
+	"github.com/Aptomi/aptomi/pkg/lang/builder"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestDiffMaintenance(t *testing.T) {
+	b := makePolicyBuilder()
+
+	// add dependency
+	d1 := b.AddDependency(b.AddUser(), b.Policy().GetObjectsByKind(lang.ContractObject.Kind)[0].(*lang.Contract))
+	d1.Labels["param"] = "value1"
+	resolvedNext := resolvePolicy(t, b)
+	resolvedEmpty := resolvePolicy(t, builder.NewPolicyBuilder())
+
+	// every Saturday at 2am for 4 hours
+	saturday := time.Date(2017, 11, 4, 0, 0, 0, 0, time.UTC)
+	window := config.MaintenanceWindow{Name: "weekend", Schedule: "0 2 * * 6", Duration: 4 * time.Hour}
+
+	// no windows
+	createDiff := NewPolicyResolutionDiff(resolvedNext, resolvedEmpty)
+	_, allowed := createDiff.CheckMaintenance(b.Policy(), config.Maintenance{}, saturday)
+	assert.True(t, allowed, "Plan should be allowed without maintenance windows")
+
+	// within and outside of the window
+	maintenance := config.Maintenance{Windows: []config.MaintenanceWindow{window}}
+	_, allowed = createDiff.CheckMaintenance(b.Policy(), maintenance, saturday.Add(3*time.Hour))
+	assert.True(t, allowed, "Plan should be allowed within maintenance window")
+	scheduledFor, allowed := createDiff.CheckMaintenance(b.Policy(), maintenance, saturday)
+	assert.False(t, allowed, "Plan should not be allowed outside of maintenance window")
+	assert.Equal(t, saturday.Add(2*time.Hour), scheduledFor, "Plan should be scheduled for the next window")
+
+	// additive changes
+	maintenance.AllowAdditive = true
+	_, allowed = createDiff.CheckMaintenance(b.Policy(), maintenance, saturday)
+	assert.True(t, allowed, "Additive plan should be allowed outside of maintenance window")
+	_, allowed = NewPolicyResolutionDiff(resolvedEmpty, resolvedNext).CheckMaintenance(b.Policy(), maintenance, saturday)
+	assert.False(t, allowed, "Destructive plan should not be allowed outside of maintenance window")
+
+	// cluster with its own window assigned via label
+	maintenance = config.Maintenance{Windows: []config.MaintenanceWindow{
+		window,
+		{Name: "daily", Schedule: "0 0 * * *", Duration: time.Hour, Clusters: []string{"cluster-other"}},
+	}}
+	cluster := b.Policy().GetObjectsByKind(lang.ClusterObject.Kind)[0].(*lang.Cluster)
+	cluster.Labels = map[string]string{lang.ClusterLabelMaintenanceWindow: "daily"}
+	_, allowed = createDiff.CheckMaintenance(b.Policy(), maintenance, saturday)
+	assert.True(t, allowed, "Plan should be allowed within maintenance window assigned to the cluster")
+	scheduledFor, allowed = createDiff.CheckMaintenance(b.Policy(), maintenance, saturday.Add(2*time.Hour))
+	assert.False(t, allowed, "Common window should not be used for cluster with its own window")
+	assert.Equal(t, saturday.AddDate(0, 0, 1), scheduledFor, "Plan should be scheduled for the next window of the cluster")
+}
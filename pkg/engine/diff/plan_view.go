@@ -0,0 +1,78 @@
+package diff
+
+import (
+	"github.com/Aptomi/aptomi/pkg/engine/apply/action"
+	"sort"
+)
+
+// GetPlanView returns a structured representation of the action plan, which could be returned via API. Every action
+// carries its kind, component key, cluster and the reason why it has been generated. Nodes without actions are not
+// included into the view, but dependencies between nodes are preserved through them
+func (diff *PolicyResolutionDiff) GetPlanView() *action.PlanView {
+	keys := []string{}
+	for key, node := range diff.ActionPlan.NodeMap {
+		if len(node.Actions) > 0 {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	result := &action.PlanView{
+		Nodes: []*action.GraphNodeView{},
+	}
+	for _, key := range keys {
+		node := diff.ActionPlan.NodeMap[key]
+		nodeView := &action.GraphNodeView{
+			Key:     key,
+			Before:  diff.getBeforeKeys(node),
+			Actions: []*action.ActionView{},
+		}
+		for _, act := range node.Actions {
+			nodeView.Actions = append(nodeView.Actions, &action.ActionView{
+				Kind:         act.GetKind(),
+				Name:         act.GetName(),
+				ComponentKey: key,
+				Cluster:      diff.getCluster(key),
+				Reason:       node.Reasons[act.GetName()],
+			})
+		}
+		result.Nodes = append(result.Nodes, nodeView)
+	}
+
+	return result
+}
+
+// Returns sorted keys of nodes with actions, which have to be processed before a given node. Nodes without actions
+// are traversed, so transitive dependencies are not lost
+func (diff *PolicyResolutionDiff) getBeforeKeys(node *action.GraphNode) []string {
+	result := []string{}
+	seen := make(map[string]bool)
+	queue := append([]*action.GraphNode{}, node.Before...)
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		if seen[next.Key] {
+			continue
+		}
+		seen[next.Key] = true
+
+		if len(next.Actions) > 0 {
+			result = append(result, next.Key)
+		} else {
+			queue = append(queue, next.Before...)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Returns cluster for a given component instance, looking into next state first and then into prev state
+func (diff *PolicyResolutionDiff) getCluster(key string) string {
+	if instance := diff.Next.ComponentInstanceMap[key]; instance != nil {
+		return instance.GetCluster()
+	}
+	if instance := diff.Prev.ComponentInstanceMap[key]; instance != nil {
+		return instance.GetCluster()
+	}
+	return ""
+}
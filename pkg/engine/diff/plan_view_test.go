@@ -0,0 +1,54 @@
+package diff
+
+import (
+	"github.com/Aptomi/aptomi/pkg/engine/apply/action"
+	"github.com/Aptomi/aptomi/pkg/engine/apply/action/component"
+	"github.com/Aptomi/aptomi/pkg/lang"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDiffPlanView(t *testing.T) {
+	b := makePolicyBuilder()
+	resolvedPrev := resolvePolicy(t, b)
+
+	// add dependency
+	d1 := b.AddDependency(b.AddUser(), b.Policy().GetObjectsByKind(lang.ContractObject.Kind)[0].(*lang.Contract))
+	d1.Labels["param"] = "value1"
+	resolvedNext := resolvePolicy(t, b)
+
+	// plan view should contain create actions with a new consumer as the reason
+	view := NewPolicyResolutionDiff(resolvedNext, resolvedPrev).GetPlanView()
+	assert.Equal(t, 5, view.NumberOfActions(), "Plan view should contain all actions")
+	codeKey := getCodeInstance(t, resolvedNext).GetKey()
+	for _, node := range view.Nodes {
+		for _, act := range node.Actions {
+			assert.Equal(t, node.Key, act.ComponentKey, "Action should be applied to the component instance of its node")
+			assert.NotEmpty(t, act.Cluster, "Action should have cluster set")
+			if act.Kind == component.CreateActionObject.Kind {
+				assert.Equal(t, action.ReasonNewConsumer, act.Reason.Kind, "Component instance should be created for a new consumer")
+			}
+		}
+		if node.Key != codeKey {
+			assert.Contains(t, node.Before, codeKey, "Code component instance should be processed before service instance")
+		}
+	}
+
+	// update dependency
+	d1.Labels["param"] = "value2"
+	resolvedNextAgain := resolvePolicy(t, b)
+
+	// plan view should contain update action with the diff of code parameters
+	view = NewPolicyResolutionDiff(resolvedNextAgain, resolvedNext).GetPlanView()
+	found := false
+	for _, node := range view.Nodes {
+		for _, act := range node.Actions {
+			if act.Kind == component.UpdateActionObject.Kind && act.ComponentKey == codeKey {
+				found = true
+				assert.Equal(t, action.ReasonParamsChanged, act.Reason.Kind, "Component instance should be updated due to changed params")
+				assert.Contains(t, act.Reason.Diff, "value2", "Reason should contain the diff of code params")
+			}
+		}
+	}
+	assert.True(t, found, "Plan view should contain update action for code component instance")
+}
@@ -50,6 +50,11 @@ func (diff *PolicyResolutionDiff) compareAndProduceActions() {
 		diff.buildActions(key)
 	}
 
+	// Refresh component instances in actual state, if their data changed and there are no other actions for them
+	for key := range allCompInstances {
+		diff.buildRefreshActions(key)
+	}
+
 	// Generate dependencies between actions
 	for key := range allCompInstances {
 		outgoing := make(map[string]bool)
@@ -97,49 +102,121 @@ func (diff *PolicyResolutionDiff) buildActions(key string) {
 
 	// See if a component needs to be instantiated
 	if len(depKeysPrev) <= 0 && len(depKeysNext) > 0 {
-		node.AddAction(component.NewCreateAction(key), true)
+		node.AddAction(component.NewCreateAction(key), action.NewReason(action.ReasonNewConsumer), true)
 		endpointsAction = true
 	}
 
-	// See if a component needs to be updated
-	if len(depKeysPrev) > 0 && len(depKeysNext) > 0 && isCodeComponent {
+	// See if a component drifted away in the cloud and needs to be repaired (re-created or updated)
+	repairCreate, repairUpdate := false, false
+	if len(depKeysPrev) > 0 && len(depKeysNext) > 0 && isCodeComponent && prevInstance.Drift != nil && prevInstance.Drift.Repair {
+		if !prevInstance.Drift.Exists {
+			node.AddAction(component.NewCreateAction(key), action.NewReason(action.ReasonDriftRepair), true)
+			repairCreate = true
+			endpointsAction = true
+		} else if !prevInstance.Drift.Matches {
+			repairUpdate = true
+		}
+	}
+
+	// See if a component needs to be updated. Changes of data for plugins don't require deployment to be updated
+	updateAction := false
+	if len(depKeysPrev) > 0 && len(depKeysNext) > 0 && isCodeComponent && !repairCreate {
 		sameParams := prevInstance.CalculatedCodeParams.DeepEqual(nextInstance.CalculatedCodeParams)
-		if !sameParams {
-			node.AddAction(component.NewUpdateAction(key), true)
+		sameDeployName := prevInstance.GetDeployBaseName() == nextInstance.GetDeployBaseName()
+		if !sameParams || !sameDeployName || repairUpdate {
+			reason := action.NewReason(action.ReasonDriftRepair)
+			if !sameParams {
+				reason = &action.Reason{Kind: action.ReasonParamsChanged, Diff: prevInstance.CalculatedCodeParams.Diff(nextInstance.CalculatedCodeParams)}
+			} else if !sameDeployName {
+				reason = action.NewReason(action.ReasonDeployNameChanged)
+			}
+			node.AddAction(component.NewUpdateAction(key), reason, true)
+			updateAction = true
 
 			// indicate that a parent service component instance gets updated as well
 			// this is required for adjusting update/creation times of a service with changed component
 			// this may produce duplicate "update" actions for the parent service
 			serviceKey := nextInstance.Metadata.Key.GetParentServiceKey().GetKey()
 			serviceNode := diff.ActionPlan.GetActionGraphNode(serviceKey)
-			serviceNode.AddAction(component.NewUpdateAction(serviceKey), true)
+			serviceNode.AddAction(component.NewUpdateAction(serviceKey), action.NewReason(action.ReasonComponentChanged), true)
 
 			endpointsAction = true
 		}
 	}
 
+	// See if a component needs to be reconfigured, as its data for plugins changed (create and update apply it anyway)
+	if len(depKeysPrev) > 0 && len(depKeysNext) > 0 && isCodeComponent && !repairCreate && !updateAction {
+		if !equalDataForPlugins(prevInstance.DataForPlugins, nextInstance.DataForPlugins) {
+			node.AddAction(component.NewReconfigureAction(key), action.NewReason(action.ReasonPluginDataChanged), true)
+		}
+	}
+
 	// See if a user needs to be attached to a component
 	for dependencyID := range depKeysNext {
 		if !depKeysPrev[dependencyID] {
-			node.AddAction(component.NewAttachDependencyAction(key, dependencyID), true)
+			node.AddAction(component.NewAttachDependencyAction(key, dependencyID), action.NewReason(action.ReasonConsumerAttached), true)
 		}
 	}
 
 	// See if a user needs to be detached from a component
 	for dependencyID := range depKeysPrev {
 		if !depKeysNext[dependencyID] {
-			node.AddAction(component.NewDetachDependencyAction(key, dependencyID), true)
+			node.AddAction(component.NewDetachDependencyAction(key, dependencyID), action.NewReason(action.ReasonConsumerDetached), true)
 		}
 	}
 
 	// See if a component needs to be destructed
 	if len(depKeysPrev) > 0 && len(depKeysNext) <= 0 {
-		node.AddAction(component.NewDeleteAction(key), true)
+		if diff.Next.IsProtected(prevInstance) {
+			// protected component instance is not going to be destructed, action will fail instead. protection is
+			// decided by the desired policy, so instances of forcefully removed or unprotected objects get destructed
+			node.AddAction(component.NewProtectedDeleteAction(key), action.NewReason(action.ReasonLastConsumerDetached), true)
+		} else {
+			node.AddAction(component.NewDeleteAction(key), action.NewReason(action.ReasonLastConsumerDetached), true)
+		}
 		endpointsAction = false
 	}
 
 	// See if we need to retrieve component endpoints
 	if endpointsAction && isCodeComponent {
-		node.AddAction(component.NewEndpointsAction(key), true)
+		node.AddAction(component.NewEndpointsAction(key), action.NewReason(action.ReasonDeploymentChanged), true)
+	}
+}
+
+// Refreshes a given component instance in actual state, if its labels, discovery parameters, data for plugins or
+// protection have changed, but no other actions have been generated for it (all other actions update actual state anyway)
+func (diff *PolicyResolutionDiff) buildRefreshActions(key string) {
+	node := diff.ActionPlan.GetActionGraphNode(key)
+	if len(node.Actions) > 0 {
+		return
+	}
+
+	prevInstance := diff.Prev.ComponentInstanceMap[key]
+	nextInstance := diff.Next.ComponentInstanceMap[key]
+	if prevInstance == nil || nextInstance == nil || len(prevInstance.DependencyKeys) <= 0 || len(nextInstance.DependencyKeys) <= 0 {
+		return
+	}
+
+	sameLabels := prevInstance.CalculatedLabels.Equal(nextInstance.CalculatedLabels)
+	sameDiscovery := prevInstance.CalculatedDiscovery.DeepEqual(nextInstance.CalculatedDiscovery)
+	samePluginData := equalDataForPlugins(prevInstance.DataForPlugins, nextInstance.DataForPlugins)
+	sameProtected := prevInstance.Protected == nextInstance.Protected
+	if !sameLabels || !sameDiscovery || !samePluginData || !sameProtected {
+		node.AddAction(component.NewRefreshAction(key), action.NewReason(action.ReasonStateChanged), true)
+	}
+}
+
+// Compares data for plugins of two component instances. Nil and empty maps are considered equal
+func equalDataForPlugins(prev map[string]string, next map[string]string) bool {
+	for k, v := range prev {
+		if nextValue, ok := next[k]; !ok || nextValue != v {
+			return false
+		}
+	}
+	for k := range next {
+		if _, ok := prev[k]; !ok {
+			return false
+		}
 	}
+	return true
 }
@@ -1,6 +1,7 @@
 package diff
 
 import (
+	"context"
 	"fmt"
 	"github.com/Aptomi/aptomi/pkg/engine/apply/action"
 	"github.com/Aptomi/aptomi/pkg/engine/apply/action/component"
@@ -20,7 +21,7 @@ func TestDiffEmpty(t *testing.T) {
 
 	// diff should be empty
 	diff := NewPolicyResolutionDiff(resolvedNext, resolvedPrev)
-	verifyDiff(t, diff, 0, 0, 0, 0, 0, 0)
+	verifyDiff(t, diff, 0, 0, 0, 0, 0, 0, 0)
 }
 
 func TestDiffComponentCreationAndAttachDependency(t *testing.T) {
@@ -34,7 +35,7 @@ func TestDiffComponentCreationAndAttachDependency(t *testing.T) {
 
 	// diff should contain instantiated component
 	diff := NewPolicyResolutionDiff(resolvedNext, resolvedPrev)
-	verifyDiff(t, diff, 2, 0, 0, 2, 0, 1)
+	verifyDiff(t, diff, 2, 0, 0, 2, 0, 1, 0)
 
 	// add another dependency
 	d2 := b.AddDependency(b.AddUser(), b.Policy().GetObjectsByKind(lang.ContractObject.Kind)[0].(*lang.Contract))
@@ -43,7 +44,7 @@ func TestDiffComponentCreationAndAttachDependency(t *testing.T) {
 
 	// component should not be instantiated again (it's already there), just new dependency should be attached
 	diffAgain := NewPolicyResolutionDiff(resolvedNextAgain, resolvedNext)
-	verifyDiff(t, diffAgain, 0, 0, 0, 2, 0, 0)
+	verifyDiff(t, diffAgain, 0, 0, 0, 2, 0, 0, 0)
 }
 
 func TestDiffComponentUpdate(t *testing.T) {
@@ -57,7 +58,7 @@ func TestDiffComponentUpdate(t *testing.T) {
 
 	// diff should contain instantiated component
 	diff := NewPolicyResolutionDiff(resolvedNext, resolvedPrev)
-	verifyDiff(t, diff, 2, 0, 0, 2, 0, 1)
+	verifyDiff(t, diff, 2, 0, 0, 2, 0, 1, 0)
 
 	// update dependency
 	d1.Labels["param"] = "value2"
@@ -65,7 +66,7 @@ func TestDiffComponentUpdate(t *testing.T) {
 
 	// component should be updated
 	diffAgain := NewPolicyResolutionDiff(resolvedNextAgain, resolvedNext)
-	verifyDiff(t, diffAgain, 0, 0, 2, 0, 0, 1)
+	verifyDiff(t, diffAgain, 0, 0, 2, 0, 0, 1, 0)
 }
 
 func TestDiffComponentDelete(t *testing.T) {
@@ -79,14 +80,148 @@ func TestDiffComponentDelete(t *testing.T) {
 
 	// diff should contain instantiated component
 	diff := NewPolicyResolutionDiff(resolvedNext, resolvedPrev)
-	verifyDiff(t, diff, 2, 0, 0, 2, 0, 1)
+	verifyDiff(t, diff, 2, 0, 0, 2, 0, 1, 0)
 
 	// resolve empty policy
 	resolvedEmpty := resolvePolicy(t, builder.NewPolicyBuilder())
 
 	// diff should contain destructed component
 	diffAgain := NewPolicyResolutionDiff(resolvedEmpty, resolvedNext)
-	verifyDiff(t, diffAgain, 0, 2, 0, 0, 2, 0)
+	verifyDiff(t, diffAgain, 0, 2, 0, 0, 2, 0, 0)
+}
+
+func TestDiffComponentSuspend(t *testing.T) {
+	b := makePolicyBuilder()
+
+	// add dependency
+	d1 := b.AddDependency(b.AddUser(), b.Policy().GetObjectsByKind(lang.ContractObject.Kind)[0].(*lang.Contract))
+	d1.Labels["param"] = "value1"
+	resolvedPrev := resolvePolicy(t, b)
+
+	// suspend dependency, component should be destructed
+	d1.Suspended = true
+	resolvedSuspended := resolvePolicy(t, b)
+	verifyDiff(t, NewPolicyResolutionDiff(resolvedSuspended, resolvedPrev), 0, 2, 0, 0, 2, 0, 0)
+
+	// resume dependency, component should be instantiated again
+	d1.Suspended = false
+	resolvedResumed := resolvePolicy(t, b)
+	verifyDiff(t, NewPolicyResolutionDiff(resolvedResumed, resolvedSuspended), 2, 0, 0, 2, 0, 1, 0)
+}
+
+func TestDiffComponentDeleteProtected(t *testing.T) {
+	b := makePolicyBuilder()
+
+	// add protected dependency
+	d1 := b.AddDependency(b.AddUser(), b.Policy().GetObjectsByKind(lang.ContractObject.Kind)[0].(*lang.Contract))
+	d1.Labels["param"] = "value1"
+	d1.Protected = true
+	resolvedPrev := resolvePolicy(t, b)
+	assert.True(t, getCodeInstance(t, resolvedPrev).Protected, "Component instance should be protected")
+
+	// suspend dependency, which stays protected in the policy
+	d1.Suspended = true
+	resolvedSuspended := resolvePolicy(t, b)
+
+	// diff should contain delete actions, which will fail instead of destructing protected components
+	diff := NewPolicyResolutionDiff(resolvedSuspended, resolvedPrev)
+	verifyDiff(t, diff, 0, 2, 0, 0, 2, 0, 0)
+	verifyDeleteProtected(t, diff, true)
+
+	// unprotect dependency, components should be destructed
+	d1.Protected = false
+	resolvedUnprotected := resolvePolicy(t, b)
+	diff = NewPolicyResolutionDiff(resolvedUnprotected, resolvedPrev)
+	verifyDiff(t, diff, 0, 2, 0, 0, 2, 0, 0)
+	verifyDeleteProtected(t, diff, false)
+
+	// remove dependency by force (resolve empty policy), components should be destructed
+	diff = NewPolicyResolutionDiff(resolvePolicy(t, builder.NewPolicyBuilder()), resolvedPrev)
+	verifyDiff(t, diff, 0, 2, 0, 0, 2, 0, 0)
+	verifyDeleteProtected(t, diff, false)
+}
+
+func TestDiffComponentProtectionRefresh(t *testing.T) {
+	b := makePolicyBuilder()
+
+	// add dependency
+	d1 := b.AddDependency(b.AddUser(), b.Policy().GetObjectsByKind(lang.ContractObject.Kind)[0].(*lang.Contract))
+	d1.Labels["param"] = "value1"
+	resolvedPrev := resolvePolicy(t, b)
+
+	// protect dependency, components should be refreshed in actual state
+	d1.Protected = true
+	resolvedProtected := resolvePolicy(t, b)
+	verifyDiff(t, NewPolicyResolutionDiff(resolvedProtected, resolvedPrev), 0, 0, 0, 0, 0, 0, 2)
+
+	// unprotect dependency, components should be refreshed in actual state again
+	d1.Protected = false
+	verifyDiff(t, NewPolicyResolutionDiff(resolvePolicy(t, b), resolvedProtected), 0, 0, 0, 0, 0, 0, 2)
+}
+
+func TestDiffComponentDriftRepair(t *testing.T) {
+	b := makePolicyBuilder()
+
+	// add dependency
+	d1 := b.AddDependency(b.AddUser(), b.Policy().GetObjectsByKind(lang.ContractObject.Kind)[0].(*lang.Contract))
+	d1.Labels["param"] = "value1"
+	resolvedPrev := resolvePolicy(t, b)
+	resolvedNext := resolvePolicy(t, b)
+
+	// mark code component as missing in the cloud, but without repair
+	drifted := getCodeInstance(t, resolvedPrev)
+	drifted.Drift = &resolve.DriftStatus{Exists: false, Matches: false}
+	verifyDiff(t, NewPolicyResolutionDiff(resolvedNext, resolvedPrev), 0, 0, 0, 0, 0, 0, 0)
+
+	// component should be re-created, once repair is requested
+	drifted.Drift.Repair = true
+	verifyDiff(t, NewPolicyResolutionDiff(resolvedNext, resolvedPrev), 1, 0, 0, 0, 0, 1, 0)
+
+	// component should be updated, if it exists, but doesn't match
+	drifted.Drift.Exists = true
+	verifyDiff(t, NewPolicyResolutionDiff(resolvedNext, resolvedPrev), 0, 0, 2, 0, 0, 1, 0)
+}
+
+func TestDiffComponentDataChanges(t *testing.T) {
+	b := makePolicyBuilder()
+
+	// add dependency
+	d1 := b.AddDependency(b.AddUser(), b.Policy().GetObjectsByKind(lang.ContractObject.Kind)[0].(*lang.Contract))
+	d1.Labels["param"] = "value1"
+	resolvedPrev := resolvePolicy(t, b)
+	resolvedNext := resolvePolicy(t, b)
+
+	// component should be refreshed in actual state, if its discovery params have changed
+	changed := getCodeInstance(t, resolvedPrev)
+	changed.CalculatedDiscovery = util.NestedParameterMap{"url": "changed"}
+	verifyDiff(t, NewPolicyResolutionDiff(resolvedNext, resolvedPrev), 0, 0, 0, 0, 0, 0, 1)
+
+	// component should be refreshed in actual state, if its labels have changed
+	changed.CalculatedDiscovery = getCodeInstance(t, resolvedNext).CalculatedDiscovery
+	changed.CalculatedLabels = lang.NewLabelSet(map[string]string{"label": "changed"})
+	verifyDiff(t, NewPolicyResolutionDiff(resolvedNext, resolvedPrev), 0, 0, 0, 0, 0, 0, 1)
+
+	// component should only be reconfigured in the cloud, if data for plugins has changed
+	changed.CalculatedLabels = getCodeInstance(t, resolvedNext).CalculatedLabels
+	changed.DataForPlugins = map[string]string{resolve.AllowIngres: "changed"}
+	verifyReconfigureOnly(t, NewPolicyResolutionDiff(resolvedNext, resolvedPrev))
+
+	// component should be updated in the cloud, if its deploy name has changed
+	changed.DataForPlugins = getCodeInstance(t, resolvedNext).DataForPlugins
+	changed.DeployName = "changed"
+	verifyDiff(t, NewPolicyResolutionDiff(resolvedNext, resolvedPrev), 0, 0, 2, 0, 0, 1, 0)
+
+	// component should only be reconfigured in the cloud, if allowed ingress sources have changed
+	changed.DeployName = getCodeInstance(t, resolvedNext).DeployName
+	changed.DataForPlugins = map[string]string{resolve.AllowIngressFrom: "changed"}
+	for key, value := range getCodeInstance(t, resolvedNext).DataForPlugins {
+		changed.DataForPlugins[key] = value
+	}
+	verifyReconfigureOnly(t, NewPolicyResolutionDiff(resolvedNext, resolvedPrev))
+
+	// data for plugins is re-applied by update, if deployment has to be updated anyway
+	changed.CalculatedCodeParams = util.NestedParameterMap{"param": "changed"}
+	verifyDiff(t, NewPolicyResolutionDiff(resolvedNext, resolvedPrev), 0, 0, 2, 0, 0, 1, 0)
 }
 
 func TestDiffComponentWithServiceSharing(t *testing.T) {
@@ -96,7 +231,7 @@ func TestDiffComponentWithServiceSharing(t *testing.T) {
 
 	// diff should contain instantiated component
 	diff := NewPolicyResolutionDiff(resolvedNext, resolvedEmpty)
-	verifyDiff(t, diff, 7, 0, 0, 9, 0, 0)
+	verifyDiff(t, diff, 7, 0, 0, 9, 0, 0, 0)
 }
 
 /*
@@ -148,6 +283,17 @@ func makePolicyBuilderWithServiceSharing() *builder.PolicyBuilder {
 	return b
 }
 
+func getCodeInstance(t *testing.T, resolution *resolve.PolicyResolution) *resolve.ComponentInstance {
+	t.Helper()
+	for _, instance := range resolution.ComponentInstanceMap {
+		if instance.IsCode {
+			return instance
+		}
+	}
+	t.Fatal("Code component instance not found in resolution data")
+	return nil
+}
+
 func resolvePolicy(t *testing.T, builder *builder.PolicyBuilder) *resolve.PolicyResolution {
 	t.Helper()
 	eventLog := event.NewLog("test-resolve", false)
@@ -161,7 +307,32 @@ func resolvePolicy(t *testing.T, builder *builder.PolicyBuilder) *resolve.Policy
 	return result
 }
 
-func verifyDiff(t *testing.T, diff *PolicyResolutionDiff, componentInstantiate int, componentDestruct int, componentUpdate int, componentAttachDependency int, componentDetachDependency int, componentEndpoints int) {
+func verifyDeleteProtected(t *testing.T, diff *PolicyResolutionDiff, protected bool) {
+	t.Helper()
+	for _, node := range diff.ActionPlan.NodeMap {
+		for _, act := range node.Actions {
+			if deleteAction, ok := act.(*component.DeleteAction); ok {
+				assert.Equal(t, protected, deleteAction.Protected, "Delete action should be marked as protected only if component instance is protected in the policy")
+				if protected {
+					assert.NotNil(t, deleteAction.Apply(nil), "Protected delete action should fail")
+				}
+			}
+		}
+	}
+}
+
+func verifyReconfigureOnly(t *testing.T, diff *PolicyResolutionDiff) {
+	t.Helper()
+	actions := []action.Base{}
+	for _, node := range diff.ActionPlan.NodeMap {
+		actions = append(actions, node.Actions...)
+	}
+	if assert.Len(t, actions, 1, "Only data for plugins should be re-applied") {
+		assert.IsType(t, &component.ReconfigureAction{}, actions[0], "Only data for plugins should be re-applied")
+	}
+}
+
+func verifyDiff(t *testing.T, diff *PolicyResolutionDiff, componentInstantiate int, componentDestruct int, componentUpdate int, componentAttachDependency int, componentDetachDependency int, componentEndpoints int, componentRefresh int) {
 	t.Helper()
 	cnt := struct {
 		create    int
@@ -170,6 +341,7 @@ func verifyDiff(t *testing.T, diff *PolicyResolutionDiff, componentInstantiate i
 		attach    int
 		detach    int
 		endpoints int
+		refresh   int
 	}{}
 
 	s := []string{}
@@ -187,6 +359,8 @@ func verifyDiff(t *testing.T, diff *PolicyResolutionDiff, componentInstantiate i
 			cnt.detach++
 		case *component.EndpointsAction:
 			cnt.endpoints++
+		case *component.RefreshAction:
+			cnt.refresh++
 		default:
 			t.Fatalf("Incorrect action type: %T", act)
 		}
@@ -194,7 +368,7 @@ func verifyDiff(t *testing.T, diff *PolicyResolutionDiff, componentInstantiate i
 		return nil
 	}
 
-	_ = diff.ActionPlan.Apply(action.WrapSequential(fn), action.NewApplyResultUpdaterImpl())
+	_ = diff.ActionPlan.Apply(context.Background(), action.WrapSequential(fn), action.NewApplyResultUpdaterImpl())
 
 	ok := assert.Equal(t, componentInstantiate, cnt.create, "Diff: component instantiations")
 	ok = ok && assert.Equal(t, componentDestruct, cnt.delete, "Diff: component destructions")
@@ -202,6 +376,7 @@ func verifyDiff(t *testing.T, diff *PolicyResolutionDiff, componentInstantiate i
 	ok = ok && assert.Equal(t, componentAttachDependency, cnt.attach, "Diff: dependencies attached to components")
 	ok = ok && assert.Equal(t, componentDetachDependency, cnt.detach, "Diff: dependencies removed from components")
 	ok = ok && assert.Equal(t, componentEndpoints, cnt.endpoints, "Diff: component endpoints")
+	ok = ok && assert.Equal(t, componentRefresh, cnt.refresh, "Diff: component refreshes")
 
 	if !ok {
 		t.Logf("Log of actions: %s", s)
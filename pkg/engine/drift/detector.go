@@ -0,0 +1,139 @@
+package drift
+
+import (
+	"context"
+	"fmt"
+	"github.com/Aptomi/aptomi/pkg/engine/resolve"
+	"github.com/Aptomi/aptomi/pkg/event"
+	"github.com/Aptomi/aptomi/pkg/lang"
+	"github.com/Aptomi/aptomi/pkg/plugin"
+	"github.com/Aptomi/aptomi/pkg/runtime"
+	"time"
+)
+
+// Result is a result of drift detection
+type Result struct {
+	// Checked is a set of component instance keys, which have been inspected in the cloud
+	Checked map[string]bool
+
+	// Drifted is a map from component instance key to its drift status, only for drifted component instances
+	Drifted map[string]*resolve.DriftStatus
+}
+
+// Detector checks component instances recorded in actual state against deployments running in the cloud
+type Detector struct {
+	policy      *lang.Policy
+	actualState *resolve.PolicyResolution
+	plugins     plugin.Registry
+	eventLog    *event.Log
+}
+
+// NewDetector creates a new drift Detector
+func NewDetector(policy *lang.Policy, actualState *resolve.PolicyResolution, plugins plugin.Registry, eventLog *event.Log) *Detector {
+	return &Detector{
+		policy:      policy,
+		actualState: actualState,
+		plugins:     plugins,
+		eventLog:    eventLog,
+	}
+}
+
+// Detect goes over all code component instances in actual state and asks the corresponding code plugins to inspect
+// their deployments. Component instances, which code plugins don't support inspection, are skipped. Errors are
+// written into the event log and corresponding component instances are skipped as well. Once context is done, the
+// rest of component instances are left unchecked.
+func (detector *Detector) Detect(ctx context.Context) *Result {
+	result := &Result{
+		Checked: make(map[string]bool),
+		Drifted: make(map[string]*resolve.DriftStatus),
+	}
+
+	for key, instance := range detector.actualState.ComponentInstanceMap {
+		if !instance.IsCode {
+			continue
+		}
+
+		if ctx.Err() != nil {
+			detector.eventLog.LogError(fmt.Errorf("drift detection interrupted, component instance '%s' and the rest are not checked: %s", key, ctx.Err()))
+			break
+		}
+
+		status, err := detector.inspect(ctx, instance)
+		if err != nil {
+			detector.eventLog.LogError(fmt.Errorf("error while detecting drift for component instance '%s': %s", key, err))
+			continue
+		}
+		if status == nil {
+			continue
+		}
+
+		result.Checked[key] = true
+		if status.Exists && status.Matches {
+			continue
+		}
+
+		detector.eventLog.WithFields(event.Fields{
+			"componentKey": key,
+			"exists":       status.Exists,
+			"matches":      status.Matches,
+		}).Warningf("Drift detected for component instance %s: %s", key, status.Message)
+
+		result.Drifted[key] = &resolve.DriftStatus{
+			Exists:     status.Exists,
+			Matches:    status.Matches,
+			Message:    status.Message,
+			DetectedAt: time.Now(),
+		}
+	}
+
+	return result
+}
+
+// inspect returns deployment status for a given component instance, or nil if code plugin doesn't support inspection
+func (detector *Detector) inspect(ctx context.Context, instance *resolve.ComponentInstance) (*plugin.DeploymentStatus, error) {
+	codePlugin, err := CodePluginFor(instance, detector.policy, detector.plugins)
+	if err != nil {
+		return nil, err
+	}
+	if codePlugin == nil {
+		return nil, nil
+	}
+
+	inspector, ok := codePlugin.(plugin.DeploymentInspector)
+	if !ok {
+		return nil, nil
+	}
+
+	return inspector.Inspect(ctx, instance.GetDeployName(), instance.CalculatedCodeParams, detector.eventLog)
+}
+
+// CodePluginFor returns code plugin for a given component instance, or nil if component instance doesn't have code
+func CodePluginFor(instance *resolve.ComponentInstance, policy *lang.Policy, plugins plugin.Registry) (plugin.CodePlugin, error) {
+	serviceObj, err := policy.GetObject(lang.ServiceObject.Kind, instance.Metadata.Key.ServiceName, instance.Metadata.Key.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	if serviceObj == nil {
+		return nil, fmt.Errorf("service '%s/%s' is not present in policy", instance.Metadata.Key.Namespace, instance.Metadata.Key.ServiceName)
+	}
+	component := serviceObj.(*lang.Service).GetComponentsMap()[instance.Metadata.Key.ComponentName]
+
+	if component == nil || component.Code == nil {
+		return nil, nil
+	}
+
+	clusterName := instance.GetCluster()
+	if len(clusterName) <= 0 {
+		return nil, fmt.Errorf("component instance does not have cluster assigned: %s", instance.GetKey())
+	}
+
+	clusterObj, err := policy.GetObject(lang.ClusterObject.Kind, clusterName, runtime.SystemNS)
+	if err != nil {
+		return nil, err
+	}
+	if clusterObj == nil {
+		return nil, fmt.Errorf("cluster '%s' in not present in policy", clusterName)
+	}
+
+	return plugins.ForCodeType(clusterObj.(*lang.Cluster), component.Code.Type)
+}
@@ -0,0 +1,128 @@
+package drift
+
+import (
+	"context"
+	"github.com/Aptomi/aptomi/pkg/config"
+	"github.com/Aptomi/aptomi/pkg/engine/resolve"
+	"github.com/Aptomi/aptomi/pkg/event"
+	"github.com/Aptomi/aptomi/pkg/lang"
+	"github.com/Aptomi/aptomi/pkg/lang/builder"
+	"github.com/Aptomi/aptomi/pkg/plugin"
+	"github.com/Aptomi/aptomi/pkg/plugin/fake"
+	"github.com/Aptomi/aptomi/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDetectNoDrift(t *testing.T) {
+	b := makePolicyBuilder()
+	actualState := resolvePolicy(t, b)
+
+	result := NewDetector(b.Policy(), actualState, mockRegistry(&plugin.DeploymentStatus{Exists: true, Matches: true}), event.NewLog("test-drift", false)).Detect(context.Background())
+
+	assert.Equal(t, 1, len(result.Checked), "Code component instance should be checked")
+	assert.Equal(t, 0, len(result.Drifted), "No drift should be detected")
+}
+
+func TestDetectDrift(t *testing.T) {
+	b := makePolicyBuilder()
+	actualState := resolvePolicy(t, b)
+
+	// deployment is missing
+	result := NewDetector(b.Policy(), actualState, mockRegistry(&plugin.DeploymentStatus{Message: "missing"}), event.NewLog("test-drift", false)).Detect(context.Background())
+	assert.Equal(t, 1, len(result.Checked), "Code component instance should be checked")
+	if assert.Equal(t, 1, len(result.Drifted), "Drift should be detected for missing deployment") {
+		for key, status := range result.Drifted {
+			assert.True(t, actualState.ComponentInstanceMap[key].IsCode, "Drift should be reported for code component instance")
+			assert.False(t, status.Exists, "Deployment should be reported as missing")
+			assert.Equal(t, "missing", status.Message, "Drift message should be propagated from code plugin")
+		}
+	}
+
+	// deployment exists, but doesn't match
+	result = NewDetector(b.Policy(), actualState, mockRegistry(&plugin.DeploymentStatus{Exists: true}), event.NewLog("test-drift", false)).Detect(context.Background())
+	if assert.Equal(t, 1, len(result.Drifted), "Drift should be detected for changed deployment") {
+		for _, status := range result.Drifted {
+			assert.True(t, status.Exists, "Deployment should be reported as existing")
+			assert.False(t, status.Matches, "Deployment should be reported as not matching")
+		}
+	}
+}
+
+func TestDetectCancelled(t *testing.T) {
+	b := makePolicyBuilder()
+	actualState := resolvePolicy(t, b)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result := NewDetector(b.Policy(), actualState, mockRegistry(&plugin.DeploymentStatus{Message: "missing"}), event.NewLog("test-drift", false)).Detect(ctx)
+	assert.Equal(t, 0, len(result.Checked), "Nothing should be checked once context is done")
+	assert.Equal(t, 0, len(result.Drifted), "No drift should be reported once context is done")
+}
+
+/*
+	Helpers
+*/
+
+type inspectorPlugin struct {
+	plugin.CodePlugin
+	status *plugin.DeploymentStatus
+}
+
+func (p *inspectorPlugin) Inspect(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) (*plugin.DeploymentStatus, error) {
+	return p.status, nil
+}
+
+func makePolicyBuilder() *builder.PolicyBuilder {
+	b := builder.NewPolicyBuilder()
+
+	// create a service
+	service := b.AddService()
+	b.AddServiceComponent(service,
+		b.CodeComponent(
+			util.NestedParameterMap{"param": "value"},
+			nil,
+		),
+	)
+	contract := b.AddContract(service, b.CriteriaTrue())
+
+	// add rule to set cluster
+	clusterObj := b.AddCluster()
+	b.AddRule(b.CriteriaTrue(), b.RuleActions(lang.NewLabelOperationsSetSingleLabel(lang.LabelCluster, clusterObj.Name)))
+
+	// add dependency
+	b.AddDependency(b.AddUser(), contract)
+
+	return b
+}
+
+func resolvePolicy(t *testing.T, b *builder.PolicyBuilder) *resolve.PolicyResolution {
+	t.Helper()
+	eventLog := event.NewLog("test-resolve", false)
+	resolver := resolve.NewPolicyResolver(b.Policy(), b.External(), eventLog)
+	result := resolver.ResolveAllDependencies()
+	if !assert.True(t, result.AllDependenciesResolvedSuccessfully(), "All dependencies should be resolved successfully") {
+		hook := &event.HookConsole{}
+		eventLog.Save(hook)
+		t.FailNow()
+	}
+
+	return result
+}
+
+func mockRegistry(status *plugin.DeploymentStatus) plugin.Registry {
+	clusterTypes := make(map[string]plugin.ClusterPluginConstructor)
+	codeTypes := make(map[string]map[string]plugin.CodePluginConstructor)
+
+	clusterTypes["kubernetes"] = func(cluster *lang.Cluster, cfg config.Plugins) (plugin.ClusterPlugin, error) {
+		return fake.NewNoOpClusterPlugin(0), nil
+	}
+
+	codeTypes["kubernetes"] = make(map[string]plugin.CodePluginConstructor)
+	codeTypes["kubernetes"]["helm"] = func(cluster plugin.ClusterPlugin, cfg config.Plugins) (plugin.CodePlugin, error) {
+		return &inspectorPlugin{CodePlugin: fake.NewNoOpCodePlugin(0), status: status}, nil
+	}
+
+	return plugin.NewRegistry(config.Plugins{}, clusterTypes, codeTypes)
+}
@@ -0,0 +1,4 @@
+// Package drift implements detection of drift between actual state stored by Aptomi and what's actually running in
+// the cloud. Actual state is only what Aptomi believes it did, so if a deployment gets deleted or changed by hand,
+// drift detector will notice it by asking code plugins to inspect the corresponding deployments.
+package drift
@@ -15,6 +15,8 @@ var (
 		component.AttachDependencyActionObject,
 		component.DetachDependencyActionObject,
 		component.EndpointsActionObject,
+		component.RefreshActionObject,
+		component.ReconfigureActionObject,
 	}
 
 	// Objects is the list of informational objects for all objects in the engine
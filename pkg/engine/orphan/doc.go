@@ -0,0 +1,5 @@
+// Package orphan implements detection and garbage collection of orphaned deployments. Orphaned deployment is a
+// deployment in the cloud, which has been created by Aptomi (i.e. has a deploy name generated by Aptomi), but doesn't
+// have a corresponding component instance in actual state. Such deployments may appear if Aptomi crashed in the
+// middle of creating a component instance, or after actual state has been reset.
+package orphan
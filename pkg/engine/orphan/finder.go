@@ -0,0 +1,166 @@
+package orphan
+
+import (
+	"context"
+	"fmt"
+	"github.com/Aptomi/aptomi/pkg/engine/resolve"
+	"github.com/Aptomi/aptomi/pkg/event"
+	"github.com/Aptomi/aptomi/pkg/lang"
+	"github.com/Aptomi/aptomi/pkg/plugin"
+	"github.com/Aptomi/aptomi/pkg/runtime"
+	"github.com/Aptomi/aptomi/pkg/util"
+	"sort"
+)
+
+// Orphan represents an orphaned deployment in the cloud
+type Orphan struct {
+	// Cluster is a name of the cluster, where deployment is running
+	Cluster string
+
+	// CodeType is a type of the code plugin, which manages deployment
+	CodeType string
+
+	// DeployName is a name of the deployment inside the cluster
+	DeployName string
+
+	// Params are the parameters required by code plugin to destroy deployment
+	Params util.NestedParameterMap `yaml:"-"`
+}
+
+// Finder finds orphaned deployments in all clusters defined in policy and deletes them
+type Finder struct {
+	policy      *lang.Policy
+	actualState *resolve.PolicyResolution
+	plugins     plugin.Registry
+	eventLog    *event.Log
+}
+
+// NewFinder creates a new orphaned deployments Finder
+func NewFinder(policy *lang.Policy, actualState *resolve.PolicyResolution, plugins plugin.Registry, eventLog *event.Log) *Finder {
+	return &Finder{
+		policy:      policy,
+		actualState: actualState,
+		plugins:     plugins,
+		eventLog:    eventLog,
+	}
+}
+
+// Find asks code plugins of all code types used in policy to list deployments created by Aptomi in every cluster, and
+// returns the ones which don't have a corresponding component instance in actual state. Code plugins, which don't
+// support listing, are skipped. Errors are written into the event log.
+func (finder *Finder) Find(ctx context.Context) []*Orphan {
+	// deploy names of all code component instances in actual state, grouped by cluster
+	expected := make(map[string]map[string]bool)
+	for _, instance := range finder.actualState.ComponentInstanceMap {
+		if !instance.IsCode {
+			continue
+		}
+		if _, ok := expected[instance.GetCluster()]; !ok {
+			expected[instance.GetCluster()] = make(map[string]bool)
+		}
+		expected[instance.GetCluster()][instance.GetDeployName()] = true
+	}
+
+	result := []*Orphan{}
+	for _, obj := range finder.policy.GetObjectsByKind(lang.ClusterObject.Kind) {
+		cluster := obj.(*lang.Cluster)
+		for _, codeType := range finder.codeTypes() {
+			deployments, err := finder.list(ctx, cluster, codeType)
+			if err != nil {
+				finder.eventLog.LogError(fmt.Errorf("error while listing deployments of type '%s' in cluster '%s': %s", codeType, cluster.Name, err))
+				continue
+			}
+
+			for deployName, params := range deployments {
+				if expected[cluster.Name][deployName] {
+					continue
+				}
+				result = append(result, &Orphan{
+					Cluster:    cluster.Name,
+					CodeType:   codeType,
+					DeployName: deployName,
+					Params:     params,
+				})
+			}
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Cluster != result[j].Cluster {
+			return result[i].Cluster < result[j].Cluster
+		}
+		if result[i].CodeType != result[j].CodeType {
+			return result[i].CodeType < result[j].CodeType
+		}
+		return result[i].DeployName < result[j].DeployName
+	})
+
+	return result
+}
+
+// Delete destroys a given orphaned deployment via code plugin. If dryRun is true, it only records what would be
+// deleted into the event log
+func (finder *Finder) Delete(ctx context.Context, orphan *Orphan, dryRun bool) error {
+	if dryRun {
+		finder.eventLog.WithFields(event.Fields{
+			"cluster":    orphan.Cluster,
+			"deployName": orphan.DeployName,
+		}).Infof("Orphaned deployment %s (%s) in cluster %s would be deleted (dry run)", orphan.DeployName, orphan.CodeType, orphan.Cluster)
+		return nil
+	}
+
+	clusterObj, err := finder.policy.GetObject(lang.ClusterObject.Kind, orphan.Cluster, runtime.SystemNS)
+	if err != nil {
+		return err
+	}
+	if clusterObj == nil {
+		return fmt.Errorf("cluster '%s' in not present in policy", orphan.Cluster)
+	}
+
+	codePlugin, err := finder.plugins.ForCodeType(clusterObj.(*lang.Cluster), orphan.CodeType)
+	if err != nil {
+		return err
+	}
+
+	finder.eventLog.WithFields(event.Fields{
+		"cluster":    orphan.Cluster,
+		"deployName": orphan.DeployName,
+	}).Infof("Deleting orphaned deployment %s (%s) in cluster %s", orphan.DeployName, orphan.CodeType, orphan.Cluster)
+
+	return codePlugin.Destroy(ctx, orphan.DeployName, orphan.Params, finder.eventLog)
+}
+
+// list returns deployments for a given cluster and code type, or nil if code plugin doesn't support listing
+func (finder *Finder) list(ctx context.Context, cluster *lang.Cluster, codeType string) (map[string]util.NestedParameterMap, error) {
+	codePlugin, err := finder.plugins.ForCodeType(cluster, codeType)
+	if err != nil {
+		return nil, err
+	}
+
+	lister, ok := codePlugin.(plugin.DeploymentLister)
+	if !ok {
+		return nil, nil
+	}
+
+	return lister.List(ctx, finder.eventLog)
+}
+
+// codeTypes returns a sorted list of code types used by service components in policy
+func (finder *Finder) codeTypes() []string {
+	codeTypes := make(map[string]bool)
+	for _, obj := range finder.policy.GetObjectsByKind(lang.ServiceObject.Kind) {
+		for _, component := range obj.(*lang.Service).Components {
+			if component.Code != nil {
+				codeTypes[component.Code.Type] = true
+			}
+		}
+	}
+
+	result := []string{}
+	for codeType := range codeTypes {
+		result = append(result, codeType)
+	}
+	sort.Strings(result)
+
+	return result
+}
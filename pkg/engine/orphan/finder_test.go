@@ -0,0 +1,139 @@
+package orphan
+
+import (
+	"context"
+	"github.com/Aptomi/aptomi/pkg/config"
+	"github.com/Aptomi/aptomi/pkg/engine/resolve"
+	"github.com/Aptomi/aptomi/pkg/event"
+	"github.com/Aptomi/aptomi/pkg/lang"
+	"github.com/Aptomi/aptomi/pkg/lang/builder"
+	"github.com/Aptomi/aptomi/pkg/plugin"
+	"github.com/Aptomi/aptomi/pkg/plugin/fake"
+	"github.com/Aptomi/aptomi/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const orphanDeployName = "a-0123456789abc"
+
+func TestFindOrphans(t *testing.T) {
+	b := makePolicyBuilder()
+	actualState := resolvePolicy(t, b)
+	lister := newListerPlugin(actualState, orphanDeployName)
+
+	orphans := NewFinder(b.Policy(), actualState, mockRegistry(lister), event.NewLog("test-orphan", false)).Find(context.Background())
+
+	if assert.Equal(t, 1, len(orphans), "Only deployment without component instance should be reported as orphan") {
+		assert.Equal(t, orphanDeployName, orphans[0].DeployName, "Orphan deploy name")
+		assert.Equal(t, "helm", orphans[0].CodeType, "Orphan code type")
+	}
+}
+
+func TestDeleteOrphans(t *testing.T) {
+	b := makePolicyBuilder()
+	actualState := resolvePolicy(t, b)
+	lister := newListerPlugin(actualState, orphanDeployName)
+	finder := NewFinder(b.Policy(), actualState, mockRegistry(lister), event.NewLog("test-orphan", false))
+
+	orphans := finder.Find(context.Background())
+	if !assert.Equal(t, 1, len(orphans), "Orphan should be found") {
+		t.FailNow()
+	}
+
+	// dry run should not delete anything
+	assert.NoError(t, finder.Delete(context.Background(), orphans[0], true), "Orphan deletion in dry run mode")
+	assert.Equal(t, 0, len(lister.destroyed), "Nothing should be deleted in dry run mode")
+
+	assert.NoError(t, finder.Delete(context.Background(), orphans[0], false), "Orphan deletion")
+	assert.Equal(t, []string{orphanDeployName}, lister.destroyed, "Orphan should be deleted")
+	assert.Equal(t, 0, len(finder.Find(context.Background())), "No orphans should be left after deletion")
+}
+
+/*
+	Helpers
+*/
+
+type listerPlugin struct {
+	plugin.CodePlugin
+	deployments map[string]util.NestedParameterMap
+	destroyed   []string
+}
+
+func newListerPlugin(actualState *resolve.PolicyResolution, orphans ...string) *listerPlugin {
+	result := &listerPlugin{
+		CodePlugin:  fake.NewNoOpCodePlugin(0),
+		deployments: make(map[string]util.NestedParameterMap),
+	}
+	for _, instance := range actualState.ComponentInstanceMap {
+		if instance.IsCode {
+			result.deployments[instance.GetDeployName()] = util.NestedParameterMap{}
+		}
+	}
+	for _, deployName := range orphans {
+		result.deployments[deployName] = util.NestedParameterMap{}
+	}
+	return result
+}
+
+func (p *listerPlugin) List(ctx context.Context, eventLog *event.Log) (map[string]util.NestedParameterMap, error) {
+	return p.deployments, nil
+}
+
+func (p *listerPlugin) Destroy(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) error {
+	delete(p.deployments, deployName)
+	p.destroyed = append(p.destroyed, deployName)
+	return nil
+}
+
+func makePolicyBuilder() *builder.PolicyBuilder {
+	b := builder.NewPolicyBuilder()
+
+	// create a service
+	service := b.AddService()
+	b.AddServiceComponent(service,
+		b.CodeComponent(
+			util.NestedParameterMap{"param": "value"},
+			nil,
+		),
+	)
+	contract := b.AddContract(service, b.CriteriaTrue())
+
+	// add rule to set cluster
+	clusterObj := b.AddCluster()
+	b.AddRule(b.CriteriaTrue(), b.RuleActions(lang.NewLabelOperationsSetSingleLabel(lang.LabelCluster, clusterObj.Name)))
+
+	// add dependency
+	b.AddDependency(b.AddUser(), contract)
+
+	return b
+}
+
+func resolvePolicy(t *testing.T, b *builder.PolicyBuilder) *resolve.PolicyResolution {
+	t.Helper()
+	eventLog := event.NewLog("test-resolve", false)
+	resolver := resolve.NewPolicyResolver(b.Policy(), b.External(), eventLog)
+	result := resolver.ResolveAllDependencies()
+	if !assert.True(t, result.AllDependenciesResolvedSuccessfully(), "All dependencies should be resolved successfully") {
+		hook := &event.HookConsole{}
+		eventLog.Save(hook)
+		t.FailNow()
+	}
+
+	return result
+}
+
+func mockRegistry(codePlugin plugin.CodePlugin) plugin.Registry {
+	clusterTypes := make(map[string]plugin.ClusterPluginConstructor)
+	codeTypes := make(map[string]map[string]plugin.CodePluginConstructor)
+
+	clusterTypes["kubernetes"] = func(cluster *lang.Cluster, cfg config.Plugins) (plugin.ClusterPlugin, error) {
+		return fake.NewNoOpClusterPlugin(0), nil
+	}
+
+	codeTypes["kubernetes"] = make(map[string]plugin.CodePluginConstructor)
+	codeTypes["kubernetes"]["helm"] = func(cluster plugin.ClusterPlugin, cfg config.Plugins) (plugin.CodePlugin, error) {
+		return codePlugin, nil
+	}
+
+	return plugin.NewRegistry(config.Plugins{}, clusterTypes, codeTypes)
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/Aptomi/aptomi/pkg/runtime"
 	"github.com/Aptomi/aptomi/pkg/util"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -26,6 +27,12 @@ type ComponentInstanceMetadata struct {
 // AllowIngres is an special key, which is used in DataForPlugins to indicate whether ingress traffic should be allowed for a given component instance
 const AllowIngres = "allow_ingress"
 
+// AllowIngressFrom is an special key, which is used in DataForPlugins to list deploy names of component instances, which are allowed to send traffic to a given component instance when its ingress traffic is rejected
+const AllowIngressFrom = "allow_ingress_from"
+
+// IngressFromOtherClusters is an special key, which is used in DataForPlugins to list deploy names of component instances running in other clusters, which should be able to send traffic to a given component instance when its ingress traffic is rejected
+const IngressFromOtherClusters = "ingress_from_other_clusters"
+
 // ComponentInstance is an instance of a particular code component within a service, which indicate that this component
 // has to be instantiated and configured in a certain cluster. Policy resolver produces a map of component instances
 // and their parameters in desired state (PolicyResolution) as result of policy resolution.
@@ -72,6 +79,15 @@ type ComponentInstance struct {
 	// DataForPlugins is an additional data recorded for use in plugins
 	DataForPlugins map[string]string
 
+	// Protected means that component instance is backed by a protected service, contract or dependency, so it
+	// should never be destroyed by the engine
+	Protected bool `yaml:",omitempty"`
+
+	// DeployName is a name of deployment in the cloud, calculated from deploy name template defined in policy. If it's
+	// empty, deploy name gets generated from component instance key. In actual state it's the name deployment is
+	// actually running under, so changing the template doesn't affect existing deployments until they get updated
+	DeployName string `yaml:",omitempty"`
+
 	/*
 		These fields get populated during apply and desired -> actual state reconciliation
 	*/
@@ -84,6 +100,37 @@ type ComponentInstance struct {
 
 	// Endpoints represents all URLs that could be used to access deployed service
 	Endpoints map[string]string
+
+	// DeploySuffix is appended to the deploy name of the component instance. It gets switched by blue/green updates,
+	// so a new deployment could be created alongside the old one. In desired state it's taken from actual state, so
+	// discovery parameters refer to the deployment which is currently running
+	DeploySuffix string `yaml:",omitempty"`
+
+	/*
+		These fields get populated by drift detection, comparing actual state with what's running in the cloud
+	*/
+
+	// Drift is the last detected drift for this component instance. It's nil if component instance matches the cloud
+	Drift *DriftStatus `yaml:",omitempty"`
+}
+
+// DriftStatus represents a difference between component instance recorded in actual state and the corresponding
+// deployment in the cloud, as detected by periodic drift detection
+type DriftStatus struct {
+	// Exists is false if deployment is missing in the cloud
+	Exists bool
+
+	// Matches is false if deployment in the cloud doesn't match component instance code params
+	Matches bool
+
+	// Message is a human-readable explanation of the drift
+	Message string
+
+	// DetectedAt is when drift was detected
+	DetectedAt time.Time
+
+	// Repair indicates that drift should be repaired by the engine (by re-creating or updating deployment)
+	Repair bool
 }
 
 // Creates a new component instance
@@ -117,9 +164,26 @@ func (instance *ComponentInstance) GetKey() string {
 
 // GetDeployName returns a string that could be used as name for deployment inside the cluster
 func (instance *ComponentInstance) GetDeployName() string {
+	return instance.GetDeployBaseName() + instance.DeploySuffix
+}
+
+// GetDeployBaseName returns deploy name of the component instance without blue/green suffix
+func (instance *ComponentInstance) GetDeployBaseName() string {
+	if len(instance.DeployName) > 0 {
+		return instance.DeployName
+	}
 	return instance.Metadata.Key.GetDeployName()
 }
 
+// GetNextDeploySuffix returns deploy suffix, which should be used for the next blue/green deployment of the component
+// instance
+func (instance *ComponentInstance) GetNextDeploySuffix() string {
+	if instance.DeploySuffix == DeploySuffixGreen {
+		return DeploySuffixBlue
+	}
+	return DeploySuffixGreen
+}
+
 // GetNamespace returns an object namespace. It's a system namespace for all component instances
 func (instance *ComponentInstance) GetNamespace() string {
 	return runtime.SystemNS
@@ -139,6 +203,34 @@ func (instance *ComponentInstance) addDependency(dependencyKey string) {
 	instance.DependencyKeys[dependencyKey] = true
 }
 
+// IsIngressAllowed returns whether ingress traffic is allowed for the component instance. It's allowed unless rules
+// explicitly rejected it
+func (instance *ComponentInstance) IsIngressAllowed() bool {
+	allowIngress, err := strconv.ParseBool(instance.DataForPlugins[AllowIngres])
+	return err != nil || allowIngress
+}
+
+// GetAllowedIngressFrom returns deploy names of component instances, which are allowed to send traffic to the component
+// instance when its ingress traffic is rejected
+func (instance *ComponentInstance) GetAllowedIngressFrom() []string {
+	if len(instance.DataForPlugins[AllowIngressFrom]) == 0 {
+		return nil
+	}
+
+	return strings.Split(instance.DataForPlugins[AllowIngressFrom], ",")
+}
+
+// GetIngressFromOtherClusters returns deploy names of component instances running in other clusters, which should be
+// able to send traffic to the component instance when its ingress traffic is rejected. Ingress from them can't be
+// allowed, as ingress policies only select deployments within the same cluster
+func (instance *ComponentInstance) GetIngressFromOtherClusters() []string {
+	if len(instance.DataForPlugins[IngressFromOtherClusters]) == 0 {
+		return nil
+	}
+
+	return strings.Split(instance.DataForPlugins[IngressFromOtherClusters], ",")
+}
+
 func (instance *ComponentInstance) addRuleInformation(result *lang.RuleActionResult) {
 	instance.DataForPlugins[AllowIngres] = strconv.FormatBool(!result.RejectIngress)
 }
@@ -162,6 +254,24 @@ func (instance *ComponentInstance) addCodeParams(codeParams util.NestedParameter
 	return nil
 }
 
+func (instance *ComponentInstance) addDeployName(deployName string) error {
+	if len(instance.DeployName) == 0 {
+		// Record deploy name
+		instance.DeployName = deployName
+	} else if len(deployName) > 0 && instance.DeployName != deployName {
+		// Same component instance, different deploy names
+		return errors.NewErrorWithDetails(
+			fmt.Sprintf("Invalid policy. Conflicting deploy names for component instance: %s", instance.GetKey()),
+			errors.Details{
+				"instance":             instance.Metadata.Key,
+				"deploy_name_existing": instance.DeployName,
+				"deploy_name_new":      deployName,
+			},
+		)
+	}
+	return nil
+}
+
 func (instance *ComponentInstance) addDiscoveryParams(discoveryParams util.NestedParameterMap) error {
 	if len(instance.CalculatedDiscovery) == 0 {
 		// Record discovery parameters
@@ -204,6 +314,31 @@ func (instance *ComponentInstance) UpdateTimes(createdAt time.Time, updatedAt ti
 	}
 }
 
+// MakeCopy returns a copy of component instance, including the data populated during apply
+func (instance *ComponentInstance) MakeCopy() *ComponentInstance {
+	result := newComponentInstance(instance.Metadata.Key.MakeCopy())
+	err := result.appendData(instance)
+	if err != nil {
+		// it should never happen, as data is being appended to an empty instance
+		panic(fmt.Sprintf("error while copying component instance %s: %s", instance.GetKey(), err))
+	}
+
+	result.CreatedAt = instance.CreatedAt
+	result.UpdatedAt = instance.UpdatedAt
+	if instance.Endpoints != nil {
+		result.Endpoints = make(map[string]string)
+		for name, url := range instance.Endpoints {
+			result.Endpoints[name] = url
+		}
+	}
+	if instance.Drift != nil {
+		drift := *instance.Drift
+		result.Drift = &drift
+	}
+
+	return result
+}
+
 func (instance *ComponentInstance) appendData(ops *ComponentInstance) error {
 	// List of dependencies which are keeping this component instantiated
 	for dependencyKey := range ops.DependencyKeys {
@@ -213,6 +348,9 @@ func (instance *ComponentInstance) appendData(ops *ComponentInstance) error {
 	// Transfer IsCode bool
 	instance.IsCode = instance.IsCode || ops.IsCode
 
+	// Transfer Protected bool
+	instance.Protected = instance.Protected || ops.Protected
+
 	// Combine labels
 	instance.addLabels(ops.CalculatedLabels)
 
@@ -227,6 +365,16 @@ func (instance *ComponentInstance) appendData(ops *ComponentInstance) error {
 		return err
 	}
 
+	err = instance.addDeployName(ops.DeployName)
+	if err != nil {
+		return err
+	}
+
+	// Transfer deploy suffix
+	if len(ops.DeploySuffix) > 0 {
+		instance.DeploySuffix = ops.DeploySuffix
+	}
+
 	// Incoming and outgoing graph edges (instance: key -> true) as we are traversing the graph
 	for key := range ops.EdgesIn {
 		instance.addEdgeIn(key)
@@ -2,8 +2,10 @@ package resolve
 
 import (
 	"encoding/base32"
+	"fmt"
 	"github.com/Aptomi/aptomi/pkg/lang"
 	"hash/fnv"
+	"regexp"
 	"strings"
 )
 
@@ -16,6 +18,21 @@ const componentUnresolvedName = "unknown"
 // componentRootName is a name of component for service entry (which in turn consists of components)
 const componentRootName = "root"
 
+// deployNamePrefix is a prefix for all names of deployments inside the cluster
+const deployNamePrefix = "a-"
+
+// maxDeployNameLength is a max length of deploy name calculated from template, so it stays a valid DNS label (63
+// characters) after blue/green suffix gets appended
+const maxDeployNameLength = 63 - len(DeploySuffixGreen)
+
+const (
+	// DeploySuffixBlue is a deploy name suffix for the "blue" deployment of component instance updated via blue/green
+	DeploySuffixBlue = "-blue"
+
+	// DeploySuffixGreen is a deploy name suffix for the "green" deployment of component instance updated via blue/green
+	DeploySuffixGreen = "-green"
+)
+
 // ComponentInstanceKey is a key for component instance. During policy resolution every component instance gets
 // assigned a unique string key. It's important to form those keys correctly, so that we can make actual comparison
 // of actual state (components with their keys) and desired state (components with their keys).
@@ -70,6 +87,7 @@ func (cik *ComponentInstanceKey) MakeCopy() *ComponentInstanceKey {
 		ContextName:         cik.ContextName,
 		KeysResolved:        cik.KeysResolved,
 		ContextNameWithKeys: cik.ContextNameWithKeys,
+		ServiceName:         cik.ServiceName,
 		ComponentName:       cik.ComponentName,
 	}
 }
@@ -111,6 +129,8 @@ func (cik ComponentInstanceKey) GetKey() string {
 
 var (
 	base32LowerCaseHexEncoding = base32.NewEncoding("0123456789abcdefghijklmnopqrstuv")
+	deployNameRegexp           = regexp.MustCompile("^" + deployNamePrefix + "[0-9a-v]{13}(" + DeploySuffixBlue + "|" + DeploySuffixGreen + ")?$")
+	dnsLabelRegexp             = regexp.MustCompile("^[a-z0-9]([-a-z0-9]*[a-z0-9])?$")
 )
 
 // GetDeployName returns a string that could be used as name for deployment inside the cluster
@@ -122,7 +142,26 @@ func (cik ComponentInstanceKey) GetDeployName() string {
 	}
 	keyHash := base32LowerCaseHexEncoding.EncodeToString(h.Sum(nil))[0:13]
 
-	return "a-" + keyHash
+	return deployNamePrefix + keyHash
+}
+
+// IsDeployName returns true if a given string looks like a name for deployment inside the cluster, generated by
+// GetDeployName (possibly with blue/green suffix). It's used to find deployments created by Aptomi. Deployments named
+// via deploy name templates can't be recognized this way, so they are never treated as orphans
+func IsDeployName(name string) bool {
+	return deployNameRegexp.MatchString(name)
+}
+
+// validateDeployName checks that deploy name calculated from template could be used as name for deployment inside
+// the cluster, i.e. it's a DNS-compatible label
+func validateDeployName(name string) error {
+	if len(name) > maxDeployNameLength {
+		return fmt.Errorf("deploy name '%s' is too long: %d characters, while max is %d", name, len(name), maxDeployNameLength)
+	}
+	if !dnsLabelRegexp.MatchString(name) {
+		return fmt.Errorf("deploy name '%s' is not a valid DNS label: it should consist of lower case alphanumeric characters or '-', and should start and end with an alphanumeric character", name)
+	}
+	return nil
 }
 
 // If cluster has not been resolved yet and we need a key, generate one
@@ -52,6 +52,14 @@ func TestComponentKeyUnsafe(t *testing.T) {
 	assert.Equal(t, componentRootName, k[len(k)-1], "When policy objects are nil, component key should still be generated")
 }
 
+func TestComponentKeyDeployName(t *testing.T) {
+	key := makeKey(false)
+	assert.True(t, IsDeployName(key.GetDeployName()), "Generated deploy name should be recognized as deploy name")
+	assert.False(t, IsDeployName("my-release"), "Arbitrary name should not be recognized as deploy name")
+	assert.False(t, IsDeployName(key.GetDeployName()+"-old"), "Name with suffix should not be recognized as deploy name")
+	assert.True(t, IsDeployName(key.GetDeployName()+DeploySuffixGreen), "Name with blue/green suffix should be recognized as deploy name")
+}
+
 func makeKey(root bool) *ComponentInstanceKey {
 	b := builder.NewPolicyBuilder()
 	service := b.AddService()
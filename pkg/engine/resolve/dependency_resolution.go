@@ -9,6 +9,17 @@ type DependencyResolution struct {
 
 	// ComponentInstanceKey holds the reference to component instance, to which dependency got resolved
 	ComponentInstanceKey string
+
+	// Suspended indicates that dependency has been suspended, so it hasn't been resolved intentionally
+	Suspended bool
+}
+
+// Creates a new dependency resolution for suspended dependency
+func newSuspendedDependencyResolution() *DependencyResolution {
+	return &DependencyResolution{
+		Resolved:  false,
+		Suspended: true,
+	}
 }
 
 // Creates a new dependency resolution
@@ -2,9 +2,12 @@ package resolve
 
 import (
 	"fmt"
+	"github.com/Aptomi/aptomi/pkg/errors"
 	"github.com/Aptomi/aptomi/pkg/lang"
 	"github.com/Aptomi/aptomi/pkg/runtime"
 	"github.com/Aptomi/aptomi/pkg/util"
+	"sort"
+	"strings"
 )
 
 // PolicyResolution contains resolution data for the policy. It essentially represents the desired state calculated
@@ -21,6 +24,12 @@ type PolicyResolution struct {
 
 	// Resolved dependencies: dependencyID -> dependency resolution
 	dependencyInstanceMap map[string]*DependencyResolution
+
+	// Deploy names of code component instances: cluster + deploy name -> componentKey
+	deployNameMap map[string]string
+
+	// Protected services, contracts and dependencies in the policy: objectKey -> true
+	protectedObjects map[string]bool
 }
 
 // NewPolicyResolution creates new empty PolicyResolution, given a flag indicating whether it's a
@@ -30,6 +39,8 @@ func NewPolicyResolution(isDesired bool) *PolicyResolution {
 		isDesired:             isDesired,
 		ComponentInstanceMap:  make(map[string]*ComponentInstance),
 		dependencyInstanceMap: make(map[string]*DependencyResolution),
+		deployNameMap:         make(map[string]string),
+		protectedObjects:      make(map[string]bool),
 	}
 }
 
@@ -49,6 +60,44 @@ func (resolution *PolicyResolution) RecordResolved(cik *ComponentInstanceKey, de
 	instance.addRuleInformation(ruleResult)
 }
 
+// RecordProtected marks component instance as protected from being destroyed
+func (resolution *PolicyResolution) RecordProtected(cik *ComponentInstanceKey) {
+	resolution.GetComponentInstanceEntry(cik).Protected = true
+}
+
+// recordProtectedObjects records all protected services, contracts and dependencies from the policy, so that
+// protection of component instances gets decided by the desired policy (not by the stale flag stored in actual state)
+func (resolution *PolicyResolution) recordProtectedObjects(policy *lang.Policy) {
+	for _, kind := range []string{lang.ServiceObject.Kind, lang.ContractObject.Kind, lang.DependencyObject.Kind} {
+		for _, obj := range policy.GetObjectsByKind(kind) {
+			if protectable, ok := obj.(lang.Protectable); ok && protectable.IsProtected() {
+				resolution.protectedObjects[runtime.KeyForStorable(obj)] = true
+			}
+		}
+	}
+}
+
+// IsProtected returns true if a given component instance (usually taken from actual state) is backed by a service,
+// contract or dependency, which is protected in the desired policy. Objects which were forcefully removed from the
+// policy or got unprotected don't protect component instances anymore
+func (resolution *PolicyResolution) IsProtected(instance *ComponentInstance) bool {
+	key := instance.Metadata.Key
+	if len(key.Namespace) > 0 {
+		if resolution.protectedObjects[runtime.KeyFromParts(key.Namespace, lang.ServiceObject.Kind, key.ServiceName)] {
+			return true
+		}
+		if resolution.protectedObjects[runtime.KeyFromParts(key.Namespace, lang.ContractObject.Kind, key.ContractName)] {
+			return true
+		}
+	}
+	for dependencyKey := range instance.DependencyKeys {
+		if resolution.protectedObjects[dependencyKey] {
+			return true
+		}
+	}
+	return false
+}
+
 // RecordCodeParams stores calculated code params for component instance
 func (resolution *PolicyResolution) RecordCodeParams(cik *ComponentInstanceKey, codeParams util.NestedParameterMap) error {
 	instance := resolution.GetComponentInstanceEntry(cik)
@@ -56,6 +105,20 @@ func (resolution *PolicyResolution) RecordCodeParams(cik *ComponentInstanceKey,
 	return instance.addCodeParams(codeParams)
 }
 
+// RecordDeployName stores deploy name calculated from template for component instance
+func (resolution *PolicyResolution) RecordDeployName(cik *ComponentInstanceKey, deployName string) error {
+	err := validateDeployName(deployName)
+	if err != nil {
+		return err
+	}
+	return resolution.GetComponentInstanceEntry(cik).addDeployName(deployName)
+}
+
+// RecordDeploySuffix stores blue/green suffix of the deployment currently running for component instance
+func (resolution *PolicyResolution) RecordDeploySuffix(cik *ComponentInstanceKey, deploySuffix string) {
+	resolution.GetComponentInstanceEntry(cik).DeploySuffix = deploySuffix
+}
+
 // RecordDiscoveryParams stores calculated discovery params for component instance
 func (resolution *PolicyResolution) RecordDiscoveryParams(cik *ComponentInstanceKey, discoveryParams util.NestedParameterMap) error {
 	return resolution.GetComponentInstanceEntry(cik).addDiscoveryParams(discoveryParams)
@@ -75,15 +138,111 @@ func (resolution *PolicyResolution) StoreEdge(src *ComponentInstanceKey, dst *Co
 	}
 }
 
+// GetIngressSources returns keys of component instances, which should be able to send traffic to a given component
+// instance even if ingress is rejected for it. Those are other components of the same service instance, as well as
+// components of consumer service instances (which consume it via their contract components)
+func (resolution *PolicyResolution) GetIngressSources(key string) []string {
+	instance := resolution.ComponentInstanceMap[key]
+	if instance == nil {
+		return nil
+	}
+
+	sources := make(map[string]bool)
+	addComponents := func(serviceKey string) {
+		if service := resolution.ComponentInstanceMap[serviceKey]; service != nil {
+			for componentKey := range service.EdgesOut {
+				sources[componentKey] = true
+			}
+		}
+	}
+
+	for serviceKey := range instance.EdgesIn {
+		addComponents(serviceKey)
+
+		service := resolution.ComponentInstanceMap[serviceKey]
+		if service == nil {
+			continue
+		}
+		for consumerKey := range service.EdgesIn {
+			if consumer := resolution.ComponentInstanceMap[consumerKey]; consumer != nil {
+				addComponents(consumer.Metadata.Key.GetParentServiceKey().GetKey())
+			}
+		}
+	}
+	delete(sources, key)
+
+	result := make([]string, 0, len(sources))
+	for srcKey := range sources {
+		result = append(result, srcKey)
+	}
+	sort.Strings(result)
+
+	return result
+}
+
+// recordIngressSources records deploy names of component instances, which are allowed to send traffic to component
+// instances with rejected ingress traffic. They are stored in DataForPlugins, so ingress policies of deployments get
+// re-applied when the list of allowed sources changes. Sources running in other clusters are recorded separately, as
+// ingress from them can't be allowed and applying ingress policy has to fail
+func (resolution *PolicyResolution) recordIngressSources() {
+	for key, instance := range resolution.ComponentInstanceMap {
+		if !instance.Metadata.Key.IsComponent() || instance.IsIngressAllowed() {
+			continue
+		}
+
+		deployNames := []string{}
+		otherClusters := []string{}
+		for _, srcKey := range resolution.GetIngressSources(key) {
+			src := resolution.ComponentInstanceMap[srcKey]
+			if src.Metadata.Key.ClusterName != instance.Metadata.Key.ClusterName {
+				otherClusters = append(otherClusters, src.GetDeployBaseName())
+				continue
+			}
+			deployNames = append(deployNames, src.GetDeployBaseName())
+		}
+		instance.DataForPlugins[AllowIngressFrom] = strings.Join(deployNames, ",")
+		if len(otherClusters) > 0 {
+			instance.DataForPlugins[IngressFromOtherClusters] = strings.Join(otherClusters, ",")
+		}
+	}
+}
+
 // AppendData appends data to the current PolicyResolution record by aggregating data over component instances.
 // If there is a conflict (e.g. components have different code parameters), then an error will be reported.
 func (resolution *PolicyResolution) AppendData(ops *PolicyResolution) error {
 	for _, instance := range ops.ComponentInstanceMap {
-		err := resolution.GetComponentInstanceEntry(instance.Metadata.Key).appendData(instance)
+		entry := resolution.GetComponentInstanceEntry(instance.Metadata.Key)
+		err := entry.appendData(instance)
 		if err != nil {
 			return err
 		}
+
+		err = resolution.checkDeployNameUnique(entry)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkDeployNameUnique makes sure that code component instances within the same cluster have different deploy names
+func (resolution *PolicyResolution) checkDeployNameUnique(instance *ComponentInstance) error {
+	if !instance.IsCode {
+		return nil
+	}
+
+	name := instance.GetCluster() + componentInstanceKeySeparator + instance.GetDeployBaseName()
+	if key, exists := resolution.deployNameMap[name]; exists && key != instance.GetKey() {
+		return errors.NewErrorWithDetails(
+			fmt.Sprintf("Invalid policy. Conflicting deploy name '%s' for component instances: %s, %s", instance.GetDeployBaseName(), key, instance.GetKey()),
+			errors.Details{
+				"instance":          instance.Metadata.Key,
+				"deploy_name":       instance.GetDeployBaseName(),
+				"instance_existing": key,
+			},
+		)
 	}
+	resolution.deployNameMap[name] = instance.GetKey()
 	return nil
 }
 
@@ -156,9 +315,15 @@ func (resolution *PolicyResolution) Validate(policy *lang.Policy) error {
 	return nil
 }
 
-// AllDependenciesResolvedSuccessfully returns if all dependencies got resolved successfully
+// AllDependenciesResolvedSuccessfully returns if all dependencies got resolved successfully. Suspended dependencies
+// are not resolved intentionally, so they are not considered as failed
 func (resolution *PolicyResolution) AllDependenciesResolvedSuccessfully() bool {
-	return resolution.SuccessfullyResolvedDependencies() == len(resolution.dependencyInstanceMap)
+	for _, d := range resolution.dependencyInstanceMap {
+		if !d.Resolved && !d.Suspended {
+			return false
+		}
+	}
+	return true
 }
 
 // SuccessfullyResolvedDependencies returns the number of successfully resolved dependencies
@@ -32,6 +32,9 @@ type PolicyResolver struct {
 	// External data
 	externalData *external.Data
 
+	// Actual state (optional), which is used to refer to currently running deployments of existing component instances
+	actualState *PolicyResolution
+
 	/*
 		Cache
 	*/
@@ -74,6 +77,29 @@ func NewPolicyResolver(policy *lang.Policy, externalData *external.Data, eventLo
 	}
 }
 
+// SetActualState makes resolver aware of component instances in actual state, so that desired state refers to the
+// deployments which are currently running (e.g. existing component instances keep their deploy names when template
+// changes and discovery params refer to the active deployment after blue/green switch)
+func (resolver *PolicyResolver) SetActualState(actualState *PolicyResolution) {
+	resolver.actualState = actualState
+}
+
+// getActualInstance returns component instance from actual state, or nil if it doesn't exist there
+func (resolver *PolicyResolver) getActualInstance(cik *ComponentInstanceKey) *ComponentInstance {
+	if resolver.actualState == nil {
+		return nil
+	}
+	return resolver.actualState.ComponentInstanceMap[cik.GetKey()]
+}
+
+// getDeploySuffix returns blue/green suffix of the deployment currently running for a given component instance
+func (resolver *PolicyResolver) getDeploySuffix(cik *ComponentInstanceKey) string {
+	if instance := resolver.getActualInstance(cik); instance != nil {
+		return instance.DeploySuffix
+	}
+	return ""
+}
+
 // ResolveAllDependencies takes policy as input and calculates PolicyResolution (desired state) as output.
 //
 // The method resolves all recorded claims for consuming contracts ("instantiate <contract> with <labels>"), calculating
@@ -89,6 +115,12 @@ func (resolver *PolicyResolver) ResolveAllDependencies() *PolicyResolution {
 
 	// Resolve every declared dependency
 	for _, d := range dependencies {
+		// Suspended dependencies are kept in policy, but they don't keep any instances running
+		if d.(*lang.Dependency).IsSuspended() {
+			resolver.recordSuspended(d.(*lang.Dependency))
+			continue
+		}
+
 		// Start go routine for resolving a given dependency
 		wg.Add(1)
 		semaphore <- 1
@@ -103,6 +135,12 @@ func (resolver *PolicyResolver) ResolveAllDependencies() *PolicyResolution {
 	// Wait for all go routines to end
 	wg.Wait()
 
+	// Record protected objects, so protection of component instances which are no longer needed can be decided
+	resolver.resolution.recordProtectedObjects(resolver.policy)
+
+	// Once all components are resolved, record which of them could send traffic to components with rejected ingress
+	resolver.resolution.recordIngressSources()
+
 	// Once all components are resolved, print information about them into event log
 	for _, instance := range resolver.resolution.ComponentInstanceMap {
 		if instance.Metadata.Key.IsComponent() {
@@ -166,6 +204,15 @@ func (resolver *PolicyResolver) combineData(node *resolutionNode, resolutionErr
 	resolver.resolution.dependencyInstanceMap[runtime.KeyForStorable(node.dependency)] = newDependencyResolution(resolutionErr, node.serviceKey)
 }
 
+// Records that a given dependency is suspended and therefore hasn't been resolved
+func (resolver *PolicyResolver) recordSuspended(d *lang.Dependency) {
+	resolver.combineMutex.Lock()
+	defer resolver.combineMutex.Unlock()
+
+	resolver.eventLog.WithFields(event.Fields{}).Infof("Skipping suspended dependency '%s/%s' ('%s' -> '%s')", d.Namespace, d.Name, d.User, d.Contract)
+	resolver.resolution.dependencyInstanceMap[runtime.KeyForStorable(d)] = newSuspendedDependencyResolution()
+}
+
 // Evaluate evaluates and resolves a single dependency ("<user> needs <service> with <labels>") and calculates component allocations
 // Returns error only if there is an issue with the given dependency and it cannot be resolved
 func (resolver *PolicyResolver) resolveNode(node *resolutionNode) (resolveErr error) {
@@ -289,6 +336,15 @@ func (resolver *PolicyResolver) resolveNode(node *resolutionNode) (resolveErr er
 		// Create new map with resolution keys for component
 		node.discoveryTreeNode[node.component.Name] = util.NestedParameterMap{}
 
+		// Calculate and store deploy name, so it can be used in discovery params
+		if node.component.Code != nil {
+			err := node.calculateAndStoreDeployName()
+			if err != nil {
+				return err
+			}
+			node.resolution.RecordDeploySuffix(node.componentKey, node.resolver.getDeploySuffix(node.componentKey))
+		}
+
 		// Calculate and store discovery params
 		err := node.calculateAndStoreDiscoveryParams()
 		if err != nil {
@@ -324,11 +380,17 @@ func (resolver *PolicyResolver) resolveNode(node *resolutionNode) (resolveErr er
 		// Record usage of a given component instance
 		node.logInstanceSuccessfullyResolved(node.componentKey)
 		node.resolution.RecordResolved(node.componentKey, node.dependency, ruleResult)
+		if node.isProtected() {
+			node.resolution.RecordProtected(node.componentKey)
+		}
 	}
 
 	// Mark note as resolved and record usage of a given service instance
 	node.logInstanceSuccessfullyResolved(node.serviceKey)
 	node.resolution.RecordResolved(node.serviceKey, node.dependency, ruleResult)
+	if node.isProtected() {
+		node.resolution.RecordProtected(node.serviceKey)
+	}
 
 	return nil
 }
@@ -149,6 +149,11 @@ func (node *resolutionNode) objectResolved(object interface{}) {
 	node.eventLog.AttachTo(object)
 }
 
+// Returns true if the node is backed by a protected dependency, contract or service
+func (node *resolutionNode) isProtected() bool {
+	return node.dependency.IsProtected() || node.contract.IsProtected() || node.service.IsProtected()
+}
+
 // Helper to check that user exists
 func (node *resolutionNode) checkUserExists() error {
 	if node.user == nil {
@@ -256,9 +261,15 @@ func (node *resolutionNode) createComponentKey(component *lang.ServiceComponent)
 	if err != nil || clusterObj == nil {
 		return nil, node.errorClusterDoesNotExist(clusterName)
 	}
+	cluster := clusterObj.(*lang.Cluster)
+
+	// code type could only be checked against the cluster type, once it's known which cluster component goes into
+	if component != nil && component.Code != nil && !lang.IsCodeTypeSupported(cluster.Type, component.Code.Type) {
+		return nil, node.errorCodeTypeNotSupported(cluster, component)
+	}
 
 	return NewComponentInstanceKey(
-		clusterObj.(*lang.Cluster),
+		cluster,
 		node.contract,
 		node.context,
 		node.allocationKeysResolved,
@@ -335,6 +346,43 @@ func (node *resolutionNode) calculateAndStoreCodeParams() error {
 	return nil
 }
 
+func (node *resolutionNode) calculateAndStoreDeployName() error {
+	// existing component instance keeps running under its current name, unless migration to the template is requested
+	if instanceActual := node.resolver.getActualInstance(node.componentKey); instanceActual != nil && !node.service.IsDeployNameMigrated(node.component) {
+		if len(instanceActual.DeployName) <= 0 {
+			return nil
+		}
+		err := node.resolution.RecordDeployName(node.componentKey, instanceActual.DeployName)
+		if err != nil {
+			return node.errorWhenProcessingDeployName(err)
+		}
+		return nil
+	}
+
+	deployNameTemplate := node.service.GetDeployNameTemplate(node.component)
+	if len(deployNameTemplate) <= 0 {
+		return nil
+	}
+
+	deployName, err := node.resolver.templateCache.Evaluate(deployNameTemplate, node.getContextualDataForDeployNameTemplate())
+	if err != nil {
+		return node.errorWhenProcessingDeployName(err)
+	}
+
+	err = node.resolution.RecordDeployName(node.componentKey, deployName)
+	if err != nil {
+		return node.errorWhenProcessingDeployName(err)
+	}
+
+	return nil
+}
+
+// getDeployName returns deploy name of the current component instance, including blue/green suffix of the deployment
+// which is currently running
+func (node *resolutionNode) getDeployName() string {
+	return node.resolution.GetComponentInstanceEntry(node.componentKey).GetDeployName()
+}
+
 func (node *resolutionNode) calculateAndStoreDiscoveryParams() error {
 	componentDiscoveryParams, err := util.ProcessParameterTree(node.component.Discovery, node.getContextualDataForCodeDiscoveryTemplate(), node.resolver.templateCache, util.ModeEvaluate)
 	if err != nil {
@@ -347,7 +395,7 @@ func (node *resolutionNode) calculateAndStoreDiscoveryParams() error {
 	}
 
 	// Populate discovery tree (allow this component to announce its discovery properties in the discovery tree)
-	node.discoveryTreeNode.GetNestedMap(node.component.Name)["instance"] = util.EscapeName(node.componentKey.GetDeployName())
+	node.discoveryTreeNode.GetNestedMap(node.component.Name)["instance"] = util.EscapeName(node.getDeployName())
 	for k, v := range componentDiscoveryParams {
 		node.discoveryTreeNode.GetNestedMap(node.component.Name)[k] = v
 	}
@@ -78,12 +78,32 @@ func (node *resolutionNode) getContextualDataForCodeDiscoveryTemplate() *templat
 		}{
 			User:      node.proxyUser(node.user),
 			Labels:    node.labels.Labels,
-			Discovery: node.proxyDiscovery(node.discoveryTreeNode, node.componentKey),
+			Discovery: node.proxyDiscovery(node.discoveryTreeNode, node.componentKey, node.getDeployName()),
 			Cluster:   node.proxyCluster(node.labels.Labels[lang.LabelCluster]),
 		},
 	)
 }
 
+// This method defines which contextual information will be exposed to the template engine (for evaluating deploy names)
+// Be careful about what gets exposed through this method. User can refer to structs and their methods from the policy
+func (node *resolutionNode) getContextualDataForDeployNameTemplate() *template.Parameters {
+	return template.NewParams(
+		struct {
+			User      interface{}
+			Labels    interface{}
+			Service   interface{}
+			Component interface{}
+			Keys      interface{}
+		}{
+			User:      node.proxyUser(node.user),
+			Labels:    node.labels.Labels,
+			Service:   node.proxyService(node.service),
+			Component: node.proxyComponent(node.component),
+			Keys:      node.allocationKeysResolved,
+		},
+	)
+}
+
 /*
 	Proxy functions
 */
@@ -99,6 +119,15 @@ func (node *resolutionNode) proxyService(service *lang.Service) interface{} {
 	}
 }
 
+// How service component is visible from the policy language
+func (node *resolutionNode) proxyComponent(component *lang.ServiceComponent) interface{} {
+	return struct {
+		Name interface{}
+	}{
+		Name: component.Name,
+	}
+}
+
 // How user is visible from the policy language
 func (node *resolutionNode) proxyUser(user *lang.User) interface{} {
 	return struct {
@@ -149,11 +178,11 @@ func (node *resolutionNode) proxyCluster(name string) interface{} {
 }
 
 // How discovery tree is visible from the policy language
-func (node *resolutionNode) proxyDiscovery(discoveryTree util.NestedParameterMap, cik *ComponentInstanceKey) interface{} {
+func (node *resolutionNode) proxyDiscovery(discoveryTree util.NestedParameterMap, cik *ComponentInstanceKey, deployName string) interface{} {
 	result := discoveryTree.MakeCopy()
 
 	// special case to announce own component instance
-	result["Instance"] = util.EscapeName(cik.GetDeployName())
+	result["Instance"] = util.EscapeName(deployName)
 
 	// special case to announce own component ID
 	result["InstanceId"] = util.HashFnv(cik.GetKey())
@@ -35,6 +35,15 @@ func (node *resolutionNode) userNotAllowedToConsumeService(err error) error {
 	)
 }
 
+func (node *resolutionNode) errorCodeTypeNotSupported(cluster *lang.Cluster, component *lang.ServiceComponent) error {
+	return errors.NewErrorWithDetails(
+		fmt.Sprintf("Code type '%s' of component '%s' is not supported by cluster '%s/%s' of type '%s'", component.Code.Type, component.Name, runtime.SystemNS, cluster.Name, cluster.Type),
+		errors.Details{
+			"component": component,
+		},
+	)
+}
+
 func (node *resolutionNode) errorClusterDoesNotExist(clusterName string) error {
 	if len(clusterName) > 0 {
 		return errors.NewErrorWithDetails(
@@ -115,6 +124,17 @@ func (node *resolutionNode) errorWhenProcessingCodeParams(cause error) error {
 	)
 }
 
+func (node *resolutionNode) errorWhenProcessingDeployName(cause error) error {
+	return errors.NewErrorWithDetails(
+		fmt.Sprintf("Error when processing deploy name for service '%s', contract '%s', context '%s', component '%s': %s", node.service.Name, node.contract.Name, node.context.Name, node.component.Name, cause),
+		errors.Details{
+			"component":       node.component,
+			"contextual_data": node.getContextualDataForDeployNameTemplate(),
+			"cause":           cause,
+		},
+	)
+}
+
 func (node *resolutionNode) errorWhenProcessingDiscoveryParams(cause error) error {
 	return errors.NewErrorWithDetails(
 		fmt.Sprintf("Error when processing discovery params for service '%s', contract '%s', context '%s', component '%s': %s", node.service.Name, node.contract.Name, node.context.Name, node.component.Name, cause),
@@ -8,6 +8,7 @@ import (
 	"github.com/Aptomi/aptomi/pkg/runtime"
 	"github.com/Aptomi/aptomi/pkg/util"
 	"github.com/stretchr/testify/assert"
+	"sort"
 	"testing"
 )
 
@@ -273,6 +274,36 @@ func TestPolicyResolverCodeAndDiscoveryParams(t *testing.T) {
 	assert.Equal(t, 5, instance2.CalculatedCodeParams.GetNestedMap("nested").GetNestedMap("param")["nameInt"], "Code parameter should be calculated correctly (int)")
 }
 
+func TestPolicyResolverSuspendedDependency(t *testing.T) {
+	b := builder.NewPolicyBuilder()
+
+	// create a service with a single instance per dependency
+	service := b.AddService()
+	component := b.AddServiceComponent(service, b.CodeComponent(nil, nil))
+	contract := b.AddContract(service, b.CriteriaTrue())
+	contract.Contexts[0].Allocation.Keys = []string{"{{ .Dependency.ID }}"}
+	cluster := b.AddCluster()
+	b.AddRule(b.CriteriaTrue(), b.RuleActions(lang.NewLabelOperationsSetSingleLabel(lang.LabelCluster, cluster.Name)))
+
+	// add two dependencies and suspend one of them
+	d1 := b.AddDependency(b.AddUser(), contract)
+	d2 := b.AddDependency(b.AddUser(), contract)
+	d2.Suspended = true
+
+	// suspended dependency should not be resolved, but it shouldn't be considered as failed
+	resolution := resolvePolicy(t, b, ResAllDependenciesResolvedSuccessfully, "Skipping suspended dependency")
+	assert.True(t, resolution.GetDependencyInstanceMap()[runtime.KeyForStorable(d1)].Resolved, "Dependency should be successfully resolved")
+	assert.False(t, resolution.GetDependencyInstanceMap()[runtime.KeyForStorable(d2)].Resolved, "Suspended dependency should not be resolved")
+	assert.True(t, resolution.GetDependencyInstanceMap()[runtime.KeyForStorable(d2)].Suspended, "Suspended dependency should be marked as suspended")
+	assert.Equal(t, 2, len(resolution.ComponentInstanceMap), "Only instances of the first dependency should be present")
+
+	// once resumed, dependency should be resolved with the same allocation keys
+	d2.Suspended = false
+	resolution = resolvePolicy(t, b, ResAllDependenciesResolvedSuccessfully, "Successfully resolved")
+	instance := getInstanceByParams(t, cluster, contract, contract.Contexts[0], []string{runtime.KeyForStorable(d2)}, service, component, resolution)
+	assert.True(t, instance.DependencyKeys[runtime.KeyForStorable(d2)], "Instance should be referenced by resumed dependency")
+}
+
 func TestPolicyResolverDependencyWithNonExistingUser(t *testing.T) {
 	b := builder.NewPolicyBuilder()
 	service := b.AddService()
@@ -339,6 +370,161 @@ func TestPolicyResolverConflictingDiscoveryParams(t *testing.T) {
 	resolvePolicy(t, b, ResSomeDependenciesFailed, "Conflicting discovery parameters")
 }
 
+func TestPolicyResolverDeployName(t *testing.T) {
+	b := builder.NewPolicyBuilder()
+
+	// create a service with 2 components, one of which has deploy name template
+	service := b.AddService()
+	component1 := b.CodeComponent(nil, util.NestedParameterMap{"url": "component1-{{ .Discovery.Instance }}"})
+	component1.DeployName = "app-{{ .Labels.env }}-one"
+	component2 := b.CodeComponent(nil, nil)
+	b.AddServiceComponent(service, component1)
+	b.AddServiceComponent(service, component2)
+
+	contract := b.AddContract(service, b.CriteriaTrue())
+	cluster := b.AddCluster()
+	b.AddRule(b.CriteriaTrue(), b.RuleActions(lang.NewLabelOperationsSetSingleLabel(lang.LabelCluster, cluster.Name)))
+
+	d := b.AddDependency(b.AddUser(), contract)
+	d.Labels["env"] = "prod"
+
+	// policy should be resolved successfully
+	resolution := resolvePolicy(t, b, ResAllDependenciesResolvedSuccessfully, "Successfully resolved")
+
+	// deploy name should be calculated from template and exposed via discovery
+	instance1 := getInstanceByParams(t, cluster, contract, contract.Contexts[0], nil, service, component1, resolution)
+	assert.Equal(t, "app-prod-one", instance1.GetDeployName(), "Deploy name should be calculated from template")
+	assert.Equal(t, "component1-app-prod-one", instance1.CalculatedDiscovery["url"], "Discovery parameter should refer to deploy name calculated from template")
+
+	// deploy name without template should be generated from component key
+	instance2 := getInstanceByParams(t, cluster, contract, contract.Contexts[0], nil, service, component2, resolution)
+	assert.Equal(t, instance2.Metadata.Key.GetDeployName(), instance2.GetDeployName(), "Deploy name should be generated from component key")
+}
+
+func TestPolicyResolverDeployNameExisting(t *testing.T) {
+	b := builder.NewPolicyBuilder()
+
+	// create a service with 2 components, one of which has deploy name template
+	service := b.AddService()
+	component1 := b.CodeComponent(nil, nil)
+	component1.DeployName = "app-one"
+	component2 := b.CodeComponent(nil, nil)
+	b.AddServiceComponent(service, component1)
+	b.AddServiceComponent(service, component2)
+
+	contract := b.AddContract(service, b.CriteriaTrue())
+	cluster := b.AddCluster()
+	b.AddRule(b.CriteriaTrue(), b.RuleActions(lang.NewLabelOperationsSetSingleLabel(lang.LabelCluster, cluster.Name)))
+	b.AddDependency(b.AddUser(), contract)
+	actualState := resolvePolicy(t, b, ResAllDependenciesResolvedSuccessfully, "Successfully resolved")
+
+	// change deploy name templates
+	component1.DeployName = "app-changed"
+	component2.DeployName = "app-two"
+	resolveWithActual := func() *PolicyResolution {
+		resolver := NewPolicyResolver(b.Policy(), b.External(), event.NewLog("test-resolve", false))
+		resolver.SetActualState(actualState)
+		return resolver.ResolveAllDependencies()
+	}
+
+	// existing component instances should keep their deploy names
+	resolution := resolveWithActual()
+	instance1 := getInstanceByParams(t, cluster, contract, contract.Contexts[0], nil, service, component1, resolution)
+	assert.Equal(t, "app-one", instance1.GetDeployName(), "Existing component instance should keep its deploy name")
+	instance2 := getInstanceByParams(t, cluster, contract, contract.Contexts[0], nil, service, component2, resolution)
+	assert.Equal(t, instance2.Metadata.Key.GetDeployName(), instance2.GetDeployName(), "Existing component instance should keep its generated deploy name")
+
+	// once migration is requested, deploy names should be calculated from templates
+	service.DeployNameMigrate = true
+	resolution = resolveWithActual()
+	instance1 = getInstanceByParams(t, cluster, contract, contract.Contexts[0], nil, service, component1, resolution)
+	assert.Equal(t, "app-changed", instance1.GetDeployName(), "Deploy name should be migrated to the template")
+	instance2 = getInstanceByParams(t, cluster, contract, contract.Contexts[0], nil, service, component2, resolution)
+	assert.Equal(t, "app-two", instance2.GetDeployName(), "Deploy name should be migrated to the template")
+}
+
+func TestPolicyResolverDeploySuffix(t *testing.T) {
+	b := builder.NewPolicyBuilder()
+
+	// create a service with a component, which exposes its deploy name via discovery
+	service := b.AddService()
+	component := b.CodeComponent(nil, util.NestedParameterMap{"url": "component-{{ .Discovery.Instance }}"})
+	component.DeployName = "app-one"
+	b.AddServiceComponent(service, component)
+
+	contract := b.AddContract(service, b.CriteriaTrue())
+	cluster := b.AddCluster()
+	b.AddRule(b.CriteriaTrue(), b.RuleActions(lang.NewLabelOperationsSetSingleLabel(lang.LabelCluster, cluster.Name)))
+	b.AddDependency(b.AddUser(), contract)
+
+	// switch running deployment to the green one in actual state
+	actualState := resolvePolicy(t, b, ResAllDependenciesResolvedSuccessfully, "Successfully resolved")
+	instanceActual := getInstanceByParams(t, cluster, contract, contract.Contexts[0], nil, service, component, actualState)
+	instanceActual.DeploySuffix = DeploySuffixGreen
+
+	// discovery should refer to the deployment which is currently running
+	resolver := NewPolicyResolver(b.Policy(), b.External(), event.NewLog("test-resolve", false))
+	resolver.SetActualState(actualState)
+	resolution := resolver.ResolveAllDependencies()
+	instance := getInstanceByParams(t, cluster, contract, contract.Contexts[0], nil, service, component, resolution)
+	assert.Equal(t, "app-one"+DeploySuffixGreen, instance.GetDeployName(), "Deploy name should have suffix of running deployment")
+	assert.Equal(t, "component-app-one"+DeploySuffixGreen, instance.CalculatedDiscovery["url"], "Discovery parameter should refer to running deployment")
+}
+
+func TestPolicyResolverConflictingDeployNames(t *testing.T) {
+	b := builder.NewPolicyBuilder()
+
+	// create a service with 2 components, which get the same deploy name
+	service := b.AddService()
+	service.DeployName = "app"
+	b.AddServiceComponent(service, b.CodeComponent(nil, nil))
+	b.AddServiceComponent(service, b.CodeComponent(nil, nil))
+
+	contract := b.AddContract(service, b.CriteriaTrue())
+	cluster := b.AddCluster()
+	b.AddRule(b.CriteriaTrue(), b.RuleActions(lang.NewLabelOperationsSetSingleLabel(lang.LabelCluster, cluster.Name)))
+	b.AddDependency(b.AddUser(), contract)
+
+	// policy resolution with the same deploy name for different component instances should result in an error
+	resolvePolicy(t, b, ResSomeDependenciesFailed, "Conflicting deploy name")
+}
+
+func TestPolicyResolverInvalidDeployName(t *testing.T) {
+	b := builder.NewPolicyBuilder()
+
+	// create a service with a component, which gets deploy name that is not a valid DNS label
+	service := b.AddService()
+	component := b.CodeComponent(nil, nil)
+	component.DeployName = "App_{{ .Labels.env }}"
+	b.AddServiceComponent(service, component)
+
+	contract := b.AddContract(service, b.CriteriaTrue())
+	cluster := b.AddCluster()
+	b.AddRule(b.CriteriaTrue(), b.RuleActions(lang.NewLabelOperationsSetSingleLabel(lang.LabelCluster, cluster.Name)))
+	d := b.AddDependency(b.AddUser(), contract)
+	d.Labels["env"] = "prod"
+
+	// policy resolution with invalid deploy name should result in an error
+	resolvePolicy(t, b, ResSomeDependenciesFailed, "not a valid DNS label")
+}
+
+func TestPolicyResolverUnsupportedCodeType(t *testing.T) {
+	b := builder.NewPolicyBuilder()
+
+	// create a service with a component, which code type isn't supported by the local cluster
+	service := b.AddService()
+	b.AddServiceComponent(service, b.CodeComponent(nil, nil))
+
+	contract := b.AddContract(service, b.CriteriaTrue())
+	cluster := b.AddCluster()
+	cluster.Type = "local"
+	b.AddRule(b.CriteriaTrue(), b.RuleActions(lang.NewLabelOperationsSetSingleLabel(lang.LabelCluster, cluster.Name)))
+	b.AddDependency(b.AddUser(), contract)
+
+	// policy resolution with code type not supported by the cluster type should result in an error
+	resolvePolicy(t, b, ResSomeDependenciesFailed, "is not supported by cluster")
+}
+
 func TestPolicyResolverServiceLoop(t *testing.T) {
 	b := builder.NewPolicyBuilder()
 
@@ -400,6 +586,87 @@ func TestPolicyResolverPickClusterViaRules(t *testing.T) {
 	assert.Equal(t, cluster2.Name, instance2.CalculatedLabels.Labels[lang.LabelCluster], "Cluster should be set correctly via rules")
 }
 
+func TestPolicyResolverIngress(t *testing.T) {
+	b := builder.NewPolicyBuilder()
+
+	// create a backend service with 2 components, which rejects ingress traffic
+	backend := b.AddService()
+	backendComponent1 := b.AddServiceComponent(backend, b.CodeComponent(nil, nil))
+	backendComponent2 := b.AddServiceComponent(backend, b.CodeComponent(nil, nil))
+	backendContract := b.AddContract(backend, b.CriteriaTrue())
+	backendContract.ChangeLabels = lang.NewLabelOperationsSetSingleLabel("tier", "backend")
+
+	// create a frontend service, which consumes the backend service
+	frontend := b.AddService()
+	frontendComponent := b.AddServiceComponent(frontend, b.CodeComponent(nil, nil))
+	frontendContractComponent := b.AddServiceComponent(frontend, b.ContractComponent(backendContract))
+	frontendContract := b.AddContract(frontend, b.CriteriaTrue())
+
+	cluster := b.AddCluster()
+	b.AddRule(b.CriteriaTrue(), b.RuleActions(lang.NewLabelOperationsSetSingleLabel(lang.LabelCluster, cluster.Name)))
+	b.AddRule(b.Criteria("tier == 'backend'", "true", "false"), &lang.RuleActions{Ingress: lang.Reject})
+
+	b.AddDependency(b.AddUser(), frontendContract)
+
+	// policy should be resolved successfully
+	resolution := resolvePolicy(t, b, ResAllDependenciesResolvedSuccessfully, "Successfully resolved")
+
+	// ingress should be rejected for backend components only
+	backendInstance1 := getInstanceByParams(t, cluster, backendContract, backendContract.Contexts[0], nil, backend, backendComponent1, resolution)
+	backendInstance2 := getInstanceByParams(t, cluster, backendContract, backendContract.Contexts[0], nil, backend, backendComponent2, resolution)
+	frontendInstance := getInstanceByParams(t, cluster, frontendContract, frontendContract.Contexts[0], nil, frontend, frontendComponent, resolution)
+	frontendContractInstance := getInstanceByParams(t, cluster, frontendContract, frontendContract.Contexts[0], nil, frontend, frontendContractComponent, resolution)
+	assert.False(t, backendInstance1.IsIngressAllowed(), "Ingress should be rejected for backend component")
+	assert.True(t, frontendInstance.IsIngressAllowed(), "Ingress should be allowed for frontend component")
+
+	// backend component should accept traffic from the other backend component and from frontend components
+	expected := []string{backendInstance2.GetKey(), frontendInstance.GetKey(), frontendContractInstance.GetKey()}
+	sort.Strings(expected)
+	assert.Equal(t, expected, resolution.GetIngressSources(backendInstance1.GetKey()), "Ingress sources should include same service and consumer components")
+	allowedFrom := backendInstance1.GetAllowedIngressFrom()
+	assert.Len(t, allowedFrom, 3, "Deploy names of ingress sources should be recorded for plugins")
+	assert.Contains(t, allowedFrom, backendInstance2.GetDeployName(), "Deploy name of the same service component should be recorded")
+	assert.Contains(t, allowedFrom, frontendInstance.GetDeployName(), "Deploy name of consumer component should be recorded")
+	assert.Empty(t, frontendInstance.GetAllowedIngressFrom(), "Ingress sources should not be recorded if ingress is allowed")
+}
+
+func TestPolicyResolverIngressFromOtherClusters(t *testing.T) {
+	b := builder.NewPolicyBuilder()
+
+	// create a backend service, which rejects ingress traffic
+	backend := b.AddService()
+	backendComponent := b.AddServiceComponent(backend, b.CodeComponent(nil, nil))
+	backendContract := b.AddContract(backend, b.CriteriaTrue())
+	backendContract.ChangeLabels = lang.NewLabelOperationsSetSingleLabel("tier", "backend")
+
+	// create a frontend service, which consumes the backend service
+	frontend := b.AddService()
+	frontendComponent := b.AddServiceComponent(frontend, b.CodeComponent(nil, nil))
+	b.AddServiceComponent(frontend, b.ContractComponent(backendContract))
+	frontendContract := b.AddContract(frontend, b.CriteriaTrue())
+
+	// frontend and backend get deployed to different clusters
+	frontendCluster := b.AddCluster()
+	backendCluster := b.AddCluster()
+	b.AddRule(b.CriteriaTrue(), b.RuleActions(lang.NewLabelOperationsSetSingleLabel(lang.LabelCluster, frontendCluster.Name)))
+	b.AddRule(b.Criteria("tier == 'backend'", "true", "false"), &lang.RuleActions{
+		ChangeLabels: lang.NewLabelOperationsSetSingleLabel(lang.LabelCluster, backendCluster.Name),
+		Ingress:      lang.Reject,
+	})
+
+	b.AddDependency(b.AddUser(), frontendContract)
+
+	// policy should be resolved successfully
+	resolution := resolvePolicy(t, b, ResAllDependenciesResolvedSuccessfully, "Successfully resolved")
+
+	// frontend component can't be allowed to send traffic to backend, so it should be recorded separately
+	backendInstance := getInstanceByParams(t, backendCluster, backendContract, backendContract.Contexts[0], nil, backend, backendComponent, resolution)
+	frontendInstance := getInstanceByParams(t, frontendCluster, frontendContract, frontendContract.Contexts[0], nil, frontend, frontendComponent, resolution)
+	assert.False(t, backendInstance.IsIngressAllowed(), "Ingress should be rejected for backend component")
+	assert.Empty(t, backendInstance.GetAllowedIngressFrom(), "Deploy names of ingress sources in other clusters should not be allowed")
+	assert.Contains(t, backendInstance.GetIngressFromOtherClusters(), frontendInstance.GetDeployName(), "Deploy name of consumer component in other cluster should be recorded")
+}
+
 func TestPolicyResolverInternalPanic(t *testing.T) {
 	b := builder.NewPolicyBuilder()
 	b.PanicWhenLoadingUsers()
@@ -19,12 +19,23 @@ var RevisionObject = &runtime.Info{
 var RevisionKey = runtime.KeyFromParts(runtime.SystemNS, RevisionObject.Kind, runtime.EmptyName)
 
 const (
-	// RevisionStatusWaiting represents Revision status when it has been created, but apply haven't started yet
+	// RevisionStatusWaiting represents Revision status when it has been planned, but apply haven't started yet
 	RevisionStatusWaiting = "waiting"
 	// RevisionStatusInProgress represents Revision status with apply in progress
 	RevisionStatusInProgress = "inprogress"
 	// RevisionStatusCompleted represents Revision status with apply finished
 	RevisionStatusCompleted = "completed"
+	// RevisionStatusAborted represents Revision status when apply has been aborted (on request or by timeout)
+	RevisionStatusAborted = "aborted"
+	// RevisionStatusBlocked represents Revision status when apply has been blocked by safety limits and has to be
+	// explicitly overridden
+	RevisionStatusBlocked = "blocked"
+	// RevisionStatusScheduled represents Revision status when apply has been postponed until maintenance windows get
+	// open
+	RevisionStatusScheduled = "scheduled"
+	// RevisionStatusSuperseded represents Revision status when it has been replaced by a newer revision before apply
+	// has started
+	RevisionStatusSuperseded = "superseded"
 	// RevisionStatusError represents Revision status when a critical error happened (we should rarely see those)
 	RevisionStatusError = "error"
 )
@@ -44,6 +55,36 @@ type Revision struct {
 
 	ResolveLog []*event.APIEvent
 	ApplyLog   []*event.APIEvent
+
+	// Plan is the plan of actions calculated when revision has been created. The actual plan gets re-calculated
+	// right before apply, as actual state could change while revision is pending
+	Plan *action.PlanView `yaml:",omitempty"`
+
+	// DependencyComponents contains keys of component instances of every dependency in desired state (dependency key ->
+	// component instance keys). It's recorded when revision has been planned, so dependency progress could be
+	// calculated without resolving policy again
+	DependencyComponents map[string][]string `yaml:",omitempty"`
+
+	// Blocked contains reasons why revision has been blocked by safety limits
+	Blocked *RevisionBlock `yaml:",omitempty"`
+
+	// Override indicates that blocked revision has been explicitly allowed to proceed
+	Override bool
+
+	// Scheduled contains the time revision is scheduled to be applied at, if it has been recorded outside of
+	// maintenance windows
+	Scheduled *RevisionSchedule `yaml:",omitempty"`
+}
+
+// RevisionBlock represents reasons why revision has been blocked by safety limits
+type RevisionBlock struct {
+	Reasons []string
+}
+
+// RevisionSchedule represents the time revision is scheduled to be applied at
+type RevisionSchedule struct {
+	// For is the time when maintenance windows get open (zero, if they will never be open)
+	For time.Time
 }
 
 // NewRevision creates a new revision
@@ -59,6 +100,12 @@ func NewRevision(gen runtime.Generation, policyGen runtime.Generation) *Revision
 	}
 }
 
+// IsPending returns true if revision has been planned, but apply haven't started yet. Pending revision could be
+// superseded by a newer one
+func (revision *Revision) IsPending() bool {
+	return revision.Status == RevisionStatusWaiting || revision.Status == RevisionStatusBlocked || revision.Status == RevisionStatusScheduled
+}
+
 // GetName returns Revision name
 func (revision *Revision) GetName() string {
 	return runtime.EmptyName
@@ -6,3 +6,8 @@ import "github.com/Aptomi/aptomi/pkg/runtime"
 type Base interface {
 	runtime.Deletable
 }
+
+// Protectable represents policy object which could be protected from being destroyed
+type Protectable interface {
+	IsProtected() bool
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/Aptomi/aptomi/pkg/runtime"
 	"gopkg.in/yaml.v2"
+	"strings"
 )
 
 // ClusterObject is an informational data structure with Kind and Constructor for Cluster
@@ -31,6 +32,31 @@ type Cluster struct {
 	Config interface{} `validate:"required"`
 }
 
+// ClusterLabelProtected is a cluster label, which marks cluster as protected (if set to "true"). Engine refuses to
+// delete anything in protected clusters, unless explicitly overridden
+const ClusterLabelProtected = "protected"
+
+// IsProtected returns true if cluster is labelled as protected
+func (cluster *Cluster) IsProtected() bool {
+	return cluster.Labels[ClusterLabelProtected] == "true"
+}
+
+// ClusterLabelMaintenanceWindow is a cluster label, which assigns maintenance windows from the server config to the
+// cluster (comma-separated list of window names)
+const ClusterLabelMaintenanceWindow = "maintenance-window"
+
+// GetMaintenanceWindows returns names of maintenance windows assigned to the cluster via label
+func (cluster *Cluster) GetMaintenanceWindows() []string {
+	result := []string{}
+	for _, name := range strings.Split(cluster.Labels[ClusterLabelMaintenanceWindow], ",") {
+		name = strings.TrimSpace(name)
+		if len(name) > 0 {
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
 // ParseConfigInto parses cluster config into provided object
 func (cluster *Cluster) ParseConfigInto(obj interface{}) error {
 	data, err := yaml.Marshal(cluster.Config)
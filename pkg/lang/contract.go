@@ -28,11 +28,20 @@ type Contract struct {
 	// the contract gets matched
 	ChangeLabels LabelOperations `yaml:"change-labels,omitempty" validate:"labelOperations"`
 
+	// Protected, if set to true, prevents instances allocated for the contract from being destroyed by the engine.
+	// Protected contract can only be removed from policy when removal is explicitly forced
+	Protected bool `yaml:"protected,omitempty"`
+
 	// Contexts contains an ordered list of contexts within a contract. When allocating an instance, Aptomi will pick
 	// and instantiate the first context which matches the criteria
 	Contexts []*Context `validate:"dive"`
 }
 
+// IsProtected returns true if contract is protected from being destroyed
+func (contract *Contract) IsProtected() bool {
+	return contract.Protected
+}
+
 // Context represents a single context within a service contract.
 // It's essentially a service instance for a given of class of use cases, a given set of consumers, etc.
 type Context struct {
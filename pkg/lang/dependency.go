@@ -30,6 +30,24 @@ type Dependency struct {
 
 	// Labels which are provided by the user.
 	Labels map[string]string `yaml:"labels,omitempty" validate:"omitempty,labels"`
+
+	// Protected, if set to true, prevents instances used by the dependency from being destroyed by the engine.
+	// Protected dependency can only be removed from policy when removal is explicitly forced
+	Protected bool `yaml:"protected,omitempty"`
+
+	// Suspended, if set to true, detaches dependency from its instances while keeping it in policy. Instances used
+	// only by suspended dependency get destroyed and then restored with the same allocation keys once it's resumed
+	Suspended bool `yaml:"suspended,omitempty"`
+}
+
+// IsProtected returns true if dependency is protected from being destroyed
+func (dependency *Dependency) IsProtected() bool {
+	return dependency.Protected
+}
+
+// IsSuspended returns true if dependency is suspended and shouldn't be resolved
+func (dependency *Dependency) IsSuspended() bool {
+	return dependency.Suspended
 }
 
 // GlobalDependencies represents the list of global dependencies (see the definition above)
@@ -61,6 +61,47 @@ func (view *PolicyView) ManageObject(obj Base) error {
 	return nil
 }
 
+// ManageProtectedObject checks if user has permissions to remove a given protected object. Only domain admins are
+// allowed to do that. If user has no permissions, then ACL error will be returned
+func (view *PolicyView) ManageProtectedObject(obj Base) error {
+	err := view.ManageObject(obj)
+	if err != nil {
+		return err
+	}
+	isDomainAdmin, err := view.isDomainAdmin()
+	if err != nil {
+		return err
+	}
+	if !isDomainAdmin {
+		return fmt.Errorf("user '%s' doesn't have ACL permissions to remove protected object '%s/%s/%s'", view.User.Name, obj.GetNamespace(), obj.GetKind(), obj.GetName())
+	}
+	return nil
+}
+
+// ManageDomain checks if user has permissions to perform domain-wide operations, which aren't bound to any policy
+// object (e.g. deleting orphaned deployments in the clusters). Only domain admins are allowed to do that. If user has
+// no permissions, then ACL error will be returned
+func (view *PolicyView) ManageDomain() error {
+	isDomainAdmin, err := view.isDomainAdmin()
+	if err != nil {
+		return err
+	}
+	if !isDomainAdmin {
+		return fmt.Errorf("user '%s' doesn't have ACL permissions to perform domain-wide operations", view.User.Name)
+	}
+	return nil
+}
+
+// isDomainAdmin returns if user has domain admin role
+func (view *PolicyView) isDomainAdmin() (bool, error) {
+	roleMap, err := view.Policy.aclResolver.GetUserRoleMap(view.User)
+	if err != nil {
+		return false, err
+	}
+	_, ok := roleMap[domainAdmin.ID]
+	return ok, nil
+}
+
 // CanConsume returns if user has permissions to consume a given service.
 // If a user can declare a dependency in a given namespace, then he can essentially can consume the service
 func (view *PolicyView) CanConsume(service *Service) (bool, error) {
@@ -77,6 +77,46 @@ func TestPolicyViewCommonObjects(t *testing.T) {
 	assert.Equal(t, []int{0, 0, 0}, errCntConsume, "PolicyView.CanConsume() should work correctly")
 }
 
+func TestPolicyViewManageProtectedObject(t *testing.T) {
+	policy := makeEmptyPolicyWithACL()
+	service := &Service{
+		TypeKind: ServiceObject.GetTypeKind(),
+		Metadata: Metadata{
+			Namespace: "main",
+			Name:      "service",
+		},
+		Protected: true,
+	}
+	err := policy.AddObject(service)
+	assert.NoError(t, err, "Policy.AddObject() should work correctly")
+
+	// only domain admins can remove protected objects
+	users := []*User{
+		{Name: "1", Labels: map[string]string{"is_domain_admin": "true"}},
+		{Name: "2", Labels: map[string]string{"is_namespace_admin": "true"}},
+		{Name: "3", Labels: map[string]string{"is_consumer": "true"}},
+	}
+	assert.NoError(t, policy.View(users[0]).ManageProtectedObject(service), "Domain admin should be able to remove protected object")
+	assert.Error(t, policy.View(users[1]).ManageProtectedObject(service), "Namespace admin should not be able to remove protected object")
+	assert.Error(t, policy.View(users[2]).ManageProtectedObject(service), "Consumer should not be able to remove protected object")
+}
+
+func TestPolicyViewManageDomain(t *testing.T) {
+	policy := makeEmptyPolicyWithACL()
+
+	// only domain admins can perform domain-wide operations
+	users := []*User{
+		{Name: "1", Labels: map[string]string{"is_domain_admin": "true"}},
+		{Name: "2", Labels: map[string]string{"is_namespace_admin": "true"}},
+		{Name: "3", Labels: map[string]string{"is_consumer": "true"}},
+		{Name: "4", DomainAdmin: true},
+	}
+	assert.NoError(t, policy.View(users[0]).ManageDomain(), "Domain admin should be able to perform domain-wide operations")
+	assert.Error(t, policy.View(users[1]).ManageDomain(), "Namespace admin should not be able to perform domain-wide operations")
+	assert.Error(t, policy.View(users[2]).ManageDomain(), "Consumer should not be able to perform domain-wide operations")
+	assert.NoError(t, policy.View(users[3]).ManageDomain(), "User marked as domain admin should be able to perform domain-wide operations")
+}
+
 func TestPolicyViewManageACLRules(t *testing.T) {
 	// users which will be used for viewing policy
 	users := []*User{
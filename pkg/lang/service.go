@@ -30,6 +30,19 @@ type Service struct {
 	// Labels is a set of labels attached to the service
 	Labels map[string]string `yaml:"labels,omitempty" validate:"omitempty,labels"`
 
+	// Protected, if set to true, prevents service instances from being destroyed by the engine. Protected service can
+	// only be removed from policy when removal is explicitly forced
+	Protected bool `yaml:"protected,omitempty"`
+
+	// DeployName is a text template for names of deployments, which get created in the cloud for code components of
+	// this service. If it's empty, names get generated from component instance keys. It's applied to new component
+	// instances only, existing ones keep running under their current names unless DeployNameMigrate is set
+	DeployName string `yaml:"deploy-name,omitempty" validate:"omitempty,template"`
+
+	// DeployNameMigrate, if set to true, moves existing component instances of the service under the names calculated
+	// from the deploy name template, which means that their deployments get re-created under the new names
+	DeployNameMigrate bool `yaml:"deploy-name-migrate,omitempty"`
+
 	// Components is the list of components service consists of
 	Components []*ServiceComponent `validate:"dive"`
 
@@ -42,6 +55,11 @@ type Service struct {
 	componentsMap     map[string]*ServiceComponent
 }
 
+// IsProtected returns true if service is protected from being destroyed
+func (service *Service) IsProtected() bool {
+	return service.Protected
+}
+
 // ServiceComponent defines component within a service
 type ServiceComponent struct {
 	// Name is a user-defined component name
@@ -66,6 +84,53 @@ type ServiceComponent struct {
 	// Dependencies is cross-component dependencies within a service. Component may need other components within that
 	// service to run, before it gets instantiated
 	Dependencies []string `yaml:"dependencies,omitempty" validate:"dive,identifier"`
+
+	// UpdateStrategy defines how changes of code parameters get applied to running component instances (in-place,
+	// recreate or blue-green). If it's empty, then component instances are updated in-place
+	UpdateStrategy string `yaml:"update-strategy,omitempty" validate:"omitempty,updateStrategy"`
+
+	// DeployName is a text template for names of deployments, which get created in the cloud for this component. It
+	// overrides deploy name template defined for the service
+	DeployName string `yaml:"deploy-name,omitempty" validate:"omitempty,template"`
+
+	// DeployNameMigrate, if set to true, moves existing component instances under the names calculated from the deploy
+	// name template (same as for the service)
+	DeployNameMigrate bool `yaml:"deploy-name-migrate,omitempty"`
+}
+
+const (
+	// UpdateStrategyInPlace means that component instance gets updated in-place by the code plugin
+	UpdateStrategyInPlace = "in-place"
+
+	// UpdateStrategyRecreate means that component instance gets destroyed and then created again with new parameters
+	UpdateStrategyRecreate = "recreate"
+
+	// UpdateStrategyBlueGreen means that a new component instance gets deployed alongside the old one under a
+	// different deploy name, then endpoints get switched to it once it's ready, and then the old one gets destroyed
+	UpdateStrategyBlueGreen = "blue-green"
+)
+
+// GetUpdateStrategy returns update strategy for the component, which is in-place by default
+func (component *ServiceComponent) GetUpdateStrategy() string {
+	if len(component.UpdateStrategy) <= 0 {
+		return UpdateStrategyInPlace
+	}
+	return component.UpdateStrategy
+}
+
+// GetDeployNameTemplate returns deploy name template for a given component of the service. If neither component nor
+// service define it, empty string is returned
+func (service *Service) GetDeployNameTemplate(component *ServiceComponent) string {
+	if component != nil && len(component.DeployName) > 0 {
+		return component.DeployName
+	}
+	return service.DeployName
+}
+
+// IsDeployNameMigrated returns true if existing component instances of a given component of the service should be
+// moved under the names calculated from the deploy name template
+func (service *Service) IsDeployNameMigrated(component *ServiceComponent) bool {
+	return service.DeployNameMigrate || (component != nil && component.DeployNameMigrate)
 }
 
 // Code with type and parameters, used to instantiate/update/delete component instances
@@ -0,0 +1,84 @@
+package lang
+
+import (
+	"github.com/Aptomi/aptomi/pkg/util"
+	"sort"
+	"sync"
+)
+
+// TypeRegistry provides cluster types and code types, which are supported by the configured plugins. Policy
+// validation consults it to make sure that clusters and code components refer to the supported types only
+type TypeRegistry interface {
+	// SupportedTypes returns a map from cluster type to the list of code types supported for it
+	SupportedTypes() map[string][]string
+}
+
+// StaticTypes is a TypeRegistry with a fixed set of cluster and code types
+type StaticTypes map[string][]string
+
+// SupportedTypes returns a map from cluster type to the list of code types supported for it
+func (types StaticTypes) SupportedTypes() map[string][]string {
+	return types
+}
+
+var (
+	// BuiltinTypes is a map from cluster type to the list of code types, which are supported by the plugins built into
+	// aptomi. Server registers built-in plugins for these types and it's used for validation until type registry is
+	// set explicitly (e.g. in client and tests)
+	BuiltinTypes = StaticTypes{
+		"kubernetes": {"helm", "raw", "webhook"},
+		"local":      {"exec", "webhook"},
+		"gitops":     {"helm", "raw", "webhook"},
+	}
+
+	typeRegistryMutex sync.RWMutex
+	typeRegistry      TypeRegistry = BuiltinTypes
+)
+
+// SetTypeRegistry sets the type registry, which will be used for validation of all policies. Server sets it to the
+// live plugin registry, so that policy could only refer to the types which plugins are configured for
+func SetTypeRegistry(registry TypeRegistry) {
+	typeRegistryMutex.Lock()
+	defer typeRegistryMutex.Unlock()
+	if registry == nil {
+		registry = BuiltinTypes
+	}
+	typeRegistry = registry
+}
+
+// IsCodeTypeSupported returns true if a given code type is supported for a given cluster type. It's used once it's
+// known which cluster a code component gets deployed into
+func IsCodeTypeSupported(clusterType string, codeType string) bool {
+	typeRegistryMutex.RLock()
+	defer typeRegistryMutex.RUnlock()
+
+	for _, supported := range typeRegistry.SupportedTypes()[clusterType] {
+		if supported == codeType {
+			return true
+		}
+	}
+	return false
+}
+
+// getSupportedTypes returns sorted lists of supported cluster types and code types (code types are merged across all
+// cluster types, as a code component doesn't know which cluster it will be deployed into until policy is resolved)
+func getSupportedTypes() ([]string, []string) {
+	typeRegistryMutex.RLock()
+	defer typeRegistryMutex.RUnlock()
+
+	types := typeRegistry.SupportedTypes()
+	codeTypeMap := make(map[string]bool)
+	for _, codeTypes := range types {
+		for _, codeType := range codeTypes {
+			codeTypeMap[codeType] = true
+		}
+	}
+
+	codeTypes := make([]string, 0, len(codeTypeMap))
+	for codeType := range codeTypeMap {
+		codeTypes = append(codeTypes, codeType)
+	}
+	sort.Strings(codeTypes)
+
+	return util.GetSortedStringKeys(types), codeTypes
+}
@@ -18,11 +18,10 @@ import (
 
 // Constants
 var (
-	identifierRegex = "^[a-zA-Z][a-zA-Z0-9_-]{0,63}$"
-	clusterTypes    = []string{"kubernetes"}
-	codeTypes       = []string{"helm", "raw"}
-	labelOpsKeys    = []string{"set", "remove"}
-	allowReject     = []string{"allow", "reject"}
+	identifierRegex  = "^[a-zA-Z][a-zA-Z0-9_-]{0,63}$"
+	labelOpsKeys     = []string{"set", "remove"}
+	allowReject      = []string{"allow", "reject"}
+	updateStrategies = []string{UpdateStrategyInPlace, UpdateStrategyRecreate, UpdateStrategyBlueGreen}
 )
 
 // Custom type for context key, so we don't have to use 'string' directly
@@ -30,6 +29,8 @@ type contextKey string
 
 var policyKey = contextKey("policy")
 var errorsKey = contextKey("errors")
+var clusterTypesKey = contextKey("clusterTypes")
+var codeTypesKey = contextKey("codeTypes")
 
 func (c contextKey) String() string {
 	return "lang context key " + string(c)
@@ -70,6 +71,7 @@ func NewPolicyValidator(policy *Policy) *PolicyValidator {
 	_ = result.RegisterValidationCtx("labels", validateLabels)
 	_ = result.RegisterValidationCtx("labelOperations", validateLabelOperations)
 	_ = result.RegisterValidationCtx("allowReject", validateAllowRejectAction)
+	_ = result.RegisterValidationCtx("updateStrategy", validateUpdateStrategy)
 	_ = result.RegisterValidationCtx("addRoleNS", validateACLRoleActionMap)
 
 	// validators with context containing policy
@@ -79,9 +81,12 @@ func NewPolicyValidator(policy *Policy) *PolicyValidator {
 	result.RegisterStructValidationCtx(validateDependency, Dependency{})
 	result.RegisterStructValidationCtx(validateContract, Contract{})
 
-	// context
+	// context (supported types are taken from type registry once, so the whole policy is validated against them)
+	clusterTypes, codeTypes := getSupportedTypes()
 	ctx := context.WithValue(context.Background(), policyKey, policy)
 	ctx = context.WithValue(ctx, errorsKey, &policyValidationError{})
+	ctx = context.WithValue(ctx, clusterTypesKey, clusterTypes)
+	ctx = context.WithValue(ctx, codeTypesKey, codeTypes)
 
 	// default translations
 	eng := english.New()
@@ -109,6 +114,10 @@ func NewPolicyValidator(policy *Policy) *PolicyValidator {
 			tag:         "allowReject",
 			translation: fmt.Sprintf("'{0}' is not valid, must be in %s", allowReject),
 		},
+		{
+			tag:         "updateStrategy",
+			translation: fmt.Sprintf("'{0}' is not valid, must be in %s", updateStrategies),
+		},
 		{
 			tag:         "systemNS",
 			translation: fmt.Sprintf("'{0}' is not valid, must always be '%s'", runtime.SystemNS),
@@ -240,14 +249,19 @@ func validateAllowRejectAction(ctx context.Context, fl validator.FieldLevel) boo
 	return validateInStringArray(ctx, allowReject, fl)
 }
 
+// checks if a given string is a valid update strategy
+func validateUpdateStrategy(ctx context.Context, fl validator.FieldLevel) bool {
+	return validateInStringArray(ctx, updateStrategies, fl)
+}
+
 // checks if a given string is a valid cluster type
 func validateClusterType(ctx context.Context, fl validator.FieldLevel) bool {
-	return validateInStringArray(ctx, clusterTypes, fl)
+	return validateInStringArray(ctx, ctx.Value(clusterTypesKey).([]string), fl)
 }
 
 // checks if a given string is a valid code type
 func validateCodeType(ctx context.Context, fl validator.FieldLevel) bool {
-	return validateInStringArray(ctx, codeTypes, fl)
+	return validateInStringArray(ctx, ctx.Value(codeTypesKey).([]string), fl)
 }
 
 // checks if a given string is valid identifier
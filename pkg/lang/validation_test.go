@@ -46,6 +46,8 @@ func TestPolicyValidationService(t *testing.T) {
 		makeServiceComponents(2, contract.Name, Nil, 0),
 		makeServiceComponents(3, "", 0, 1),
 		makeServiceComponents(4, "", 1, 1),
+		updateStrategy(makeServiceComponents(2, "", 1, 1), UpdateStrategyBlueGreen),
+		deployName(makeServiceComponents(2, "", 1, 1), "{{ .Service.Name }}-{{ .Component.Name }}"),
 	}
 	for _, components := range componentTestsPass {
 		service := makeService("service", Empty)
@@ -63,6 +65,8 @@ func TestPolicyValidationService(t *testing.T) {
 		duplicateNames(makeServiceComponents(10, "", 1, 1)),
 		dependenciesInvalid(makeServiceComponents(10, "", 1, 1)),
 		dependenciesCycle(makeServiceComponents(10, "", 1, 1)),
+		updateStrategy(makeServiceComponents(2, "", 1, 1), "unknown"),
+		deployName(makeServiceComponents(2, "", 1, 1), "{{ .Service.Name "),
 	}
 	for _, components := range componentTestsFail {
 		service := makeService("service", Empty)
@@ -153,6 +157,33 @@ func TestPolicyValidationCluster(t *testing.T) {
 	})
 }
 
+func TestPolicyValidationTypeRegistry(t *testing.T) {
+	SetTypeRegistry(StaticTypes{"openshift": {"exec"}})
+	defer SetTypeRegistry(nil)
+
+	// only types from the registry are valid
+	runValidationTests(t, ResSuccess, true, []Base{
+		makeCluster("openshift", runtime.SystemNS),
+	})
+	runValidationTests(t, ResFailure, true, []Base{
+		makeCluster("kubernetes", runtime.SystemNS),
+	})
+
+	service := makeService("service", Empty)
+	service.Components = makeServiceComponents(1, "", 0, 0)
+	service.Components[0].Code.Type = "exec"
+	runValidationTests(t, ResSuccess, true, []Base{service})
+
+	service = makeService("service", Empty)
+	service.Components = makeServiceComponents(1, "", 0, 0)
+	runValidationTests(t, ResFailure, true, []Base{service})
+
+	// code types are supported only for the cluster types they are registered for
+	assert.True(t, IsCodeTypeSupported("openshift", "exec"), "Code type should be supported for its cluster type")
+	assert.False(t, IsCodeTypeSupported("openshift", "helm"), "Code type should not be supported if it's not registered")
+	assert.False(t, IsCodeTypeSupported("kubernetes", "exec"), "Code type should not be supported for unknown cluster type")
+}
+
 func runValidationTests(t *testing.T, result int, every bool, objects []Base) {
 	t.Helper()
 
@@ -411,6 +442,20 @@ func makeServiceComponents(count int, contract string, codeNum int, discoveryNum
 	return result
 }
 
+func updateStrategy(components []*ServiceComponent, strategy string) []*ServiceComponent {
+	for _, component := range components {
+		component.UpdateStrategy = strategy
+	}
+	return components
+}
+
+func deployName(components []*ServiceComponent, deployName string) []*ServiceComponent {
+	for _, component := range components {
+		component.DeployName = deployName
+	}
+	return components
+}
+
 func duplicateNames(components []*ServiceComponent) []*ServiceComponent {
 	for _, component := range components {
 		component.Name = "name"
@@ -0,0 +1,50 @@
+package external
+
+import (
+	"context"
+	"github.com/Aptomi/aptomi/pkg/config"
+	"github.com/Aptomi/aptomi/pkg/event"
+	"github.com/Aptomi/aptomi/pkg/lang"
+	"github.com/Aptomi/aptomi/pkg/plugin"
+)
+
+// clusterProvider is implemented by cluster plugins, which are able to tell what cluster they were created for. It's
+// used to pass cluster to external code plugins
+type clusterProvider interface {
+	GetCluster() *lang.Cluster
+}
+
+// ClusterPlugin represents cluster plugin, which is implemented by external plugin executable
+type ClusterPlugin struct {
+	plugin  *Plugin
+	cluster *lang.Cluster
+}
+
+var _ plugin.ClusterPlugin = &ClusterPlugin{}
+
+// NewClusterPluginConstructor returns constructor of the cluster plugin, which is implemented by a given external
+// plugin executable
+func NewClusterPluginConstructor(p *Plugin) plugin.ClusterPluginConstructor {
+	return func(cluster *lang.Cluster, cfg config.Plugins) (plugin.ClusterPlugin, error) {
+		return &ClusterPlugin{
+			plugin:  p,
+			cluster: cluster,
+		}, nil
+	}
+}
+
+// GetCluster returns cluster, for which plugin has been created
+func (p *ClusterPlugin) GetCluster() *lang.Cluster {
+	return p.cluster
+}
+
+// Validate checks cluster by calling external plugin
+func (p *ClusterPlugin) Validate() error {
+	_, err := p.plugin.call(context.Background(), MethodValidate, &Request{Cluster: newCluster(p.cluster)}, event.NewLog("external-plugin-validate", false))
+	return err
+}
+
+// Cleanup does nothing, as external plugin process is started for every call and exits after it
+func (p *ClusterPlugin) Cleanup() error {
+	return nil
+}
@@ -0,0 +1,93 @@
+package external
+
+import (
+	"context"
+	"fmt"
+	"github.com/Aptomi/aptomi/pkg/config"
+	"github.com/Aptomi/aptomi/pkg/event"
+	"github.com/Aptomi/aptomi/pkg/lang"
+	"github.com/Aptomi/aptomi/pkg/plugin"
+	"github.com/Aptomi/aptomi/pkg/util"
+)
+
+// CodePlugin represents code plugin, which is implemented by external plugin executable
+type CodePlugin struct {
+	plugin   *Plugin
+	cluster  *lang.Cluster
+	codeType string
+}
+
+var _ plugin.CodePlugin = &CodePlugin{}
+
+// NewCodePluginConstructor returns constructor of the code plugin for a given code type, which is implemented by a
+// given external plugin executable
+func NewCodePluginConstructor(p *Plugin, codeType string) plugin.CodePluginConstructor {
+	return func(clusterPlugin plugin.ClusterPlugin, cfg config.Plugins) (plugin.CodePlugin, error) {
+		provider, ok := clusterPlugin.(clusterProvider)
+		if !ok {
+			return nil, fmt.Errorf("external code plugin %s can't be used with cluster plugin %T", codeType, clusterPlugin)
+		}
+
+		return &CodePlugin{
+			plugin:   p,
+			cluster:  provider.GetCluster(),
+			codeType: codeType,
+		}, nil
+	}
+}
+
+// Cleanup does nothing, as external plugin process is started for every call and exits after it
+func (p *CodePlugin) Cleanup() error {
+	return nil
+}
+
+// Create creates deployment by calling external plugin
+func (p *CodePlugin) Create(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) error {
+	_, err := p.plugin.call(ctx, MethodCreate, p.newRequest(deployName, params), eventLog)
+	return err
+}
+
+// Update updates deployment by calling external plugin
+func (p *CodePlugin) Update(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) error {
+	_, err := p.plugin.call(ctx, MethodUpdate, p.newRequest(deployName, params), eventLog)
+	return err
+}
+
+// Destroy destroys deployment by calling external plugin
+func (p *CodePlugin) Destroy(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) error {
+	_, err := p.plugin.call(ctx, MethodDestroy, p.newRequest(deployName, params), eventLog)
+	return err
+}
+
+// Endpoints returns endpoints of deployment by calling external plugin
+func (p *CodePlugin) Endpoints(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) (map[string]string, error) {
+	result, err := p.plugin.call(ctx, MethodEndpoints, p.newRequest(deployName, params), eventLog)
+	if err != nil {
+		return nil, err
+	}
+	if result.Endpoints == nil {
+		return make(map[string]string), nil
+	}
+	return result.Endpoints, nil
+}
+
+// Resources returns resources of deployment by calling external plugin
+func (p *CodePlugin) Resources(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) (plugin.Resources, error) {
+	result, err := p.plugin.call(ctx, MethodResources, p.newRequest(deployName, params), eventLog)
+	if err != nil {
+		return nil, err
+	}
+	if result.Resources == nil {
+		return make(plugin.Resources), nil
+	}
+	return result.Resources, nil
+}
+
+func (p *CodePlugin) newRequest(deployName string, params util.NestedParameterMap) *Request {
+	return &Request{
+		Cluster:    newCluster(p.cluster),
+		CodeType:   p.codeType,
+		DeployName: deployName,
+		Params:     params,
+	}
+}
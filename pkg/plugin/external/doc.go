@@ -0,0 +1,11 @@
+// Package external implements support for out-of-process plugins, which are executables discovered in a configured
+// directory. They allow to add cluster types and code types without recompiling Aptomi.
+//
+// Every plugin call starts the plugin executable, writes a single JSON-RPC 2.0 request into its stdin and reads a
+// single JSON-RPC 2.0 response from its stdout. Stderr of the plugin gets recorded into the event log. When the call
+// gets cancelled (e.g. on timeout or when revision is aborted), the plugin process gets killed.
+//
+// Supported methods are "info", "validate", "create", "update", "destroy", "endpoints" and "resources". Method "info"
+// gets called once on discovery and should return cluster types and code types the plugin supports. Plugins written
+// in Go could use Serve to handle requests.
+package external
@@ -0,0 +1,144 @@
+package external
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/Aptomi/aptomi/pkg/event"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Plugin is an external plugin executable along with the information about cluster types and code types it supports
+type Plugin struct {
+	// Path is a path to the plugin executable
+	Path string
+
+	// Info describes what plugin supports, as reported by the plugin itself
+	Info *Info
+
+	timeout time.Duration
+}
+
+// Discover finds all executables in a given directory and queries them for supported cluster types and code types.
+// Every call to the plugin is limited by a given timeout (0 means no timeout)
+func Discover(dir string, timeout time.Duration) ([]*Plugin, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading external plugins dir %s: %s", dir, err)
+	}
+
+	result := []*Plugin{}
+	for _, file := range files {
+		if file.IsDir() || file.Mode()&0111 == 0 {
+			continue
+		}
+
+		p := &Plugin{
+			Path:    filepath.Join(dir, file.Name()),
+			timeout: timeout,
+		}
+		res, callErr := p.call(context.Background(), MethodInfo, &Request{}, event.NewLog("external-plugin-info", false))
+		if callErr != nil {
+			return nil, fmt.Errorf("error while getting info from external plugin %s: %s", p.Path, callErr)
+		}
+		if res.Info == nil {
+			return nil, fmt.Errorf("external plugin %s returned no info", p.Path)
+		}
+		p.Info = res.Info
+
+		result = append(result, p)
+	}
+
+	return result, nil
+}
+
+// String returns plugin name along with cluster types and code types it supports
+func (p *Plugin) String() string {
+	types := append([]string{}, p.Info.ClusterTypes...)
+	for clusterType, codeTypes := range p.Info.CodeTypes {
+		for _, codeType := range codeTypes {
+			types = append(types, clusterType+"/"+codeType)
+		}
+	}
+	sort.Strings(types)
+	return fmt.Sprintf("%s (%s)", filepath.Base(p.Path), strings.Join(types, ", "))
+}
+
+// call starts plugin executable and makes a single JSON-RPC call, recording events reported by the plugin into the
+// event log. Plugin process gets killed if context is cancelled or call times out
+func (p *Plugin) call(ctx context.Context, method string, request *Request, eventLog *event.Log) (*Result, error) {
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+
+	input, err := json.Marshal(&rpcRequest{
+		JSONRPC: jsonRPCVersion,
+		ID:      1,
+		Method:  method,
+		Params:  request,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error while encoding request: %s", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.Path) // nolint: gas
+	cmd.Stdin = bytes.NewReader(append(input, '\n'))
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err = cmd.Run()
+	if stderr.Len() > 0 {
+		eventLog.WithFields(event.Fields{}).Debugf("External plugin %s (%s) stderr: %s", filepath.Base(p.Path), method, stderr.String())
+	}
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("external plugin %s (%s) has been interrupted: %s", filepath.Base(p.Path), method, ctx.Err())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error while running external plugin %s (%s): %s", filepath.Base(p.Path), method, err)
+	}
+
+	response := &rpcResponse{}
+	err = json.Unmarshal(stdout.Bytes(), response)
+	if err != nil {
+		return nil, fmt.Errorf("error while decoding response from external plugin %s (%s): %s", filepath.Base(p.Path), method, err)
+	}
+
+	if response.Result != nil {
+		for _, e := range response.Result.Events {
+			logEvent(eventLog, e)
+		}
+	}
+
+	if response.Error != nil {
+		return nil, fmt.Errorf("external plugin %s (%s) failed: %s", filepath.Base(p.Path), method, response.Error.Message)
+	}
+	if response.Result == nil {
+		return &Result{}, nil
+	}
+
+	return response.Result, nil
+}
+
+// logEvent records event reported by external plugin into the event log
+func logEvent(eventLog *event.Log, e Event) {
+	entry := eventLog.WithFields(event.Fields{})
+	switch e.Level {
+	case "debug":
+		entry.Debug(e.Message)
+	case "warning":
+		entry.Warning(e.Message)
+	case "error":
+		entry.Error(e.Message)
+	default:
+		entry.Info(e.Message)
+	}
+}
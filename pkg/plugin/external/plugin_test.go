@@ -0,0 +1,182 @@
+package external
+
+import (
+	"context"
+	"fmt"
+	"github.com/Aptomi/aptomi/pkg/event"
+	"github.com/Aptomi/aptomi/pkg/plugin"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// envTestPlugin is set when test binary gets started as an external plugin by the tests below. Its value defines
+// how the plugin behaves
+const envTestPlugin = "APTOMI_TEST_EXTERNAL_PLUGIN"
+
+const (
+	pluginModeServe   = "serve"
+	pluginModeSleep   = "sleep"
+	pluginModeGarbage = "garbage"
+)
+
+func TestMain(m *testing.M) {
+	// test binary acts as an external plugin, serving a single call with the test handler
+	switch os.Getenv(envTestPlugin) {
+	case pluginModeServe:
+		if err := Serve(testHandler, os.Stdin, os.Stdout); err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	case pluginModeSleep:
+		time.Sleep(time.Minute)
+		os.Exit(0)
+	case pluginModeGarbage:
+		fmt.Println("not a json-rpc response")
+		os.Exit(0)
+	}
+
+	os.Exit(m.Run())
+}
+
+func TestDiscover(t *testing.T) {
+	dir := makePluginDir(t, pluginModeServe)
+	defer cleanupPluginDir(dir)
+
+	// non-executable files should be skipped
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "README"), []byte("readme"), 0644), "File should be created")
+
+	plugins, err := Discover(dir, 10*time.Second)
+	assert.NoError(t, err, "External plugins should be discovered")
+	if assert.Len(t, plugins, 1, "Only executables should be discovered as external plugins") {
+		assert.Equal(t, []string{"test-cluster"}, plugins[0].Info.ClusterTypes, "Cluster types should be reported by plugin")
+		assert.Equal(t, []string{"test-code"}, plugins[0].Info.CodeTypes["test-cluster"], "Code types should be reported by plugin")
+		assert.Equal(t, "test-plugin (test-cluster, test-cluster/test-code)", plugins[0].String(), "Plugin should be printed with its types")
+	}
+
+	// plugin, which doesn't respond properly, should fail discovery
+	dir = makePluginDir(t, pluginModeGarbage)
+	defer cleanupPluginDir(dir)
+	_, err = Discover(dir, 10*time.Second)
+	assert.Error(t, err, "Discovery should fail for plugin with invalid response")
+
+	// non-existing dir
+	_, err = Discover(filepath.Join(dir, "missing"), 10*time.Second)
+	assert.Error(t, err, "Discovery should fail for non-existing dir")
+}
+
+func TestPluginCall(t *testing.T) {
+	dir := makePluginDir(t, pluginModeServe)
+	defer cleanupPluginDir(dir)
+	p := &Plugin{Path: filepath.Join(dir, "test-plugin"), timeout: 10 * time.Second}
+	eventLog := event.NewLog("test-external", false)
+
+	// successful call
+	result, err := p.call(context.Background(), MethodEndpoints, &Request{DeployName: "foo"}, eventLog)
+	assert.NoError(t, err, "Call should succeed")
+	assert.Equal(t, "http://foo", result.Endpoints["http"], "Result should be returned from plugin")
+
+	// plugin error
+	_, err = p.call(context.Background(), MethodDestroy, &Request{DeployName: "foo"}, eventLog)
+	assert.EqualError(t, err, "external plugin test-plugin (destroy) failed: unable to destroy foo", "Plugin error should be returned")
+
+	// unsupported method
+	_, err = p.call(context.Background(), "unknown", &Request{}, eventLog)
+	assert.Error(t, err, "Call of unsupported method should fail")
+
+	// cancelled context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = p.call(ctx, MethodEndpoints, &Request{DeployName: "foo"}, eventLog)
+	assert.Error(t, err, "Call should fail with cancelled context")
+
+	// plugin gets killed once call times out
+	setPluginMode(t, pluginModeSleep)
+	p.timeout = 200 * time.Millisecond
+	_, err = p.call(context.Background(), MethodEndpoints, &Request{DeployName: "foo"}, eventLog)
+	assert.Contains(t, fmt.Sprint(err), "has been interrupted", "Call should be interrupted once it times out")
+}
+
+func TestRegister(t *testing.T) {
+	p := &Plugin{
+		Path: "test-plugin",
+		Info: &Info{
+			ClusterTypes: []string{"test-cluster"},
+			CodeTypes:    map[string][]string{"test-cluster": {"test-code"}, "kubernetes": {"test-code"}},
+		},
+	}
+
+	// types should be registered along with built-in ones
+	clusterTypes, codeTypes := makeBuiltinTypes()
+	assert.NoError(t, Register([]*Plugin{p}, clusterTypes, codeTypes), "External plugin should be registered")
+	assert.Contains(t, clusterTypes, "test-cluster", "Cluster type should be registered")
+	assert.Contains(t, codeTypes["test-cluster"], "test-code", "Code type should be registered")
+	assert.Contains(t, codeTypes["kubernetes"], "test-code", "Code type should be registered for built-in cluster type")
+	assert.Contains(t, codeTypes["kubernetes"], "helm", "Built-in code type should be kept")
+
+	// built-in cluster types can't be replaced
+	clusterTypes, codeTypes = makeBuiltinTypes()
+	p.Info.ClusterTypes = []string{"kubernetes"}
+	assert.Error(t, Register([]*Plugin{p}, clusterTypes, codeTypes), "Built-in cluster type should not be replaced")
+
+	// built-in code types can't be replaced
+	clusterTypes, codeTypes = makeBuiltinTypes()
+	p.Info.ClusterTypes = nil
+	p.Info.CodeTypes = map[string][]string{"kubernetes": {"helm"}}
+	assert.Error(t, Register([]*Plugin{p}, clusterTypes, codeTypes), "Built-in code type should not be replaced")
+}
+
+/*
+	Helpers
+*/
+
+// makePluginDir creates a directory with the test binary linked as external plugin, which will behave according to
+// a given mode
+func makePluginDir(t *testing.T, mode string) string {
+	t.Helper()
+	executable, err := os.Executable()
+	if !assert.NoError(t, err, "Test binary path should be known") {
+		t.FailNow()
+	}
+
+	dir, err := ioutil.TempDir("", "aptomi-external-plugins")
+	if !assert.NoError(t, err, "Temp dir should be created") {
+		t.FailNow()
+	}
+
+	err = os.Symlink(executable, filepath.Join(dir, "test-plugin"))
+	if !assert.NoError(t, err, "Test plugin should be linked") {
+		t.FailNow()
+	}
+
+	// plugin process inherits environment, so it will know how to behave
+	setPluginMode(t, mode)
+
+	return dir
+}
+
+// cleanupPluginDir removes directory with the test plugin and resets its mode
+func cleanupPluginDir(dir string) {
+	os.RemoveAll(dir)          // nolint: errcheck
+	os.Unsetenv(envTestPlugin) // nolint: errcheck
+}
+
+func setPluginMode(t *testing.T, mode string) {
+	t.Helper()
+	if !assert.NoError(t, os.Setenv(envTestPlugin, mode), "Env variable should be set") {
+		t.FailNow()
+	}
+}
+
+func makeBuiltinTypes() (map[string]plugin.ClusterPluginConstructor, map[string]map[string]plugin.CodePluginConstructor) {
+	clusterTypes := map[string]plugin.ClusterPluginConstructor{
+		"kubernetes": nil,
+	}
+	codeTypes := map[string]map[string]plugin.CodePluginConstructor{
+		"kubernetes": {"helm": nil, "raw": nil},
+	}
+	return clusterTypes, codeTypes
+}
@@ -0,0 +1,142 @@
+package external
+
+import (
+	"fmt"
+	"github.com/Aptomi/aptomi/pkg/lang"
+	"github.com/Aptomi/aptomi/pkg/plugin"
+	"github.com/Aptomi/aptomi/pkg/util"
+)
+
+// Methods supported by external plugins
+const (
+	MethodInfo      = "info"
+	MethodValidate  = "validate"
+	MethodCreate    = "create"
+	MethodUpdate    = "update"
+	MethodDestroy   = "destroy"
+	MethodEndpoints = "endpoints"
+	MethodResources = "resources"
+)
+
+// jsonRPCVersion is a version of JSON-RPC protocol used to talk to external plugins
+const jsonRPCVersion = "2.0"
+
+// Info describes what external plugin supports
+type Info struct {
+	// ClusterTypes is a list of cluster types, which plugin is able to validate
+	ClusterTypes []string `json:"clusterTypes,omitempty"`
+
+	// CodeTypes is a map from cluster type to the list of code types, which plugin is able to deploy into clusters
+	// of that type
+	CodeTypes map[string][]string `json:"codeTypes,omitempty"`
+}
+
+// Cluster is a cluster, as it gets passed to external plugins
+type Cluster struct {
+	Name   string            `json:"name"`
+	Type   string            `json:"type"`
+	Labels map[string]string `json:"labels,omitempty"`
+	Config interface{}       `json:"config,omitempty"`
+}
+
+// Request represents parameters of a single external plugin call
+type Request struct {
+	// Cluster is a cluster the call is made for (not set for "info")
+	Cluster *Cluster `json:"cluster,omitempty"`
+
+	// CodeType is a code type of component instance (set for code plugin calls only)
+	CodeType string `json:"codeType,omitempty"`
+
+	// DeployName is a name of deployment (set for code plugin calls only)
+	DeployName string `json:"deployName,omitempty"`
+
+	// Params are code parameters of component instance (set for code plugin calls only)
+	Params util.NestedParameterMap `json:"params,omitempty"`
+}
+
+// Result represents result of a single external plugin call
+type Result struct {
+	// Info is set for "info" calls
+	Info *Info `json:"info,omitempty"`
+
+	// Endpoints is set for "endpoints" calls
+	Endpoints map[string]string `json:"endpoints,omitempty"`
+
+	// Resources is set for "resources" calls
+	Resources plugin.Resources `json:"resources,omitempty"`
+
+	// Events are messages, which get recorded into the event log
+	Events []Event `json:"events,omitempty"`
+}
+
+// Event is a message reported by external plugin
+type Event struct {
+	// Level is one of "debug", "info", "warning" or "error"
+	Level   string `json:"level"`
+	Message string `json:"message"`
+}
+
+type rpcRequest struct {
+	JSONRPC string   `json:"jsonrpc"`
+	ID      int      `json:"id"`
+	Method  string   `json:"method"`
+	Params  *Request `json:"params"`
+}
+
+type rpcResponse struct {
+	JSONRPC string    `json:"jsonrpc"`
+	ID      int       `json:"id"`
+	Result  *Result   `json:"result,omitempty"`
+	Error   *rpcError `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error codes defined by JSON-RPC 2.0
+const (
+	errorCodeParse          = -32700
+	errorCodeMethodNotFound = -32601
+	errorCodeInternal       = -32603
+)
+
+// newCluster converts cluster into the form it gets passed to external plugins
+func newCluster(cluster *lang.Cluster) *Cluster {
+	if cluster == nil {
+		return nil
+	}
+	return &Cluster{
+		Name:   cluster.Name,
+		Type:   cluster.Type,
+		Labels: cluster.Labels,
+		Config: toJSONCompatible(cluster.Config),
+	}
+}
+
+// toJSONCompatible converts maps with interface keys (produced by yaml parser for cluster config) into maps with
+// string keys, so they can be serialized into JSON
+func toJSONCompatible(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[fmt.Sprint(key)] = toJSONCompatible(item)
+		}
+		return result
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[key] = toJSONCompatible(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for idx, item := range v {
+			result[idx] = toJSONCompatible(item)
+		}
+		return result
+	}
+	return value
+}
@@ -0,0 +1,32 @@
+package external
+
+import (
+	"fmt"
+	"github.com/Aptomi/aptomi/pkg/plugin"
+)
+
+// Register adds constructors for all cluster types and code types supported by given external plugins. It returns an
+// error if some cluster type or code type is already registered, so built-in plugins can't be silently replaced
+func Register(plugins []*Plugin, clusterTypes map[string]plugin.ClusterPluginConstructor, codeTypes map[string]map[string]plugin.CodePluginConstructor) error {
+	for _, p := range plugins {
+		for _, clusterType := range p.Info.ClusterTypes {
+			if _, exist := clusterTypes[clusterType]; exist {
+				return fmt.Errorf("external plugin %s provides cluster type %s, which is already registered", p.Path, clusterType)
+			}
+			clusterTypes[clusterType] = NewClusterPluginConstructor(p)
+		}
+
+		for clusterType, types := range p.Info.CodeTypes {
+			if _, exist := codeTypes[clusterType]; !exist {
+				codeTypes[clusterType] = make(map[string]plugin.CodePluginConstructor)
+			}
+			for _, codeType := range types {
+				if _, exist := codeTypes[clusterType][codeType]; exist {
+					return fmt.Errorf("external plugin %s provides code type %s for cluster type %s, which is already registered", p.Path, codeType, clusterType)
+				}
+				codeTypes[clusterType][codeType] = NewCodePluginConstructor(p, codeType)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package external
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// ErrMethodNotFound should be returned by Handler for methods it doesn't support
+var ErrMethodNotFound = errors.New("method not found")
+
+// Handler handles a single call to the external plugin
+type Handler func(method string, request *Request) (*Result, error)
+
+// Serve could be used by external plugins written in Go. It reads a single JSON-RPC request from a given input
+// (typically stdin), handles it with a given handler and writes JSON-RPC response into a given output (typically stdout)
+func Serve(handler Handler, in io.Reader, out io.Writer) error {
+	response := &rpcResponse{
+		JSONRPC: jsonRPCVersion,
+	}
+
+	request := &rpcRequest{}
+	err := json.NewDecoder(in).Decode(request)
+	if err != nil {
+		response.Error = &rpcError{Code: errorCodeParse, Message: err.Error()}
+	} else {
+		response.ID = request.ID
+		if request.Params == nil {
+			request.Params = &Request{}
+		}
+
+		result, handleErr := handler(request.Method, request.Params)
+		if handleErr == ErrMethodNotFound {
+			response.Error = &rpcError{Code: errorCodeMethodNotFound, Message: handleErr.Error() + ": " + request.Method}
+		} else if handleErr != nil {
+			response.Error = &rpcError{Code: errorCodeInternal, Message: handleErr.Error()}
+		} else {
+			response.Result = result
+		}
+	}
+
+	return json.NewEncoder(out).Encode(response)
+}
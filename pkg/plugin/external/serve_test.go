@@ -0,0 +1,64 @@
+package external
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testHandler(method string, request *Request) (*Result, error) {
+	switch method {
+	case MethodInfo:
+		return &Result{Info: &Info{
+			ClusterTypes: []string{"test-cluster"},
+			CodeTypes:    map[string][]string{"test-cluster": {"test-code"}},
+		}}, nil
+	case MethodEndpoints:
+		return &Result{Endpoints: map[string]string{"http": "http://" + request.DeployName}}, nil
+	case MethodDestroy:
+		return nil, fmt.Errorf("unable to destroy %s", request.DeployName)
+	}
+	return nil, ErrMethodNotFound
+}
+
+func serve(t *testing.T, input string) *rpcResponse {
+	t.Helper()
+	out := &bytes.Buffer{}
+	assert.NoError(t, Serve(testHandler, strings.NewReader(input), out), "Serve should not fail")
+
+	response := &rpcResponse{}
+	assert.NoError(t, json.Unmarshal(out.Bytes(), response), "Response should be valid JSON")
+	assert.Equal(t, jsonRPCVersion, response.JSONRPC, "Response should have JSON-RPC version set")
+	return response
+}
+
+func TestServe(t *testing.T) {
+	// info
+	response := serve(t, `{"jsonrpc": "2.0", "id": 1, "method": "info"}`)
+	assert.Equal(t, 1, response.ID, "Response ID should match request ID")
+	assert.Nil(t, response.Error, "Info call should succeed")
+	assert.Equal(t, []string{"test-code"}, response.Result.Info.CodeTypes["test-cluster"], "Info should contain supported code types")
+
+	// request params are passed to the handler
+	response = serve(t, `{"jsonrpc": "2.0", "id": 2, "method": "endpoints", "params": {"deployName": "foo"}}`)
+	assert.Nil(t, response.Error, "Endpoints call should succeed")
+	assert.Equal(t, "http://foo", response.Result.Endpoints["http"], "Endpoints should be calculated from request")
+
+	// handler error
+	response = serve(t, `{"jsonrpc": "2.0", "id": 3, "method": "destroy", "params": {"deployName": "foo"}}`)
+	assert.Nil(t, response.Result, "Failed call should have no result")
+	assert.Equal(t, errorCodeInternal, response.Error.Code, "Handler error should be reported as internal error")
+	assert.Equal(t, "unable to destroy foo", response.Error.Message, "Handler error message should be passed through")
+
+	// unknown method
+	response = serve(t, `{"jsonrpc": "2.0", "id": 4, "method": "unknown"}`)
+	assert.Equal(t, errorCodeMethodNotFound, response.Error.Code, "Unknown method should be reported")
+
+	// malformed request
+	response = serve(t, `{not json`)
+	assert.Equal(t, errorCodeParse, response.Error.Code, "Malformed request should be reported as parse error")
+}
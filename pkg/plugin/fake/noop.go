@@ -1,6 +1,7 @@
 package fake
 
 import (
+	"context"
 	"github.com/Aptomi/aptomi/pkg/engine/resolve"
 	"github.com/Aptomi/aptomi/pkg/event"
 	"github.com/Aptomi/aptomi/pkg/external"
@@ -16,6 +17,7 @@ type noOpPlugin struct {
 
 var _ plugin.ClusterPlugin = &noOpPlugin{}
 var _ plugin.CodePlugin = &noOpPlugin{}
+var _ plugin.DeploymentInspector = &noOpPlugin{}
 
 // NewNoOpClusterPlugin returns fake cluster plugin which does nothing, except sleeping a given time amount on every action
 func NewNoOpClusterPlugin(sleepTime time.Duration) plugin.ClusterPlugin {
@@ -39,30 +41,47 @@ func (plugin *noOpPlugin) Cleanup() error {
 	return nil
 }
 
-func (plugin *noOpPlugin) Create(deployName string, params util.NestedParameterMap, eventLog *event.Log) error {
-	time.Sleep(plugin.sleepTime)
-	return nil
+func (plugin *noOpPlugin) Create(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) error {
+	return plugin.sleep(ctx)
 }
 
-func (plugin *noOpPlugin) Update(deployName string, params util.NestedParameterMap, eventLog *event.Log) error {
-	time.Sleep(plugin.sleepTime)
-	return nil
+func (plugin *noOpPlugin) Update(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) error {
+	return plugin.sleep(ctx)
 }
 
-func (plugin *noOpPlugin) Destroy(deployName string, params util.NestedParameterMap, eventLog *event.Log) error {
-	time.Sleep(plugin.sleepTime)
-	return nil
+func (plugin *noOpPlugin) Destroy(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) error {
+	return plugin.sleep(ctx)
 }
 
-func (plugin *noOpPlugin) Endpoints(deployName string, params util.NestedParameterMap, eventLog *event.Log) (map[string]string, error) {
-	time.Sleep(plugin.sleepTime)
+func (plugin *noOpPlugin) Endpoints(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) (map[string]string, error) {
+	err := plugin.sleep(ctx)
+	if err != nil {
+		return nil, err
+	}
 	return make(map[string]string), nil
 }
 
-func (plugin *noOpPlugin) Resources(deployName string, params util.NestedParameterMap, eventLog *event.Log) (plugin.Resources, error) {
+func (plugin *noOpPlugin) Resources(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) (plugin.Resources, error) {
 	return nil, nil
 }
 
+func (p *noOpPlugin) Inspect(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) (*plugin.DeploymentStatus, error) {
+	return &plugin.DeploymentStatus{Exists: true, Matches: true}, nil
+}
+
 func (plugin *noOpPlugin) Process(desiredPolicy *lang.Policy, desiredState *resolve.PolicyResolution, externalData *external.Data, eventLog *event.Log) error {
 	return nil
 }
+
+// sleep sleeps a given time amount, unless context gets cancelled
+func (plugin *noOpPlugin) sleep(ctx context.Context) error {
+	timer := time.NewTimer(plugin.sleepTime)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
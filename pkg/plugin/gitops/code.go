@@ -0,0 +1,238 @@
+package gitops
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/Aptomi/aptomi/pkg/config"
+	"github.com/Aptomi/aptomi/pkg/event"
+	"github.com/Aptomi/aptomi/pkg/plugin"
+	"github.com/Aptomi/aptomi/pkg/util"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// renderer renders code params of the component instance into a map from file name to file content
+type renderer func(params util.NestedParameterMap) (map[string][]byte, error)
+
+// CodePlugin represents gitops code plugin, which renders component instances into files in the cluster directory
+type CodePlugin struct {
+	gitops *Plugin
+	render renderer
+}
+
+var _ plugin.CodePlugin = &CodePlugin{}
+var _ plugin.DeploymentInspector = &CodePlugin{}
+var _ plugin.DeploymentLister = &CodePlugin{}
+
+// NewRaw returns new instance of the gitops code plugin, which renders raw k8s manifest from the "manifest" param
+func NewRaw(clusterPlugin plugin.ClusterPlugin, cfg config.Plugins) (plugin.CodePlugin, error) {
+	return newCodePlugin(clusterPlugin, renderRaw)
+}
+
+// NewHelm returns new instance of the gitops code plugin, which renders Helm chart reference and values
+func NewHelm(clusterPlugin plugin.ClusterPlugin, cfg config.Plugins) (plugin.CodePlugin, error) {
+	return newCodePlugin(clusterPlugin, renderHelm)
+}
+
+func newCodePlugin(clusterPlugin plugin.ClusterPlugin, render renderer) (plugin.CodePlugin, error) {
+	gitopsPlugin, ok := clusterPlugin.(*Plugin)
+	if !ok {
+		return nil, fmt.Errorf("gitops cluster plugin expected for gitops code plugin creation but received: %T", clusterPlugin)
+	}
+
+	return &CodePlugin{
+		gitops: gitopsPlugin,
+		render: render,
+	}, nil
+}
+
+func renderRaw(params util.NestedParameterMap) (map[string][]byte, error) {
+	manifest, ok := params["manifest"].(string)
+	if !ok {
+		return nil, fmt.Errorf("manifest is a mandatory parameter")
+	}
+
+	return map[string][]byte{"manifest.yaml": []byte(manifest)}, nil
+}
+
+func renderHelm(params util.NestedParameterMap) (map[string][]byte, error) {
+	chart := make(map[string]string)
+	values := params.MakeCopy()
+	for _, key := range []string{"chartRepo", "chartName", "chartVersion"} {
+		if value, exist := values[key]; exist {
+			chart[key] = fmt.Sprint(value)
+			delete(values, key)
+		}
+	}
+	if len(chart["chartRepo"]) == 0 || len(chart["chartName"]) == 0 {
+		return nil, fmt.Errorf("chartRepo and chartName are mandatory parameters")
+	}
+
+	chartData, err := yaml.Marshal(chart)
+	if err != nil {
+		return nil, fmt.Errorf("error while marshaling chart reference: %s", err)
+	}
+	valuesData, err := yaml.Marshal(values)
+	if err != nil {
+		return nil, fmt.Errorf("error while marshaling chart values: %s", err)
+	}
+
+	return map[string][]byte{"chart.yaml": chartData, "values.yaml": valuesData}, nil
+}
+
+// Cleanup implements cleanup phase for the gitops code plugin
+func (p *CodePlugin) Cleanup() error {
+	return nil
+}
+
+// Create implements creation of a new component instance by rendering it into the cluster directory
+func (p *CodePlugin) Create(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) error {
+	return p.write(deployName, params, eventLog)
+}
+
+// Update implements update of an existing component instance by re-rendering it into the cluster directory
+func (p *CodePlugin) Update(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) error {
+	return p.write(deployName, params, eventLog)
+}
+
+// Destroy implements destruction of an existing component instance by deleting its files from the cluster directory
+func (p *CodePlugin) Destroy(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) error {
+	err := p.gitops.Init()
+	if err != nil {
+		return err
+	}
+
+	err = os.RemoveAll(p.deployDir(deployName))
+	if err != nil {
+		return fmt.Errorf("error while deleting files of %s: %s", deployName, err)
+	}
+	eventLog.WithFields(event.Fields{}).Infof("Deleted files of %s from %s", deployName, p.gitops.clusterDir())
+
+	return nil
+}
+
+// Endpoints returns no endpoints, as component instances are deployed by GitOps controller
+func (p *CodePlugin) Endpoints(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) (map[string]string, error) {
+	return make(map[string]string), nil
+}
+
+// Resources returns files rendered for the component instance
+func (p *CodePlugin) Resources(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) (plugin.Resources, error) {
+	err := p.gitops.Init()
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := ioutil.ReadDir(p.deployDir(deployName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	table := &plugin.ResourceTable{
+		Headers: []string{"Path", "Size"},
+	}
+	for _, file := range files {
+		table.Items = append(table.Items, plugin.Resource{
+			filepath.Join(p.gitops.Config.Dir, deployName, file.Name()),
+			fmt.Sprintf("%d", file.Size()),
+		})
+	}
+
+	return plugin.Resources{"file": table}, nil
+}
+
+// Inspect checks that files of the component instance exist and match the ones rendered from provided params
+func (p *CodePlugin) Inspect(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) (*plugin.DeploymentStatus, error) {
+	err := p.gitops.Init()
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := p.render(params)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, statErr := os.Stat(p.deployDir(deployName)); os.IsNotExist(statErr) {
+		return &plugin.DeploymentStatus{Exists: false, Message: fmt.Sprintf("files of %s are missing in %s", deployName, p.gitops.clusterDir())}, nil
+	}
+
+	for name, data := range files {
+		current, readErr := ioutil.ReadFile(filepath.Join(p.deployDir(deployName), name))
+		if readErr != nil || !bytes.Equal(current, data) {
+			return &plugin.DeploymentStatus{Exists: true, Matches: false, Message: fmt.Sprintf("file %s of %s differs from the code params", name, deployName)}, nil
+		}
+	}
+
+	return &plugin.DeploymentStatus{Exists: true, Matches: true}, nil
+}
+
+// List returns all component instances rendered into the cluster directory. Params aren't needed to delete them, so
+// they are empty
+func (p *CodePlugin) List(ctx context.Context, eventLog *event.Log) (map[string]util.NestedParameterMap, error) {
+	err := p.gitops.Init()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]util.NestedParameterMap)
+	files, err := ioutil.ReadDir(p.gitops.clusterDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return result, nil
+		}
+		return nil, err
+	}
+
+	for _, file := range files {
+		if file.IsDir() && file.Name() != ".git" {
+			result[file.Name()] = util.NestedParameterMap{}
+		}
+	}
+
+	return result, nil
+}
+
+// write renders component instance and writes its files into the deploy directory, removing stale files
+func (p *CodePlugin) write(deployName string, params util.NestedParameterMap, eventLog *event.Log) error {
+	err := p.gitops.Init()
+	if err != nil {
+		return err
+	}
+
+	files, err := p.render(params)
+	if err != nil {
+		return err
+	}
+
+	dir := p.deployDir(deployName)
+	err = os.RemoveAll(dir)
+	if err != nil {
+		return fmt.Errorf("error while deleting stale files of %s: %s", deployName, err)
+	}
+	err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		return fmt.Errorf("error while creating dir for %s: %s", deployName, err)
+	}
+
+	for name, data := range files {
+		err = ioutil.WriteFile(filepath.Join(dir, name), data, 0644)
+		if err != nil {
+			return fmt.Errorf("error while writing file %s for %s: %s", name, deployName, err)
+		}
+	}
+	eventLog.WithFields(event.Fields{}).Infof("Rendered %s into %s", deployName, dir)
+
+	return nil
+}
+
+// deployDir returns absolute path to the directory with files of the component instance
+func (p *CodePlugin) deployDir(deployName string) string {
+	return filepath.Join(p.gitops.clusterDir(), deployName)
+}
@@ -0,0 +1,53 @@
+package gitops
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ClusterConfig represents gitops cluster plugin configuration
+type ClusterConfig struct {
+	// Repo is a path to the local git working tree (mandatory)
+	Repo string `yaml:",omitempty"`
+
+	// Dir is a directory inside the repository, where component instances of the cluster get rendered (cluster name
+	// by default)
+	Dir string `yaml:",omitempty"`
+
+	// AuthorName and AuthorEmail are used for commits (Aptomi <aptomi@localhost> by default)
+	AuthorName  string `yaml:"authorName,omitempty"`
+	AuthorEmail string `yaml:"authorEmail,omitempty"`
+
+	// Push enables pushing commits to the default remote
+	Push bool `yaml:",omitempty"`
+}
+
+func (p *Plugin) parseClusterConfig() error {
+	clusterConfig := &ClusterConfig{}
+	err := p.Cluster.ParseConfigInto(clusterConfig)
+	if err != nil {
+		return fmt.Errorf("error while parsing gitops specific config of cluster %s: %s", p.Cluster.Name, err)
+	}
+
+	if len(clusterConfig.Repo) == 0 {
+		return fmt.Errorf("repo is mandatory in config of gitops cluster %s", p.Cluster.Name)
+	}
+	if len(clusterConfig.Dir) == 0 {
+		clusterConfig.Dir = p.Cluster.Name
+	}
+	clusterConfig.Dir = filepath.Clean(clusterConfig.Dir)
+	if filepath.IsAbs(clusterConfig.Dir) || clusterConfig.Dir == ".." || strings.HasPrefix(clusterConfig.Dir, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("dir in config of gitops cluster %s should be relative to repo: %s", p.Cluster.Name, clusterConfig.Dir)
+	}
+	if len(clusterConfig.AuthorName) == 0 {
+		clusterConfig.AuthorName = "Aptomi"
+	}
+	if len(clusterConfig.AuthorEmail) == 0 {
+		clusterConfig.AuthorEmail = "aptomi@localhost"
+	}
+
+	p.Config = clusterConfig
+
+	return nil
+}
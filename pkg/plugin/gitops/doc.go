@@ -0,0 +1,9 @@
+// Package gitops implements support for the gitops cluster type. Instead of deploying components into the cloud,
+// its code plugins render component instances into files in a local git working tree, which is then picked up by
+// a GitOps controller managing the actual cluster.
+//
+// Every component instance gets its own directory under the per-cluster directory of the repository. Raw code
+// plugin writes manifest.yaml with the raw manifest, while Helm code plugin writes chart.yaml with the chart reference
+// and values.yaml with the chart values. Once all actions of the revision have been applied, all changes in the
+// cluster directory are committed with a message referencing Aptomi revision generation (and optionally pushed).
+package gitops
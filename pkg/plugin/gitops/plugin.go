@@ -0,0 +1,129 @@
+package gitops
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/Aptomi/aptomi/pkg/config"
+	"github.com/Aptomi/aptomi/pkg/event"
+	"github.com/Aptomi/aptomi/pkg/lang"
+	"github.com/Aptomi/aptomi/pkg/plugin"
+	"github.com/Aptomi/aptomi/pkg/runtime"
+	"github.com/Aptomi/aptomi/pkg/util/sync"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	gosync "sync"
+)
+
+// git commands are serialized, as multiple clusters could share the same repository
+var gitMutex gosync.Mutex
+
+// Plugin represents gitops cluster plugin, which renders component instances into a git repository
+type Plugin struct {
+	once    sync.Init
+	Cluster *lang.Cluster
+	Config  *ClusterConfig
+}
+
+var _ plugin.ClusterPlugin = &Plugin{}
+var _ plugin.RevisionCommitter = &Plugin{}
+
+// New creates new instance of the gitops cluster plugin for specified Cluster and plugins config
+func New(cluster *lang.Cluster, cfg config.Plugins) (plugin.ClusterPlugin, error) {
+	return &Plugin{
+		Cluster: cluster,
+	}, nil
+}
+
+// GetCluster returns cluster, for which plugin has been created
+func (p *Plugin) GetCluster() *lang.Cluster {
+	return p.Cluster
+}
+
+// Validate checks that configured repository is a git working tree
+func (p *Plugin) Validate() error {
+	err := p.Init()
+	if err != nil {
+		return err
+	}
+
+	_, err = p.git("rev-parse", "--is-inside-work-tree")
+	if err != nil {
+		return fmt.Errorf("repo of gitops cluster %s is not a git working tree: %s", p.Cluster.Name, err)
+	}
+
+	return nil
+}
+
+// Init parses gitops cluster config
+func (p *Plugin) Init() error {
+	return p.once.Do(func() error {
+		return p.parseClusterConfig()
+	})
+}
+
+// Cleanup intended to run cleanup operations for plugin, but it's not used in gitops cluster plugin
+func (p *Plugin) Cleanup() error {
+	// no cleanup needed
+	return nil
+}
+
+// CommitRevision commits all changes made in the cluster directory while applying revision with a given generation.
+// Commits are pushed even if there are no new changes, so commits which failed to be pushed before get pushed too
+func (p *Plugin) CommitRevision(gen runtime.Generation, eventLog *event.Log) error {
+	err := p.Init()
+	if err != nil {
+		return err
+	}
+
+	gitMutex.Lock()
+	defer gitMutex.Unlock()
+
+	_, err = p.git("add", "--all", "--", p.Config.Dir)
+	if err != nil {
+		return err
+	}
+
+	status, err := p.git("status", "--porcelain", "--", p.Config.Dir)
+	if err != nil {
+		return err
+	}
+	if len(strings.TrimSpace(status)) > 0 {
+		message := fmt.Sprintf("Aptomi revision %d for cluster %s", gen, p.Cluster.Name)
+		_, err = p.git("-c", "user.name="+p.Config.AuthorName, "-c", "user.email="+p.Config.AuthorEmail, "commit", "--quiet", "--message", message, "--", p.Config.Dir)
+		if err != nil {
+			return err
+		}
+		eventLog.WithFields(event.Fields{}).Infof("Committed changes for cluster %s into %s: %s", p.Cluster.Name, p.Config.Repo, message)
+	}
+
+	if p.Config.Push {
+		_, err = p.git("push", "--quiet")
+		if err != nil {
+			return err
+		}
+		eventLog.WithFields(event.Fields{}).Infof("Pushed changes for cluster %s from %s", p.Cluster.Name, p.Config.Repo)
+	}
+
+	return nil
+}
+
+// clusterDir returns absolute path to the cluster directory in the repository
+func (p *Plugin) clusterDir() string {
+	return filepath.Join(p.Config.Repo, p.Config.Dir)
+}
+
+// git runs git command in the repository and returns its standard output
+func (p *Plugin) git(args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", p.Config.Repo}, args...)...) // nolint: gas
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return "", fmt.Errorf("error while running git %s: %s (%s)", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.String(), nil
+}
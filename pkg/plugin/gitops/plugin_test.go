@@ -0,0 +1,165 @@
+package gitops
+
+import (
+	"context"
+	"github.com/Aptomi/aptomi/pkg/config"
+	"github.com/Aptomi/aptomi/pkg/event"
+	"github.com/Aptomi/aptomi/pkg/lang"
+	"github.com/Aptomi/aptomi/pkg/plugin"
+	"github.com/Aptomi/aptomi/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func newTestRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	repo, err := ioutil.TempDir("", "aptomi-gitops-test")
+	assert.NoError(t, err, "Temp dir should be created")
+	_, err = exec.Command("git", "init", "--quiet", repo).Output()
+	assert.NoError(t, err, "Git repo should be initialized")
+
+	return repo
+}
+
+func newTestPlugins(t *testing.T, repo string) (*Plugin, plugin.CodePlugin, plugin.CodePlugin) {
+	t.Helper()
+	cluster := &lang.Cluster{
+		Metadata: lang.Metadata{Name: "cluster-us-east"},
+		Type:     "gitops",
+		Config:   map[string]interface{}{"repo": repo, "dir": "clusters/us-east"},
+	}
+
+	clusterPlugin, err := New(cluster, config.Plugins{})
+	assert.NoError(t, err, "GitOps cluster plugin should be created")
+	assert.NoError(t, clusterPlugin.Validate(), "GitOps cluster should be valid")
+
+	raw, err := NewRaw(clusterPlugin, config.Plugins{})
+	assert.NoError(t, err, "GitOps raw code plugin should be created")
+	helm, err := NewHelm(clusterPlugin, config.Plugins{})
+	assert.NoError(t, err, "GitOps helm code plugin should be created")
+
+	return clusterPlugin.(*Plugin), raw, helm
+}
+
+func gitLog(t *testing.T, repo string) string {
+	t.Helper()
+	out, err := exec.Command("git", "-C", repo, "log", "--format=%s").Output()
+	assert.NoError(t, err, "Git log should be retrieved")
+	return string(out)
+}
+
+func TestGitOpsRender(t *testing.T) {
+	repo := newTestRepo(t)
+	defer os.RemoveAll(repo) // nolint: errcheck
+
+	cluster, raw, helm := newTestPlugins(t, repo)
+	ctx := context.Background()
+	eventLog := event.NewLog("test-gitops", false)
+	dir := filepath.Join(repo, "clusters", "us-east")
+
+	// render raw manifest and helm chart
+	rawParams := util.NestedParameterMap{"manifest": "kind: ConfigMap\n"}
+	assert.NoError(t, raw.Create(ctx, "config", rawParams, eventLog), "Raw component should be rendered")
+	helmParams := util.NestedParameterMap{"chartRepo": "https://charts.example.com", "chartName": "redis", "chartVersion": "1.0.0", "replicas": 3}
+	assert.NoError(t, helm.Create(ctx, "redis", helmParams, eventLog), "Helm component should be rendered")
+
+	manifest, err := ioutil.ReadFile(filepath.Join(dir, "config", "manifest.yaml"))
+	assert.NoError(t, err, "Manifest should be written")
+	assert.Equal(t, "kind: ConfigMap\n", string(manifest), "Manifest should be written as is")
+
+	values, err := ioutil.ReadFile(filepath.Join(dir, "redis", "values.yaml"))
+	assert.NoError(t, err, "Values should be written")
+	assert.Equal(t, "replicas: 3\n", string(values), "Values should not contain chart reference")
+
+	chart, err := ioutil.ReadFile(filepath.Join(dir, "redis", "chart.yaml"))
+	assert.NoError(t, err, "Chart reference should be written")
+	assert.Contains(t, string(chart), "chartName: redis", "Chart reference should be written")
+
+	// commit revision
+	assert.NoError(t, cluster.CommitRevision(5, eventLog), "Revision should be committed")
+	assert.Equal(t, "Aptomi revision 5 for cluster cluster-us-east\n", gitLog(t, repo), "Commit should reference revision generation")
+
+	// nothing to commit
+	assert.NoError(t, cluster.CommitRevision(6, eventLog), "Empty revision should be committed")
+	assert.Equal(t, "Aptomi revision 5 for cluster cluster-us-east\n", gitLog(t, repo), "Nothing should be committed if there are no changes")
+
+	// inspect and list
+	status, err := helm.(plugin.DeploymentInspector).Inspect(ctx, "redis", helmParams, eventLog)
+	assert.NoError(t, err, "Helm component should be inspected")
+	assert.True(t, status.Exists && status.Matches, "Helm component should match its params")
+
+	changedParams := helmParams.MakeCopy()
+	changedParams["replicas"] = 5
+	status, err = helm.(plugin.DeploymentInspector).Inspect(ctx, "redis", changedParams, eventLog)
+	assert.NoError(t, err, "Helm component should be inspected")
+	assert.False(t, status.Matches, "Helm component should not match changed params")
+
+	list, err := raw.(plugin.DeploymentLister).List(ctx, eventLog)
+	assert.NoError(t, err, "Rendered components should be listed")
+	assert.Len(t, list, 2, "All rendered components should be listed")
+
+	// update and destroy
+	assert.NoError(t, helm.Update(ctx, "redis", changedParams, eventLog), "Helm component should be updated")
+	assert.NoError(t, raw.Destroy(ctx, "config", rawParams, eventLog), "Raw component should be destroyed")
+	_, err = os.Stat(filepath.Join(dir, "config"))
+	assert.True(t, os.IsNotExist(err), "Files of destroyed component should be deleted")
+
+	assert.NoError(t, cluster.CommitRevision(7, eventLog), "Revision should be committed")
+	assert.Equal(t, "Aptomi revision 7 for cluster cluster-us-east\nAptomi revision 5 for cluster cluster-us-east\n", gitLog(t, repo), "Commit should reference revision generation")
+}
+
+func TestGitOpsPush(t *testing.T) {
+	// working tree is cloned from the remote repo, so pushes go there
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	remote, err := ioutil.TempDir("", "aptomi-gitops-remote")
+	assert.NoError(t, err, "Temp dir should be created")
+	defer os.RemoveAll(remote) // nolint: errcheck
+	_, err = exec.Command("git", "init", "--quiet", "--bare", remote).Output()
+	assert.NoError(t, err, "Remote repo should be initialized")
+
+	repo, err := ioutil.TempDir("", "aptomi-gitops-test")
+	assert.NoError(t, err, "Temp dir should be created")
+	defer os.RemoveAll(repo) // nolint: errcheck
+	_, err = exec.Command("git", "clone", "--quiet", remote, repo).CombinedOutput()
+	assert.NoError(t, err, "Remote repo should be cloned")
+
+	cluster, raw, _ := newTestPlugins(t, repo)
+	cluster.Config.Push = true
+	ctx := context.Background()
+	eventLog := event.NewLog("test-gitops", false)
+	assert.NoError(t, raw.Create(ctx, "config", util.NestedParameterMap{"manifest": "kind: ConfigMap\n"}, eventLog), "Raw component should be rendered")
+
+	// push failure is reported, but changes stay committed
+	_, err = exec.Command("git", "-C", repo, "remote", "set-url", "origin", filepath.Join(remote, "missing")).Output()
+	assert.NoError(t, err, "Remote url should be changed")
+	assert.Error(t, cluster.CommitRevision(5, eventLog), "Push failure should be reported")
+	assert.Equal(t, "Aptomi revision 5 for cluster cluster-us-east\n", gitLog(t, repo), "Changes should be committed")
+
+	// commit is pushed along with the next revision, even if it has no changes
+	_, err = exec.Command("git", "-C", repo, "remote", "set-url", "origin", remote).Output()
+	assert.NoError(t, err, "Remote url should be changed")
+	assert.NoError(t, cluster.CommitRevision(6, eventLog), "Empty revision should be committed")
+	assert.Equal(t, "Aptomi revision 5 for cluster cluster-us-east\n", gitLog(t, remote), "Previous commit should be pushed")
+}
+
+func TestGitOpsInvalidConfig(t *testing.T) {
+	for _, clusterConfig := range []map[string]interface{}{
+		{"dir": "clusters/us-east"},
+		{"repo": "/tmp", "dir": "../outside"},
+		{"repo": "/nonexistent/repo"},
+	} {
+		clusterPlugin, err := New(&lang.Cluster{Metadata: lang.Metadata{Name: "cluster"}, Type: "gitops", Config: clusterConfig}, config.Plugins{})
+		assert.NoError(t, err, "GitOps cluster plugin should be created")
+		assert.Error(t, clusterPlugin.Validate(), "GitOps cluster should be invalid: %v", clusterConfig)
+	}
+}
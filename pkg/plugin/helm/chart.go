@@ -0,0 +1,310 @@
+package helm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/Aptomi/aptomi/pkg/event"
+	"github.com/Aptomi/aptomi/pkg/util"
+	"github.com/ghodss/yaml"
+	"io/ioutil"
+	"k8s.io/helm/pkg/repo"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	exactVersionRegexp = regexp.MustCompile(`^v?[0-9]+\.[0-9]+\.[0-9]+(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?$`)
+	digestRegexp       = regexp.MustCompile(`^[0-9a-f]{64}$`)
+)
+
+// chartInfo represents reference to the Helm chart from code params. Chart could be either fetched from the chart
+// repository or loaded from the local charts dir
+type chartInfo struct {
+	// Repo is an URL of the chart repository
+	Repo string
+
+	// Path is a path to the local chart directory or archive, relative to the charts dir
+	Path string
+
+	// Name is a name of the chart, it's mandatory for charts from repository
+	Name string
+
+	// Version is a version (or version constraint) of the chart from repository, latest version used if it's empty
+	Version string
+
+	// Digest is an expected SHA256 digest of the chart archive (optional)
+	Digest string
+}
+
+func getChartInfo(params util.NestedParameterMap) (*chartInfo, error) {
+	chart := &chartInfo{}
+	for key, value := range map[string]*string{
+		"chartRepo":    &chart.Repo,
+		"chartPath":    &chart.Path,
+		"chartName":    &chart.Name,
+		"chartVersion": &chart.Version,
+		"chartDigest":  &chart.Digest,
+	} {
+		if paramValue, exist := params[key]; exist {
+			strValue, ok := paramValue.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s should be a string, but got: %T", key, paramValue)
+			}
+			*value = strValue
+		}
+	}
+
+	if len(chart.Repo) > 0 && len(chart.Path) > 0 {
+		return nil, fmt.Errorf("chartRepo and chartPath can't be used together")
+	}
+	if len(chart.Repo) == 0 && len(chart.Path) == 0 {
+		return nil, fmt.Errorf("chartRepo is a mandatory parameter (or chartPath for local charts)")
+	}
+
+	if len(chart.Repo) > 0 {
+		if len(chart.Name) == 0 {
+			return nil, fmt.Errorf("chartName is a mandatory parameter")
+		}
+
+		// name and version are used in the path of the cached chart
+		err := validateChartPathElement("chartName", chart.Name)
+		if err != nil {
+			return nil, err
+		}
+		err = validateChartPathElement("chartVersion", chart.Version)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(chart.Path) > 0 {
+		if len(chart.Version) > 0 {
+			return nil, fmt.Errorf("chartVersion can't be used with local chart %s", chart.Path)
+		}
+		if len(chart.Name) == 0 {
+			chart.Name = strings.TrimSuffix(strings.TrimSuffix(filepath.Base(chart.Path), ".tgz"), ".tar.gz")
+		}
+	}
+
+	if len(chart.Digest) > 0 {
+		chart.Digest = strings.ToLower(strings.TrimPrefix(chart.Digest, "sha256:"))
+		if !digestRegexp.MatchString(chart.Digest) {
+			return nil, fmt.Errorf("chartDigest should be a hex-encoded SHA256 digest: %s", chart.Digest)
+		}
+	}
+
+	return chart, nil
+}
+
+// fetchChart returns path to the chart directory or archive, which could be loaded by Helm
+func (p *Plugin) fetchChart(ctx context.Context, chart *chartInfo, eventLog *event.Log) (string, error) {
+	if len(chart.Path) > 0 {
+		return p.resolveLocalChart(chart)
+	}
+
+	return p.fetchRepoChart(ctx, chart, eventLog)
+}
+
+// resolveLocalChart returns absolute path to the local chart, while making sure that it's inside charts dir
+func (p *Plugin) resolveLocalChart(chart *chartInfo) (string, error) {
+	if len(p.config.ChartsDir) == 0 {
+		return "", fmt.Errorf("can't use local chart %s, as charts dir isn't configured", chart.Path)
+	}
+
+	chartPath := filepath.Clean(chart.Path)
+	if filepath.IsAbs(chartPath) || chartPath == ".." || strings.HasPrefix(chartPath, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("local chart path should be relative to charts dir: %s", chart.Path)
+	}
+	chartPath = filepath.Join(p.config.ChartsDir, chartPath)
+
+	info, err := os.Stat(chartPath)
+	if err != nil {
+		return "", fmt.Errorf("error while looking for local chart %s: %s", chart.Path, err)
+	}
+
+	if len(chart.Digest) > 0 {
+		if info.IsDir() {
+			return "", fmt.Errorf("chartDigest can't be checked for local chart directory %s", chart.Path)
+		}
+
+		data, readErr := ioutil.ReadFile(chartPath)
+		if readErr != nil {
+			return "", fmt.Errorf("error while reading local chart %s: %s", chart.Path, readErr)
+		}
+		err = verifyDigest(data, chart.Digest)
+		if err != nil {
+			return "", fmt.Errorf("local chart %s can't be used: %s", chart.Path, err)
+		}
+	}
+
+	return chartPath, nil
+}
+
+// fetchRepoChart downloads chart from the repository into the cache dir. If chart version is pinned and chart is
+// already in cache, it's used without accessing repository
+func (p *Plugin) fetchRepoChart(ctx context.Context, chart *chartInfo, eventLog *event.Log) (string, error) {
+	repoURL, err := url.Parse(strings.TrimSuffix(chart.Repo, "/") + "/")
+	if err != nil || (repoURL.Scheme != "http" && repoURL.Scheme != "https") || len(repoURL.Host) == 0 {
+		return "", fmt.Errorf("chartRepo should be a valid http(s) URL: %s", chart.Repo)
+	}
+
+	repoHash := sha256.Sum256([]byte(repoURL.String()))
+	cacheDir := filepath.Join(p.chartsCacheDir(), hex.EncodeToString(repoHash[:])[:16])
+
+	// pinned version is used in the cache key as is, so chart gets saved under the same key it's looked up with
+	cacheVersion := ""
+	if exactVersionRegexp.MatchString(chart.Version) {
+		cacheVersion = chart.Version
+		cachedPath := filepath.Join(cacheDir, getChartArchiveName(chart.Name, cacheVersion))
+		data, readErr := ioutil.ReadFile(cachedPath)
+		if readErr == nil && verifyDigest(data, chart.Digest) == nil {
+			eventLog.WithFields(event.Fields{}).Debugf("Using cached chart %s", cachedPath)
+			return cachedPath, nil
+		}
+	}
+
+	getter, err := newChartGetter(repoURL, p.loadRepoCredentials(repoURL.Host))
+	if err != nil {
+		return "", err
+	}
+
+	indexURL, err := repoURL.Parse("index.yaml")
+	if err != nil {
+		return "", err
+	}
+	indexData, err := getter.Get(ctx, indexURL)
+	if err != nil {
+		return "", fmt.Errorf("error while getting index of chart repo %s: %s", repoURL.Host, err)
+	}
+
+	index := &repo.IndexFile{}
+	err = yaml.Unmarshal(indexData, index)
+	if err != nil {
+		return "", fmt.Errorf("error while parsing index of chart repo %s: %s", repoURL.Host, err)
+	}
+	if len(index.APIVersion) == 0 {
+		return "", fmt.Errorf("index of chart repo %s has no api version", repoURL.Host)
+	}
+	index.SortEntries()
+
+	chartVersion, err := index.Get(chart.Name, chart.Version)
+	if err != nil {
+		return "", fmt.Errorf("chart %s version '%s' not found in repo %s: %s", chart.Name, chart.Version, repoURL.Host, err)
+	}
+	if len(cacheVersion) == 0 {
+		// version comes from the repo index, so it should be checked before being used in the path
+		err = validateChartPathElement("version of chart "+chart.Name, chartVersion.Version)
+		if err != nil {
+			return "", fmt.Errorf("chart repo %s returned invalid version: %s", repoURL.Host, err)
+		}
+		cacheVersion = chartVersion.Version
+	}
+	if len(chartVersion.URLs) == 0 {
+		return "", fmt.Errorf("chart %s version %s has no downloadable URLs in repo %s", chart.Name, chartVersion.Version, repoURL.Host)
+	}
+
+	// chart URL could be relative to the repository
+	chartURL, err := repoURL.Parse(chartVersion.URLs[0])
+	if err != nil {
+		return "", fmt.Errorf("chart %s version %s has invalid URL in repo %s: %s", chart.Name, chartVersion.Version, repoURL.Host, err)
+	}
+
+	eventLog.WithFields(event.Fields{}).Debugf("Downloading chart %s version %s from %s", chart.Name, chartVersion.Version, chartURL)
+
+	data, err := getter.Get(ctx, chartURL)
+	if err != nil {
+		return "", fmt.Errorf("error while downloading chart: %s", err)
+	}
+
+	if len(chartVersion.Digest) > 0 {
+		err = verifyDigest(data, strings.ToLower(strings.TrimPrefix(chartVersion.Digest, "sha256:")))
+		if err != nil {
+			return "", fmt.Errorf("chart %s version %s from repo %s doesn't match repo index: %s", chart.Name, chartVersion.Version, repoURL.Host, err)
+		}
+	}
+	err = verifyDigest(data, chart.Digest)
+	if err != nil {
+		return "", fmt.Errorf("chart %s version %s from repo %s can't be used: %s", chart.Name, chartVersion.Version, repoURL.Host, err)
+	}
+
+	chartPath := filepath.Join(cacheDir, getChartArchiveName(chart.Name, cacheVersion))
+	err = writeFileAtomic(chartPath, data)
+	if err != nil {
+		return "", fmt.Errorf("error while saving downloaded chart to the cache: %s", err)
+	}
+
+	return chartPath, nil
+}
+
+// chartsCacheDir returns directory, where downloaded charts are cached
+func (p *Plugin) chartsCacheDir() string {
+	if len(p.config.ChartsCacheDir) > 0 {
+		return p.config.ChartsCacheDir
+	}
+
+	return filepath.Join(os.TempDir(), "aptomi-charts")
+}
+
+// secretsUser returns name under which chart repo credentials are stored in the secret loader
+func (p *Plugin) secretsUser() string {
+	if len(p.config.SecretsUser) > 0 {
+		return p.config.SecretsUser
+	}
+
+	return "helm"
+}
+
+// validateChartPathElement makes sure that a given value could be safely used as a part of the file name
+func validateChartPathElement(name, value string) error {
+	if strings.ContainsAny(value, `/\`) || strings.Contains(value, "..") {
+		return fmt.Errorf("%s can't contain '/', '\\' or '..': %s", name, value)
+	}
+	return nil
+}
+
+func getChartArchiveName(name, version string) string {
+	return fmt.Sprintf("%s-%s.tgz", name, version)
+}
+
+// verifyDigest checks that SHA256 digest of the data matches expected one, if it's not empty
+func verifyDigest(data []byte, expected string) error {
+	if len(expected) == 0 {
+		return nil
+	}
+
+	actualHash := sha256.Sum256(data)
+	actual := hex.EncodeToString(actualHash[:])
+	if actual != expected {
+		return fmt.Errorf("digest mismatch (expected %s, got %s)", expected, actual)
+	}
+
+	return nil
+}
+
+// writeFileAtomic writes file through the temp file in the same dir, so concurrent readers never see partial file
+func writeFileAtomic(path string, data []byte) error {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+
+	tmpFile, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name()) // nolint: errcheck
+
+	_, err = tmpFile.Write(data)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmpFile.Name(), path)
+}
@@ -0,0 +1,215 @@
+package helm
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/Aptomi/aptomi/pkg/config"
+	"github.com/Aptomi/aptomi/pkg/event"
+	"github.com/Aptomi/aptomi/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+type testSecretLoader map[string]map[string]string
+
+func (loader testSecretLoader) LoadSecretsByUserName(userName string) map[string]string {
+	return loader[userName]
+}
+
+// makeTestChartArchive returns chart archive with a given name and version
+func makeTestChartArchive(t *testing.T, name, version string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	content := []byte(fmt.Sprintf("name: %s\nversion: %s\n", name, version))
+	assert.NoError(t, tarWriter.WriteHeader(&tar.Header{Name: name + "/Chart.yaml", Mode: 0644, Size: int64(len(content))}), "Tar header should be written")
+	_, err := tarWriter.Write(content)
+	assert.NoError(t, err, "Tar content should be written")
+
+	assert.NoError(t, tarWriter.Close(), "Tar should be closed")
+	assert.NoError(t, gzipWriter.Close(), "Gzip should be closed")
+
+	return buf.Bytes()
+}
+
+func digestOf(data []byte) string {
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+// newTestChartRepo starts chart repo with basic auth, which serves a single chart in two versions
+func newTestChartRepo(t *testing.T, archives map[string][]byte, requests *int32) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		atomic.AddInt32(requests, 1)
+
+		username, password, ok := request.BasicAuth()
+		if !ok || username != "user" || password != "secret" {
+			writer.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if request.URL.Path == "/charts/index.yaml" {
+			fmt.Fprintf(writer, "apiVersion: v1\nentries:\n  test:\n") // nolint: errcheck
+			for _, version := range []string{"0.2.0", "0.1.0"} {
+				fmt.Fprintf(writer, "  - name: test\n    version: %s\n    digest: %s\n    urls:\n    - test-%s.tgz\n", version, digestOf(archives[version]), version) // nolint: errcheck
+			}
+			return
+		}
+
+		for version, archive := range archives {
+			if request.URL.Path == "/charts/test-"+version+".tgz" {
+				_, _ = writer.Write(archive)
+				return
+			}
+		}
+		writer.WriteHeader(http.StatusNotFound)
+	}))
+}
+
+func TestChartInfo(t *testing.T) {
+	chart, err := getChartInfo(util.NestedParameterMap{"chartRepo": "https://charts.example.com", "chartName": "test", "chartVersion": "1.0.0", "chartDigest": "sha256:" + digestOf([]byte("test"))})
+	assert.NoError(t, err, "Chart from repo should be valid")
+	assert.Equal(t, digestOf([]byte("test")), chart.Digest, "Digest should be normalized")
+
+	chart, err = getChartInfo(util.NestedParameterMap{"chartPath": "charts/test-1.0.0.tgz"})
+	assert.NoError(t, err, "Local chart should be valid")
+	assert.Equal(t, "test-1.0.0", chart.Name, "Local chart name should be taken from the path")
+
+	for _, params := range []util.NestedParameterMap{
+		{},
+		{"chartRepo": "https://charts.example.com"},
+		{"chartRepo": "https://charts.example.com", "chartPath": "test", "chartName": "test"},
+		{"chartPath": "test", "chartVersion": "1.0.0"},
+		{"chartRepo": "https://charts.example.com", "chartName": "test", "chartDigest": "md5:123"},
+		{"chartRepo": "https://charts.example.com", "chartName": "test", "chartVersion": 1},
+		{"chartRepo": "https://charts.example.com", "chartName": "../test"},
+		{"chartRepo": "https://charts.example.com", "chartName": `..\test`},
+		{"chartRepo": "https://charts.example.com", "chartName": "test", "chartVersion": "1.0.0/../../x"},
+		{"chartRepo": "https://charts.example.com", "chartName": "test", "chartVersion": ".."},
+	} {
+		_, err = getChartInfo(params)
+		assert.Error(t, err, "Chart params should be invalid: %v", params)
+	}
+}
+
+func TestFetchRepoChart(t *testing.T) {
+	archives := map[string][]byte{
+		"0.1.0": makeTestChartArchive(t, "test", "0.1.0"),
+		"0.2.0": makeTestChartArchive(t, "test", "0.2.0"),
+	}
+	var requests int32
+	server := newTestChartRepo(t, archives, &requests)
+	defer server.Close()
+
+	cacheDir, err := ioutil.TempDir("", "aptomi-helm-cache")
+	assert.NoError(t, err, "Temp dir should be created")
+	defer os.RemoveAll(cacheDir) // nolint: errcheck
+
+	p := newTestPlugin()
+	p.config = config.Helm{ChartsCacheDir: cacheDir}
+	eventLog := event.NewLog("test-helm", false)
+
+	// without credentials
+	_, err = p.fetchChart(context.Background(), &chartInfo{Repo: server.URL + "/charts", Name: "test"}, eventLog)
+	assert.Error(t, err, "Chart should not be fetched without credentials")
+
+	// with credentials loaded from secrets, latest version
+	host := server.Listener.Addr().String()
+	p.secretLoader = testSecretLoader{"helm": {host + "/username": "user", host + "/password": "secret"}}
+
+	chartPath, err := p.fetchChart(context.Background(), &chartInfo{Repo: server.URL + "/charts", Name: "test"}, eventLog)
+	assert.NoError(t, err, "Chart should be fetched with credentials")
+	assert.Equal(t, "test-0.2.0.tgz", filepath.Base(chartPath), "Latest chart version should be fetched")
+	data, err := ioutil.ReadFile(chartPath)
+	assert.NoError(t, err, "Fetched chart should be saved")
+	assert.Equal(t, archives["0.2.0"], data, "Fetched chart should be saved as is")
+
+	// pinned version with digest, fetched once and then taken from cache
+	pinned := &chartInfo{Repo: server.URL + "/charts", Name: "test", Version: "0.1.0", Digest: digestOf(archives["0.1.0"])}
+	chartPath, err = p.fetchChart(context.Background(), pinned, eventLog)
+	assert.NoError(t, err, "Pinned chart version should be fetched")
+	assert.Equal(t, "test-0.1.0.tgz", filepath.Base(chartPath), "Pinned chart version should be fetched")
+
+	requestsBefore := atomic.LoadInt32(&requests)
+	cachedPath, err := p.fetchChart(context.Background(), pinned, eventLog)
+	assert.NoError(t, err, "Pinned chart version should be taken from cache")
+	assert.Equal(t, chartPath, cachedPath, "Pinned chart version should be taken from cache")
+	assert.Equal(t, requestsBefore, atomic.LoadInt32(&requests), "Repo should not be accessed for cached chart")
+
+	// pinned version, which differs from the version in the repo index, is cached under the pinned version
+	pinned = &chartInfo{Repo: server.URL + "/charts", Name: "test", Version: "v0.1.0"}
+	chartPath, err = p.fetchChart(context.Background(), pinned, eventLog)
+	assert.NoError(t, err, "Pinned chart version should be fetched")
+	assert.Equal(t, "test-v0.1.0.tgz", filepath.Base(chartPath), "Chart should be cached under pinned version")
+
+	requestsBefore = atomic.LoadInt32(&requests)
+	cachedPath, err = p.fetchChart(context.Background(), pinned, eventLog)
+	assert.NoError(t, err, "Pinned chart version should be taken from cache")
+	assert.Equal(t, chartPath, cachedPath, "Pinned chart version should be taken from cache")
+	assert.Equal(t, requestsBefore, atomic.LoadInt32(&requests), "Repo should not be accessed for cached chart")
+
+	// digest mismatch
+	_, err = p.fetchChart(context.Background(), &chartInfo{Repo: server.URL + "/charts", Name: "test", Version: "0.2.0", Digest: digestOf(archives["0.1.0"])}, eventLog)
+	assert.Error(t, err, "Chart with different digest should not be used")
+
+	// missing version
+	_, err = p.fetchChart(context.Background(), &chartInfo{Repo: server.URL + "/charts", Name: "test", Version: "1.0.0"}, eventLog)
+	assert.Error(t, err, "Missing chart version should not be fetched")
+
+	// context is done
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = p.fetchChart(ctx, &chartInfo{Repo: server.URL + "/charts", Name: "test"}, eventLog)
+	assert.Error(t, err, "Chart should not be fetched once context is done")
+}
+
+func TestResolveLocalChart(t *testing.T) {
+	chartsDir, err := ioutil.TempDir("", "aptomi-helm-charts")
+	assert.NoError(t, err, "Temp dir should be created")
+	defer os.RemoveAll(chartsDir) // nolint: errcheck
+
+	archive := makeTestChartArchive(t, "test", "0.1.0")
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(chartsDir, "test-0.1.0.tgz"), archive, 0644), "Chart archive should be written")
+	assert.NoError(t, os.MkdirAll(filepath.Join(chartsDir, "test"), 0755), "Chart dir should be created")
+
+	p := newTestPlugin()
+	eventLog := event.NewLog("test-helm", false)
+
+	_, err = p.fetchChart(context.Background(), &chartInfo{Path: "test"}, eventLog)
+	assert.Error(t, err, "Local charts should be disabled without charts dir")
+
+	p.config = config.Helm{ChartsDir: chartsDir}
+
+	chartPath, err := p.fetchChart(context.Background(), &chartInfo{Path: "test"}, eventLog)
+	assert.NoError(t, err, "Local chart dir should be resolved")
+	assert.Equal(t, filepath.Join(chartsDir, "test"), chartPath, "Local chart dir should be resolved")
+
+	chartPath, err = p.fetchChart(context.Background(), &chartInfo{Path: "test-0.1.0.tgz", Digest: digestOf(archive)}, eventLog)
+	assert.NoError(t, err, "Local chart archive should be resolved")
+	assert.Equal(t, filepath.Join(chartsDir, "test-0.1.0.tgz"), chartPath, "Local chart archive should be resolved")
+
+	for _, chart := range []*chartInfo{
+		{Path: "missing"},
+		{Path: "../test"},
+		{Path: "/etc"},
+		{Path: "test", Digest: digestOf(archive)},
+		{Path: "test-0.1.0.tgz", Digest: digestOf([]byte("test"))},
+	} {
+		_, err = p.fetchChart(context.Background(), chart, eventLog)
+		assert.Error(t, err, "Local chart should not be resolved: %v", chart)
+	}
+}
@@ -5,6 +5,8 @@ import "fmt"
 // ClusterConfig represents Kubernetes cluster configuration specific for Helm plugin
 type ClusterConfig struct {
 	TillerNamespace string `yaml:",omitempty"`
+	Tillerless      *bool  `yaml:",omitempty"`
+	DataNamespace   string `yaml:",omitempty"`
 }
 
 func (p *Plugin) parseClusterConfig() error {
@@ -19,5 +21,18 @@ func (p *Plugin) parseClusterConfig() error {
 		p.tillerNamespace = clusterConfig.TillerNamespace
 	}
 
+	p.tillerless = p.config.Tillerless
+	if clusterConfig.Tillerless != nil {
+		p.tillerless = *clusterConfig.Tillerless
+	}
+
+	p.dataNamespace = "aptomi"
+	if len(p.config.DataNamespace) > 0 {
+		p.dataNamespace = p.config.DataNamespace
+	}
+	if len(clusterConfig.DataNamespace) > 0 {
+		p.dataNamespace = clusterConfig.DataNamespace
+	}
+
 	return nil
 }
@@ -1,2 +1,6 @@
 // Package helm implements support for Helm plugin, which can deploy Helm charts onto k8s clusters via Helm API.
+//
+// In tillerless mode (enabled by "tillerless: true" in cluster config or in Helm plugin config), charts are rendered
+// locally and rendered manifests are applied directly, the same way as in k8sraw plugin. State of the releases is
+// stored in config maps in the data namespace.
 package helm
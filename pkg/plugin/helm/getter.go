@@ -1,72 +1,111 @@
 package helm
 
-// Copied from https://github.com/kubernetes/helm/blob/release-2.6/pkg/getter/httpgetter.go
-
 import (
-	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
-	"io"
-	"k8s.io/helm/pkg/getter"
+	"io/ioutil"
 	"net/http"
-
-	"k8s.io/helm/pkg/tlsutil"
-	"k8s.io/helm/pkg/urlutil"
+	"net/url"
 )
 
-// httpGetter is the default HTTP(/S) backend handler
-type httpGetter struct {
-	client *http.Client
-}
+// repoCredentials represents credentials for the private chart repository, which are loaded from the secret loader
+type repoCredentials struct {
+	// Username and Password are used for basic auth
+	Username string
+	Password string
 
-// Get performs a Get from repo.Getter and returns the body.
-func (g *httpGetter) Get(href string) (*bytes.Buffer, error) {
-	buf := bytes.NewBuffer(nil)
+	// Cert and Key are PEM-encoded client certificate and key used for TLS client auth
+	Cert string
+	Key  string
 
-	resp, err := g.client.Get(href)
-	if err != nil {
-		return buf, err
+	// CA is a PEM-encoded CA bundle used to verify repository server certificate
+	CA string
+}
+
+// loadRepoCredentials loads credentials for the chart repository with a given host. Secrets are keyed by host and
+// credential name, e.g. "charts.example.com/username"
+func (p *Plugin) loadRepoCredentials(host string) *repoCredentials {
+	if p.secretLoader == nil {
+		return &repoCredentials{}
 	}
-	if resp.StatusCode != 200 {
-		return buf, fmt.Errorf("Failed to fetch %s : %s", href, resp.Status)
+
+	secrets := p.secretLoader.LoadSecretsByUserName(p.secretsUser())
+	return &repoCredentials{
+		Username: secrets[host+"/username"],
+		Password: secrets[host+"/password"],
+		Cert:     secrets[host+"/cert"],
+		Key:      secrets[host+"/key"],
+		CA:       secrets[host+"/ca"],
 	}
+}
 
-	_, err = io.Copy(buf, resp.Body)
-	resp.Body.Close() // nolint: errcheck,gas
-	return buf, err
+// chartGetter downloads index and charts from the chart repository using its credentials. Basic auth is sent only to
+// the repository host, so it doesn't leak if index points to charts hosted elsewhere
+type chartGetter struct {
+	repoURL *url.URL
+	creds   *repoCredentials
+	client  *http.Client
 }
 
-// newHTTPGetter constructs a valid http/https client as Getter
-func newHTTPGetter(URL, CertFile, KeyFile, CAFile string) (getter.Getter, error) {
-	var client httpGetter
-	if CertFile != "" && KeyFile != "" {
-		tlsConf, err := tlsutil.NewClientTLS(CertFile, KeyFile, CAFile)
-		if err != nil {
-			return nil, fmt.Errorf("can't create TLS config for client: %s", err.Error())
-		}
-		tlsConf.BuildNameToCertificate()
+func newChartGetter(repoURL *url.URL, creds *repoCredentials) (*chartGetter, error) {
+	getter := &chartGetter{
+		repoURL: repoURL,
+		creds:   creds,
+		client:  http.DefaultClient,
+	}
 
-		sni, err := urlutil.ExtractHostname(URL)
+	if len(creds.Cert) == 0 && len(creds.Key) == 0 && len(creds.CA) == 0 {
+		return getter, nil
+	}
+
+	tlsConfig := &tls.Config{}
+	if len(creds.Cert) > 0 || len(creds.Key) > 0 {
+		cert, err := tls.X509KeyPair([]byte(creds.Cert), []byte(creds.Key))
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("can't load client certificate for chart repo %s: %s", repoURL.Host, err)
 		}
-		tlsConf.ServerName = sni
-
-		client.client = &http.Client{
-			Transport: &http.Transport{
-				TLSClientConfig: tlsConf,
-			},
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	if len(creds.CA) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(creds.CA)) {
+			return nil, fmt.Errorf("can't load CA bundle for chart repo %s", repoURL.Host)
 		}
-	} else {
-		client.client = http.DefaultClient
+		tlsConfig.RootCAs = pool
 	}
-	return &client, nil
-}
 
-func newGetterProviders() getter.Providers {
-	return getter.Providers{
-		{
-			Schemes: []string{"http", "https"},
-			New:     newHTTPGetter,
+	getter.client = &http.Client{
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsConfig,
 		},
 	}
+
+	return getter, nil
+}
+
+// Get downloads a given URL and returns its content, download gets interrupted once context is done
+func (g *chartGetter) Get(ctx context.Context, href *url.URL) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, href.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if len(g.creds.Username) > 0 && href.Host == g.repoURL.Host {
+		req.SetBasicAuth(g.creds.Username, g.creds.Password)
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %s: %s", href, resp.Status)
+	}
+
+	return ioutil.ReadAll(resp.Body)
 }
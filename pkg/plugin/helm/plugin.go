@@ -1,18 +1,21 @@
 package helm
 
 import (
+	"context"
 	"fmt"
 	"github.com/Aptomi/aptomi/pkg/config"
+	"github.com/Aptomi/aptomi/pkg/engine/resolve"
 	"github.com/Aptomi/aptomi/pkg/event"
+	"github.com/Aptomi/aptomi/pkg/external/secrets"
 	"github.com/Aptomi/aptomi/pkg/lang"
 	"github.com/Aptomi/aptomi/pkg/plugin"
 	"github.com/Aptomi/aptomi/pkg/plugin/k8s"
 	"github.com/Aptomi/aptomi/pkg/util"
 	"github.com/Aptomi/aptomi/pkg/util/sync"
-	"github.com/pmezard/go-difflib/difflib"
 	"gopkg.in/yaml.v2"
 	"k8s.io/helm/pkg/helm"
 	"k8s.io/helm/pkg/kube"
+	"k8s.io/helm/pkg/proto/hapi/release"
 	"strings"
 )
 
@@ -21,25 +24,33 @@ type Plugin struct {
 	once            sync.Init
 	cluster         *lang.Cluster
 	config          config.Helm
+	secretLoader    secrets.SecretLoader
 	kube            *k8s.Plugin
 	tillerNamespace string       // namespace for tiller
 	tillerTunnel    *kube.Tunnel // tunnel for accessing tiller
 	tillerHost      string       // local proxy address when connection established
+	tillerless      bool         // render charts locally and apply manifests directly instead of using tiller
+	dataNamespace   string       // namespace for storing state of releases in tillerless mode
 }
 
 var _ plugin.CodePlugin = &Plugin{}
+var _ plugin.DeploymentInspector = &Plugin{}
+var _ plugin.DeploymentLister = &Plugin{}
+var _ plugin.IngressPolicyManager = &Plugin{}
 
-// New returns new instance of the Helm code plugin for specified Kubernetes cluster plugin and plugins config
-func New(clusterPlugin plugin.ClusterPlugin, cfg config.Plugins) (plugin.CodePlugin, error) {
+// New returns new instance of the Helm code plugin for specified Kubernetes cluster plugin and plugins config. Credentials
+// for private chart repositories are loaded using a given secret loader
+func New(clusterPlugin plugin.ClusterPlugin, cfg config.Plugins, secretLoader secrets.SecretLoader) (plugin.CodePlugin, error) {
 	kubePlugin, ok := clusterPlugin.(*k8s.Plugin)
 	if !ok {
 		return nil, fmt.Errorf("k8s cluster plugin expected for helm code plugin creation but received: %T", clusterPlugin)
 	}
 
 	return &Plugin{
-		config:  cfg.Helm,
-		kube:    kubePlugin,
-		cluster: kubePlugin.Cluster,
+		config:       cfg.Helm,
+		secretLoader: secretLoader,
+		kube:         kubePlugin,
+		cluster:      kubePlugin.Cluster,
 	}, nil
 }
 
@@ -55,6 +66,15 @@ func (p *Plugin) init(eventLog *event.Log) error {
 			return err
 		}
 
+		if p.tillerless {
+			kubeClient, clientErr := p.kube.NewClient()
+			if clientErr != nil {
+				return clientErr
+			}
+
+			return p.kube.EnsureNamespace(kubeClient, p.dataNamespace)
+		}
+
 		// todo(slukjanov): we should probably verify tunnel each time we need it
 		return p.ensureTillerTunnel(eventLog)
 	})
@@ -70,21 +90,29 @@ func (p *Plugin) Cleanup() error {
 }
 
 // Create implements creation of a new component instance in the cloud by deploying a Helm chart
-func (p *Plugin) Create(deployName string, params util.NestedParameterMap, eventLog *event.Log) error {
-	return p.createOrUpdate(deployName, params, eventLog, true)
+func (p *Plugin) Create(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) error {
+	return sync.RunWithContext(ctx, func() error {
+		return p.createOrUpdate(ctx, deployName, params, eventLog, true)
+	})
 }
 
 // Update implements update of an existing component instance in the cloud by updating parameters of a helm chart
-func (p *Plugin) Update(deployName string, params util.NestedParameterMap, eventLog *event.Log) error {
-	return p.createOrUpdate(deployName, params, eventLog, false)
+func (p *Plugin) Update(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) error {
+	return sync.RunWithContext(ctx, func() error {
+		return p.createOrUpdate(ctx, deployName, params, eventLog, false)
+	})
 }
 
-func (p *Plugin) createOrUpdate(deployName string, params util.NestedParameterMap, eventLog *event.Log, create bool) error {
+func (p *Plugin) createOrUpdate(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log, create bool) error {
 	err := p.init(eventLog)
 	if err != nil {
 		return err
 	}
 
+	if p.tillerless {
+		return p.createOrUpdateTillerless(ctx, deployName, params, eventLog)
+	}
+
 	kubeClient, err := p.kube.NewClient()
 	if err != nil {
 		return err
@@ -96,17 +124,18 @@ func (p *Plugin) createOrUpdate(deployName string, params util.NestedParameterMa
 	}
 
 	releaseName := getReleaseName(deployName)
-	chartRepo, chartName, chartVersion, err := getHelmReleaseInfo(params)
+	chartRef, err := getChartInfo(params)
 	if err != nil {
 		return err
 	}
+	chartName := chartRef.Name
 
 	helmClient, err := p.newClient()
 	if err != nil {
 		return err
 	}
 
-	chartPath, err := p.fetchChart(chartRepo, chartName, chartVersion)
+	chartPath, err := p.fetchChart(ctx, chartRef, eventLog)
 	if err != nil {
 		return err
 	}
@@ -121,6 +150,11 @@ func (p *Plugin) createOrUpdate(deployName string, params util.NestedParameterMa
 		return fmt.Errorf("error while looking for Helm release %s: %s", releaseName, err)
 	}
 
+	// release shouldn't be changed once context is done, Tiller calls themselves are limited by the plugin timeout
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
 	cluster := p.cluster
 	if create {
 		if currRelease != nil {
@@ -172,23 +206,11 @@ func (p *Plugin) createOrUpdate(deployName string, params util.NestedParameterMa
 		return err
 	}
 
-	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
-		A:        difflib.SplitLines(currRelease.Release.Manifest),
-		B:        difflib.SplitLines(newRelease.Release.Manifest),
-		FromFile: "Previous",
-		ToFile:   "Current",
-		Context:  3,
-	})
+	diff, err := getManifestDiff(currRelease.Release.Manifest, newRelease.Release.Manifest)
 	if err != nil {
 		return fmt.Errorf("error while calculating diff between chart manifests for Helm release '%s', chart '%s', cluster: '%s'", releaseName, chartName, cluster.Name)
 	}
 
-	if len(diff) == 0 {
-		diff = "without changes"
-	} else {
-		diff = "with diff: \n\n" + diff
-	}
-
 	eventLog.WithFields(event.Fields{
 		"release": releaseName,
 		"chart":   chartName,
@@ -200,12 +222,36 @@ func (p *Plugin) createOrUpdate(deployName string, params util.NestedParameterMa
 }
 
 // Destroy implements destruction of an existing component instance in the cloud by running "helm delete" on the corresponding helm chart
-func (p *Plugin) Destroy(deployName string, params util.NestedParameterMap, eventLog *event.Log) error {
+func (p *Plugin) Destroy(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) error {
+	return sync.RunWithContext(ctx, func() error {
+		return p.destroy(ctx, deployName, params, eventLog)
+	})
+}
+
+func (p *Plugin) destroy(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) error {
 	err := p.init(eventLog)
 	if err != nil {
 		return err
 	}
 
+	if p.tillerless {
+		err = p.destroyTillerless(ctx, deployName, eventLog)
+	} else {
+		err = p.destroyWithTiller(ctx, deployName, eventLog)
+	}
+	if err != nil {
+		return err
+	}
+
+	kubeClient, err := p.kube.NewClient()
+	if err != nil {
+		return err
+	}
+
+	return p.kube.DeleteIngressPolicy(kubeClient, deployName, eventLog)
+}
+
+func (p *Plugin) destroyWithTiller(ctx context.Context, deployName string, eventLog *event.Log) error {
 	releaseName := getReleaseName(deployName)
 
 	helmClient, err := p.newClient()
@@ -213,6 +259,10 @@ func (p *Plugin) Destroy(deployName string, params util.NestedParameterMap, even
 		return err
 	}
 
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
 	eventLog.WithFields(event.Fields{
 		"release": releaseName,
 	}).Infof("Deleting Helm release '%s'", releaseName)
@@ -226,12 +276,26 @@ func (p *Plugin) Destroy(deployName string, params util.NestedParameterMap, even
 }
 
 // Endpoints returns map from port type to url for all services of the current chart
-func (p *Plugin) Endpoints(deployName string, params util.NestedParameterMap, eventLog *event.Log) (map[string]string, error) {
+func (p *Plugin) Endpoints(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) (map[string]string, error) {
+	var result map[string]string
+	err := sync.RunWithContext(ctx, func() error {
+		var err error
+		result, err = p.endpoints(deployName, params, eventLog)
+		return err
+	})
+	return result, err
+}
+
+func (p *Plugin) endpoints(deployName string, params util.NestedParameterMap, eventLog *event.Log) (map[string]string, error) {
 	err := p.init(eventLog)
 	if err != nil {
 		return nil, err
 	}
 
+	if p.tillerless {
+		return p.endpointsTillerless(deployName, eventLog)
+	}
+
 	helmClient, err := p.newClient()
 	if err != nil {
 		return nil, err
@@ -248,12 +312,26 @@ func (p *Plugin) Endpoints(deployName string, params util.NestedParameterMap, ev
 }
 
 // Resources returns list of all resources (like services, config maps, etc.) into the cluster by specified component instance
-func (p *Plugin) Resources(deployName string, params util.NestedParameterMap, eventLog *event.Log) (plugin.Resources, error) {
+func (p *Plugin) Resources(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) (plugin.Resources, error) {
+	var result plugin.Resources
+	err := sync.RunWithContext(ctx, func() error {
+		var err error
+		result, err = p.resources(deployName, params, eventLog)
+		return err
+	})
+	return result, err
+}
+
+func (p *Plugin) resources(deployName string, params util.NestedParameterMap, eventLog *event.Log) (plugin.Resources, error) {
 	err := p.init(eventLog)
 	if err != nil {
 		return nil, err
 	}
 
+	if p.tillerless {
+		return p.resourcesTillerless(deployName, eventLog)
+	}
+
 	helmClient, err := p.newClient()
 	if err != nil {
 		return nil, err
@@ -268,3 +346,135 @@ func (p *Plugin) Resources(deployName string, params util.NestedParameterMap, ev
 
 	return p.kube.ResourcesForManifest(deployName, currRelease.Release.Manifest, eventLog)
 }
+
+// Inspect checks that Helm release for the specified component instance exists, deployed and has the same values
+func (p *Plugin) Inspect(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) (*plugin.DeploymentStatus, error) {
+	var result *plugin.DeploymentStatus
+	err := sync.RunWithContext(ctx, func() error {
+		var err error
+		result, err = p.inspect(deployName, params, eventLog)
+		return err
+	})
+	return result, err
+}
+
+func (p *Plugin) inspect(deployName string, params util.NestedParameterMap, eventLog *event.Log) (*plugin.DeploymentStatus, error) {
+	err := p.init(eventLog)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.tillerless {
+		return p.inspectTillerless(deployName, params)
+	}
+
+	helmClient, err := p.newClient()
+	if err != nil {
+		return nil, err
+	}
+
+	releaseName := getReleaseName(deployName)
+
+	currRelease, err := helmClient.ReleaseContent(releaseName)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return &plugin.DeploymentStatus{
+				Message: fmt.Sprintf("Helm release '%s' not found", releaseName),
+			}, nil
+		}
+		return nil, fmt.Errorf("error while looking for Helm release %s: %s", releaseName, err)
+	}
+
+	statusCode := currRelease.Release.GetInfo().GetStatus().GetCode()
+	if statusCode != release.Status_DEPLOYED {
+		return &plugin.DeploymentStatus{
+			Message: fmt.Sprintf("Helm release '%s' is in status %s", releaseName, statusCode),
+		}, nil
+	}
+
+	helmParams, err := yaml.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+
+	if currRelease.Release.GetConfig().GetRaw() != string(helmParams) {
+		return &plugin.DeploymentStatus{
+			Exists:  true,
+			Message: fmt.Sprintf("Helm release '%s' values differ from the code params", releaseName),
+		}, nil
+	}
+
+	return &plugin.DeploymentStatus{Exists: true, Matches: true}, nil
+}
+
+// List returns all Helm releases in the cluster namespace, which have been created by Aptomi
+func (p *Plugin) List(ctx context.Context, eventLog *event.Log) (map[string]util.NestedParameterMap, error) {
+	var result map[string]util.NestedParameterMap
+	err := sync.RunWithContext(ctx, func() error {
+		var err error
+		result, err = p.list(eventLog)
+		return err
+	})
+	return result, err
+}
+
+func (p *Plugin) list(eventLog *event.Log) (map[string]util.NestedParameterMap, error) {
+	err := p.init(eventLog)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.tillerless {
+		return p.listTillerless()
+	}
+
+	helmClient, err := p.newClient()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]util.NestedParameterMap)
+	offset := ""
+	for {
+		resp, err := helmClient.ListReleases(
+			helm.ReleaseListNamespace(p.kube.Namespace),
+			helm.ReleaseListStatuses([]release.Status_Code{release.Status_DEPLOYED, release.Status_FAILED}),
+			helm.ReleaseListOffset(offset),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error while listing Helm releases: %s", err)
+		}
+
+		for _, rel := range resp.GetReleases() {
+			// release name is the same as deploy name
+			if resolve.IsDeployName(rel.GetName()) {
+				result[rel.GetName()] = util.NestedParameterMap{}
+			}
+		}
+
+		offset = resp.GetNext()
+		if len(offset) == 0 {
+			break
+		}
+	}
+
+	return result, nil
+}
+
+// ApplyIngressPolicy restricts ingress traffic to the pods of the Helm release, which are expected to be labeled with
+// the release name
+func (p *Plugin) ApplyIngressPolicy(ctx context.Context, deployName string, policy *plugin.IngressPolicy, eventLog *event.Log) error {
+	return sync.RunWithContext(ctx, func() error {
+		err := p.init(eventLog)
+		if err != nil {
+			return err
+		}
+
+		kubeClient, err := p.kube.NewClient()
+		if err != nil {
+			return err
+		}
+
+		return p.kube.ApplyIngressPolicy(kubeClient, deployName, policy, eventLog)
+	})
+}
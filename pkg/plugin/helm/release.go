@@ -0,0 +1,162 @@
+package helm
+
+import (
+	"fmt"
+	"github.com/Aptomi/aptomi/pkg/engine/resolve"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	api "k8s.io/client-go/pkg/api/v1"
+	"strconv"
+	"strings"
+)
+
+const (
+	releaseStatusDeployed = "deployed"
+	releaseStatusFailed   = "failed"
+)
+
+var (
+	configMapNameReplacer = strings.NewReplacer("#", "-", "_", "-")
+)
+
+// releaseState represents state of the Helm release deployed in tillerless mode. It's stored in a config map in the
+// data namespace, the same way Tiller stores releases
+type releaseState struct {
+	Namespace    string
+	ChartRepo    string
+	ChartPath    string
+	ChartName    string
+	ChartVersion string
+	Values       string
+	Manifest     string
+	Revision     int
+	Status       string
+}
+
+func (p *Plugin) getReleaseConfigMapName(releaseName string) string {
+	return strings.ToLower(configMapNameReplacer.Replace(fmt.Sprintf("aptomi-helm-%s-%s", p.cluster.Name, releaseName)))
+}
+
+func (p *Plugin) storeRelease(client kubernetes.Interface, releaseName string, state *releaseState) error {
+	name := p.getReleaseConfigMapName(releaseName)
+	data := map[string]string{
+		"namespace":    state.Namespace,
+		"chartRepo":    state.ChartRepo,
+		"chartPath":    state.ChartPath,
+		"chartName":    state.ChartName,
+		"chartVersion": state.ChartVersion,
+		"values":       state.Values,
+		"manifest":     state.Manifest,
+		"revision":     strconv.Itoa(state.Revision),
+		"status":       state.Status,
+	}
+
+	cm, err := client.CoreV1().ConfigMaps(p.dataNamespace).Get(name, meta.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			cm = &api.ConfigMap{
+				ObjectMeta: meta.ObjectMeta{
+					Name: name,
+				},
+				Data: data,
+			}
+
+			_, err = client.CoreV1().ConfigMaps(p.dataNamespace).Create(cm)
+		}
+
+		return err
+	}
+
+	cm.Data = data
+
+	_, err = client.CoreV1().ConfigMaps(p.dataNamespace).Update(cm)
+
+	return err
+}
+
+// findRelease returns stored state of the release, while not treating missing release as an error
+func (p *Plugin) findRelease(client kubernetes.Interface, releaseName string) (*releaseState, error) {
+	cm, err := client.CoreV1().ConfigMaps(p.dataNamespace).Get(p.getReleaseConfigMapName(releaseName), meta.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return releaseStateFromConfigMap(cm)
+}
+
+// loadRelease returns stored state of the release, while treating missing release as an error
+func (p *Plugin) loadRelease(client kubernetes.Interface, releaseName string) (*releaseState, error) {
+	state, err := p.findRelease(client, releaseName)
+	if err != nil {
+		return nil, fmt.Errorf("error while looking for Helm release %s: %s", releaseName, err)
+	}
+	if state == nil {
+		return nil, fmt.Errorf("can't find Helm release %s (should be stored in configmap %s/%s)", releaseName, p.dataNamespace, p.getReleaseConfigMapName(releaseName))
+	}
+
+	return state, nil
+}
+
+// listReleases returns a map from release name to stored state for all releases in the cluster
+func (p *Plugin) listReleases(client kubernetes.Interface) (map[string]*releaseState, error) {
+	prefix := p.getReleaseConfigMapName("")
+
+	cms, err := client.CoreV1().ConfigMaps(p.dataNamespace).List(meta.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]*releaseState)
+	for idx := range cms.Items {
+		cm := &cms.Items[idx]
+		if !strings.HasPrefix(cm.Name, prefix) {
+			continue
+		}
+
+		// release name is the same as deploy name
+		releaseName := strings.TrimPrefix(cm.Name, prefix)
+		if !resolve.IsDeployName(releaseName) {
+			continue
+		}
+
+		state, stateErr := releaseStateFromConfigMap(cm)
+		if stateErr != nil {
+			return nil, stateErr
+		}
+		result[releaseName] = state
+	}
+
+	return result, nil
+}
+
+func (p *Plugin) deleteRelease(client kubernetes.Interface, releaseName string) error {
+	err := client.CoreV1().ConfigMaps(p.dataNamespace).Delete(p.getReleaseConfigMapName(releaseName), &meta.DeleteOptions{})
+	if err != nil && errors.IsNotFound(err) {
+		return nil
+	}
+
+	return err
+}
+
+func releaseStateFromConfigMap(cm *api.ConfigMap) (*releaseState, error) {
+	revision, err := strconv.Atoi(cm.Data["revision"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid revision of Helm release stored in configmap %s: %s", cm.Name, err)
+	}
+
+	return &releaseState{
+		Namespace:    cm.Data["namespace"],
+		ChartRepo:    cm.Data["chartRepo"],
+		ChartPath:    cm.Data["chartPath"],
+		ChartName:    cm.Data["chartName"],
+		ChartVersion: cm.Data["chartVersion"],
+		Values:       cm.Data["values"],
+		Manifest:     cm.Data["manifest"],
+		Revision:     revision,
+		Status:       cm.Data["status"],
+	}, nil
+}
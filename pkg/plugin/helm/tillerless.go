@@ -0,0 +1,376 @@
+package helm
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/Aptomi/aptomi/pkg/event"
+	"github.com/Aptomi/aptomi/pkg/plugin"
+	"github.com/Aptomi/aptomi/pkg/plugin/k8s"
+	"github.com/Aptomi/aptomi/pkg/util"
+	"gopkg.in/yaml.v2"
+	"k8s.io/helm/pkg/chartutil"
+	"k8s.io/helm/pkg/engine"
+	"k8s.io/helm/pkg/proto/hapi/chart"
+	"path"
+	"sort"
+	"strings"
+)
+
+// installOrder is an order in which k8s objects of different kinds get created, the same as used by Tiller
+var installOrder = []string{
+	"Namespace",
+	"ResourceQuota",
+	"LimitRange",
+	"Secret",
+	"ConfigMap",
+	"PersistentVolume",
+	"PersistentVolumeClaim",
+	"ServiceAccount",
+	"CustomResourceDefinition",
+	"ClusterRole",
+	"ClusterRoleBinding",
+	"Role",
+	"RoleBinding",
+	"Service",
+	"DaemonSet",
+	"Pod",
+	"ReplicationController",
+	"ReplicaSet",
+	"Deployment",
+	"StatefulSet",
+	"Job",
+	"CronJob",
+	"Ingress",
+	"APIService",
+}
+
+// manifestDoc represents single k8s object from the rendered chart
+type manifestDoc struct {
+	source string
+	obj    *k8s.ManifestObject
+}
+
+func (p *Plugin) createOrUpdateTillerless(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) error {
+	kubeClient, err := p.kube.NewClient()
+	if err != nil {
+		return err
+	}
+
+	err = p.kube.EnsureNamespace(kubeClient, p.kube.Namespace)
+	if err != nil {
+		return err
+	}
+
+	releaseName := getReleaseName(deployName)
+	chartRef, err := getChartInfo(params)
+	if err != nil {
+		return err
+	}
+	chartName := chartRef.Name
+
+	skipHooks, err := getSkipHooks(params)
+	if err != nil {
+		return err
+	}
+
+	chartPath, err := p.fetchChart(ctx, chartRef, eventLog)
+	if err != nil {
+		return err
+	}
+
+	helmParams, err := yaml.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	currRelease, err := p.findRelease(kubeClient, releaseName)
+	if err != nil {
+		return fmt.Errorf("error while looking for Helm release %s: %s", releaseName, err)
+	}
+
+	newRelease := &releaseState{
+		Namespace:    p.kube.Namespace,
+		ChartRepo:    chartRef.Repo,
+		ChartPath:    chartRef.Path,
+		ChartName:    chartRef.Name,
+		ChartVersion: chartRef.Version,
+		Values:       string(helmParams),
+		Revision:     1,
+	}
+	currManifest := ""
+	if currRelease != nil {
+		if currRelease.Namespace != p.kube.Namespace {
+			return fmt.Errorf("it's not allowed to change namespace of the release %s (was %s, requested %s)", releaseName, currRelease.Namespace, p.kube.Namespace)
+		}
+		newRelease.Revision = currRelease.Revision + 1
+		currManifest = currRelease.Manifest
+	}
+
+	logFields := event.Fields{
+		"release": releaseName,
+		"chart":   chartName,
+		"path":    chartPath,
+		"params":  string(helmParams),
+	}
+	if currRelease == nil {
+		eventLog.WithFields(logFields).Infof("Installing Helm release '%s' without Tiller, chart '%s', cluster: '%s'", releaseName, chartName, p.cluster.Name)
+	} else {
+		eventLog.WithFields(logFields).Infof("Updating Helm release '%s' without Tiller, chart '%s', cluster: '%s'", releaseName, chartName, p.cluster.Name)
+	}
+
+	newRelease.Manifest, err = p.renderChart(chartPath, releaseName, helmParams, currRelease == nil, newRelease.Revision, skipHooks, eventLog)
+	if err != nil {
+		return fmt.Errorf("error while rendering chart '%s' for Helm release '%s': %s", chartName, releaseName, err)
+	}
+
+	applyErr := p.kube.ApplyManifest(ctx, deployName, currManifest, newRelease.Manifest, eventLog)
+
+	// release gets stored even if apply failed, so next update will be done against all objects, which could be
+	// created before the failure
+	newRelease.Status = releaseStatusDeployed
+	if applyErr != nil {
+		newRelease.Status = releaseStatusFailed
+	}
+	err = p.storeRelease(kubeClient, releaseName, newRelease)
+	if err != nil {
+		return fmt.Errorf("error while storing Helm release %s: %s", releaseName, err)
+	}
+	if applyErr != nil {
+		return applyErr
+	}
+
+	if currRelease != nil {
+		diff, diffErr := getManifestDiff(currManifest, newRelease.Manifest)
+		if diffErr != nil {
+			return fmt.Errorf("error while calculating diff between chart manifests for Helm release '%s', chart '%s', cluster: '%s'", releaseName, chartName, p.cluster.Name)
+		}
+
+		eventLog.WithFields(logFields).Debugf("Updated Helm release '%s' without Tiller, chart '%s', cluster: '%s' %s", releaseName, chartName, p.cluster.Name, diff)
+	}
+
+	return nil
+}
+
+func (p *Plugin) destroyTillerless(ctx context.Context, deployName string, eventLog *event.Log) error {
+	kubeClient, err := p.kube.NewClient()
+	if err != nil {
+		return err
+	}
+
+	releaseName := getReleaseName(deployName)
+
+	currRelease, err := p.findRelease(kubeClient, releaseName)
+	if err != nil {
+		return fmt.Errorf("error while looking for Helm release %s: %s", releaseName, err)
+	}
+	if currRelease == nil {
+		eventLog.WithFields(event.Fields{}).Warningf("Helm release '%s' not found, nothing to delete", releaseName)
+		return nil
+	}
+
+	eventLog.WithFields(event.Fields{
+		"release": releaseName,
+	}).Infof("Deleting Helm release '%s' without Tiller", releaseName)
+
+	err = p.kube.DeleteManifest(ctx, deployName, currRelease.Manifest, eventLog)
+	if err != nil {
+		return err
+	}
+
+	return p.deleteRelease(kubeClient, releaseName)
+}
+
+func (p *Plugin) endpointsTillerless(deployName string, eventLog *event.Log) (map[string]string, error) {
+	kubeClient, err := p.kube.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	currRelease, err := p.loadRelease(kubeClient, getReleaseName(deployName))
+	if err != nil {
+		return nil, err
+	}
+
+	return p.kube.EndpointsForManifests(deployName, currRelease.Manifest, eventLog)
+}
+
+func (p *Plugin) resourcesTillerless(deployName string, eventLog *event.Log) (plugin.Resources, error) {
+	kubeClient, err := p.kube.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	currRelease, err := p.loadRelease(kubeClient, getReleaseName(deployName))
+	if err != nil {
+		return nil, err
+	}
+
+	return p.kube.ResourcesForManifest(deployName, currRelease.Manifest, eventLog)
+}
+
+func (p *Plugin) inspectTillerless(deployName string, params util.NestedParameterMap) (*plugin.DeploymentStatus, error) {
+	kubeClient, err := p.kube.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	releaseName := getReleaseName(deployName)
+
+	currRelease, err := p.findRelease(kubeClient, releaseName)
+	if err != nil {
+		return nil, fmt.Errorf("error while looking for Helm release %s: %s", releaseName, err)
+	}
+
+	return inspectRelease(releaseName, currRelease, params)
+}
+
+func (p *Plugin) listTillerless() (map[string]util.NestedParameterMap, error) {
+	kubeClient, err := p.kube.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	releases, err := p.listReleases(kubeClient)
+	if err != nil {
+		return nil, fmt.Errorf("error while listing Helm releases: %s", err)
+	}
+
+	result := make(map[string]util.NestedParameterMap)
+	for releaseName, state := range releases {
+		if state.Namespace == p.kube.Namespace {
+			result[releaseName] = util.NestedParameterMap{}
+		}
+	}
+
+	return result, nil
+}
+
+// inspectRelease checks that stored release exists, deployed and has the same values
+func inspectRelease(releaseName string, currRelease *releaseState, params util.NestedParameterMap) (*plugin.DeploymentStatus, error) {
+	if currRelease == nil {
+		return &plugin.DeploymentStatus{
+			Message: fmt.Sprintf("Helm release '%s' not found", releaseName),
+		}, nil
+	}
+
+	if currRelease.Status != releaseStatusDeployed {
+		return &plugin.DeploymentStatus{
+			Message: fmt.Sprintf("Helm release '%s' is in status %s", releaseName, currRelease.Status),
+		}, nil
+	}
+
+	helmParams, err := yaml.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+
+	if currRelease.Values != string(helmParams) {
+		return &plugin.DeploymentStatus{
+			Exists:  true,
+			Message: fmt.Sprintf("Helm release '%s' values differ from the code params", releaseName),
+		}, nil
+	}
+
+	return &plugin.DeploymentStatus{Exists: true, Matches: true}, nil
+}
+
+// getSkipHooks returns whether chart hooks should be skipped, based on skipHooks code param. Hooks can't be run
+// without Tiller, so chart with hooks could only be deployed if it's explicitly allowed to skip them
+func getSkipHooks(params util.NestedParameterMap) (bool, error) {
+	value, exist := params["skipHooks"]
+	if !exist {
+		return false, nil
+	}
+
+	skipHooks, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("skipHooks should be a bool, but got: %T", value)
+	}
+
+	return skipHooks, nil
+}
+
+// renderChart renders chart from a given path locally, the same way Tiller does, and returns the resulting manifest
+func (p *Plugin) renderChart(chartPath string, releaseName string, values []byte, install bool, revision int, skipHooks bool, eventLog *event.Log) (string, error) {
+	chrt, err := chartutil.Load(chartPath)
+	if err != nil {
+		return "", fmt.Errorf("error while loading chart: %s", err)
+	}
+
+	chartValues := &chart.Config{Raw: string(values)}
+	err = chartutil.ProcessRequirementsEnabled(chrt, chartValues)
+	if err != nil {
+		return "", fmt.Errorf("error while processing chart requirements: %s", err)
+	}
+
+	renderValues, err := chartutil.ToRenderValues(chrt, chartValues, chartutil.ReleaseOptions{
+		Name:      releaseName,
+		Namespace: p.kube.Namespace,
+		Revision:  revision,
+		IsInstall: install,
+		IsUpgrade: !install,
+	})
+	if err != nil {
+		return "", fmt.Errorf("error while preparing values: %s", err)
+	}
+
+	files, err := engine.New().Render(chrt, renderValues)
+	if err != nil {
+		return "", fmt.Errorf("error while rendering templates: %s", err)
+	}
+
+	return buildManifest(files, skipHooks, eventLog)
+}
+
+// buildManifest joins rendered chart templates into a single manifest with objects sorted in the install order.
+// Notes and partials are skipped. As there is no Tiller to process hooks, error is returned for charts with hooks,
+// unless they are allowed to be skipped
+func buildManifest(files map[string]string, skipHooks bool, eventLog *event.Log) (string, error) {
+	docs := []*manifestDoc{}
+	for _, name := range util.GetSortedStringKeys(files) {
+		base := path.Base(name)
+		if strings.HasPrefix(base, "_") || strings.HasSuffix(base, "NOTES.txt") {
+			continue
+		}
+
+		objects, err := k8s.ParseManifest(files[name])
+		if err != nil {
+			return "", fmt.Errorf("error while parsing rendered template %s: %s", name, err)
+		}
+
+		for _, obj := range objects {
+			if _, isHook := obj.Metadata.Annotations["helm.sh/hook"]; isHook {
+				if !skipHooks {
+					return "", fmt.Errorf("rendered template %s contains hook %s, which isn't supported without Tiller (set skipHooks code param to skip hooks)", name, obj.Kind)
+				}
+				eventLog.WithFields(event.Fields{}).Warningf("Skipping hook %s from rendered template %s, as hooks aren't supported without Tiller", obj.Kind, name)
+				continue
+			}
+
+			docs = append(docs, &manifestDoc{source: name, obj: obj})
+		}
+	}
+
+	sort.SliceStable(docs, func(i, j int) bool {
+		return installOrderIndex(docs[i].obj.Kind) < installOrderIndex(docs[j].obj.Kind)
+	})
+
+	var manifest bytes.Buffer
+	for _, doc := range docs {
+		fmt.Fprintf(&manifest, "---\n# Source: %s\n%s\n", doc.source, doc.obj.Content)
+	}
+
+	return manifest.String(), nil
+}
+
+// installOrderIndex returns position of a given kind in the install order. Unknown kinds get installed last
+func installOrderIndex(kind string) int {
+	for idx, orderedKind := range installOrder {
+		if orderedKind == kind {
+			return idx
+		}
+	}
+
+	return len(installOrder)
+}
@@ -0,0 +1,210 @@
+package helm
+
+import (
+	"github.com/Aptomi/aptomi/pkg/event"
+	"github.com/Aptomi/aptomi/pkg/lang"
+	"github.com/Aptomi/aptomi/pkg/plugin/k8s"
+	"github.com/Aptomi/aptomi/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"k8s.io/client-go/kubernetes/fake"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var testChartFiles = map[string]string{
+	"Chart.yaml":  "name: test\nversion: 0.1.0\n",
+	"values.yaml": "replicas: 1\nport: 80\n",
+	"templates/_helpers.tpl": `{{- define "test.fullname" -}}
+{{ .Release.Name }}-{{ .Chart.Name }}
+{{- end -}}
+`,
+	"templates/deployment.yaml": `apiVersion: apps/v1beta1
+kind: Deployment
+metadata:
+  name: {{ template "test.fullname" . }}
+spec:
+  replicas: {{ .Values.replicas }}
+`,
+	"templates/service.yaml": `apiVersion: v1
+kind: Service
+metadata:
+  name: {{ template "test.fullname" . }}
+spec:
+  ports:
+  - port: {{ .Values.port }}
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ template "test.fullname" . }}
+data:
+  namespace: {{ .Release.Namespace }}
+`,
+	"templates/hook.yaml": `apiVersion: batch/v1
+kind: Job
+metadata:
+  name: {{ template "test.fullname" . }}-hook
+  annotations:
+    helm.sh/hook: pre-install
+`,
+	"templates/NOTES.txt": "Release {{ .Release.Name }} installed\n",
+}
+
+func newTestPlugin() *Plugin {
+	return &Plugin{
+		cluster:       &lang.Cluster{Metadata: lang.Metadata{Name: "cluster-us-east"}},
+		kube:          &k8s.Plugin{Namespace: "test"},
+		tillerless:    true,
+		dataNamespace: "aptomi",
+	}
+}
+
+func writeTestChart(t *testing.T) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "aptomi-helm-test")
+	assert.NoError(t, err, "Temp dir should be created")
+
+	for name, content := range testChartFiles {
+		path := filepath.Join(dir, "test", name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755), "Chart dir should be created")
+		assert.NoError(t, ioutil.WriteFile(path, []byte(content), 0644), "Chart file should be written")
+	}
+
+	return filepath.Join(dir, "test")
+}
+
+func TestTillerlessRenderChart(t *testing.T) {
+	chartPath := writeTestChart(t)
+	defer os.RemoveAll(filepath.Dir(chartPath)) // nolint: errcheck
+
+	p := newTestPlugin()
+	values, err := yaml.Marshal(util.NestedParameterMap{"chartName": "test", "replicas": 3})
+	assert.NoError(t, err, "Values should be marshaled")
+
+	// hooks aren't supported, unless they are explicitly skipped
+	_, err = p.renderChart(chartPath, "redis", values, true, 1, false, event.NewLog("test-helm", false))
+	assert.Error(t, err, "Chart with hooks should not be rendered, unless hooks are skipped")
+
+	manifest, err := p.renderChart(chartPath, "redis", values, true, 1, true, event.NewLog("test-helm", false))
+	assert.NoError(t, err, "Chart should be rendered")
+
+	// notes, partials and hooks are skipped, objects are sorted in the install order
+	expected := `---
+# Source: test/templates/service.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: redis-test
+data:
+  namespace: test
+---
+# Source: test/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: redis-test
+spec:
+  ports:
+  - port: 80
+---
+# Source: test/templates/deployment.yaml
+apiVersion: apps/v1beta1
+kind: Deployment
+metadata:
+  name: redis-test
+spec:
+  replicas: 3
+`
+	assert.Equal(t, expected, manifest, "Rendered manifest should use code params as values")
+	assert.False(t, strings.Contains(manifest, "hook"), "Hooks should be skipped")
+
+	// invalid templates
+	_, err = buildManifest(map[string]string{"test/templates/invalid.yaml": "metadata:\n  name: test\n"}, false, event.NewLog("test-helm", false))
+	assert.Error(t, err, "Objects without kind should be rejected")
+
+	// skipHooks code param
+	for _, params := range []util.NestedParameterMap{{}, {"skipHooks": false}, {"skipHooks": true}} {
+		skipHooks, paramErr := getSkipHooks(params)
+		assert.NoError(t, paramErr, "Valid skipHooks param should be parsed: %v", params)
+		assert.Equal(t, params["skipHooks"] == true, skipHooks, "skipHooks param should be parsed: %v", params)
+	}
+	_, err = getSkipHooks(util.NestedParameterMap{"skipHooks": "yes"})
+	assert.Error(t, err, "Invalid skipHooks param should be rejected")
+}
+
+func TestTillerlessReleaseStore(t *testing.T) {
+	p := newTestPlugin()
+	client := fake.NewSimpleClientset()
+
+	params := util.NestedParameterMap{"chartRepo": "https://charts.example.com", "chartName": "test", "replicas": 3}
+	values, err := yaml.Marshal(params)
+	assert.NoError(t, err, "Values should be marshaled")
+
+	// missing release
+	state, err := p.findRelease(client, "redis")
+	assert.NoError(t, err, "Missing release should not be an error")
+	assert.Nil(t, state, "Missing release should not be found")
+	_, err = p.loadRelease(client, "redis")
+	assert.Error(t, err, "Missing release should not be loaded")
+
+	status, err := inspectRelease("redis", state, params)
+	assert.NoError(t, err, "Missing release should be inspected")
+	assert.False(t, status.Exists, "Missing release should not exist")
+
+	// store and update release
+	release := &releaseState{
+		Namespace: "test",
+		ChartRepo: "https://charts.example.com",
+		ChartName: "test",
+		Values:    string(values),
+		Manifest:  "kind: Service\n",
+		Revision:  1,
+		Status:    releaseStatusFailed,
+	}
+	assert.NoError(t, p.storeRelease(client, "redis", release), "Release should be stored")
+
+	state, err = p.loadRelease(client, "redis")
+	assert.NoError(t, err, "Release should be loaded")
+	assert.Equal(t, release, state, "Loaded release should be the same as stored one")
+
+	status, err = inspectRelease("redis", state, params)
+	assert.NoError(t, err, "Release should be inspected")
+	assert.False(t, status.Exists, "Failed release should not exist")
+
+	release.Revision = 2
+	release.Status = releaseStatusDeployed
+	assert.NoError(t, p.storeRelease(client, "redis", release), "Release should be updated")
+
+	state, err = p.loadRelease(client, "redis")
+	assert.NoError(t, err, "Release should be loaded")
+	assert.Equal(t, 2, state.Revision, "Release revision should be updated")
+
+	status, err = inspectRelease("redis", state, params)
+	assert.NoError(t, err, "Release should be inspected")
+	assert.True(t, status.Exists && status.Matches, "Release should match code params")
+
+	changedParams := params.MakeCopy()
+	changedParams["replicas"] = 5
+	status, err = inspectRelease("redis", state, changedParams)
+	assert.NoError(t, err, "Release should be inspected")
+	assert.True(t, status.Exists, "Release should exist")
+	assert.False(t, status.Matches, "Release should not match changed code params")
+
+	// list releases
+	assert.NoError(t, p.storeRelease(client, "a-0123456789abc", release), "Release should be stored")
+	releases, err := p.listReleases(client)
+	assert.NoError(t, err, "Releases should be listed")
+	assert.Len(t, releases, 1, "Only releases created by Aptomi should be listed")
+	assert.Contains(t, releases, "a-0123456789abc", "Release created by Aptomi should be listed")
+
+	// delete release
+	assert.NoError(t, p.deleteRelease(client, "redis"), "Release should be deleted")
+	assert.NoError(t, p.deleteRelease(client, "redis"), "Missing release should be deleted without error")
+	state, err = p.findRelease(client, "redis")
+	assert.NoError(t, err, "Missing release should not be an error")
+	assert.Nil(t, state, "Deleted release should not be found")
+}
@@ -1,72 +1,34 @@
 package helm
 
 import (
-	"fmt"
-	"github.com/Aptomi/aptomi/pkg/util"
-	"io/ioutil"
+	"github.com/pmezard/go-difflib/difflib"
 	"k8s.io/helm/pkg/helm"
-	"k8s.io/helm/pkg/repo"
 )
 
 func (p *Plugin) newClient() (*helm.Client, error) {
 	return helm.NewClient(helm.Host(p.tillerHost)), nil
 }
 
-func getHelmReleaseInfo(params util.NestedParameterMap) (repository, name, version string, err error) {
-	var ok bool
-	if repository, ok = params["chartRepo"].(string); !ok {
-		err = fmt.Errorf("chartRepo is a mandatory parameter")
-		return
-	}
-
-	if name, ok = params["chartName"].(string); !ok {
-		err = fmt.Errorf("chartName is a mandatory parameter")
-		return
-	}
-
-	if _, ok = params["chartVersion"]; !ok {
-		// version is optional. this will use the latest
-		version = ""
-	} else {
-		version = params["chartVersion"].(string)
-	}
-
-	return
-}
-
 func getReleaseName(deployName string) string {
 	return deployName
 }
 
-func (p *Plugin) fetchChart(repository, name, version string) (string, error) {
-	chartURL, err := repo.FindChartInRepoURL(
-		repository, name, version,
-		"", "", "",
-		newGetterProviders(),
-	)
-	if err != nil {
-		return "", fmt.Errorf("error while getting chart url in repo: %s", err)
-	}
-
-	chartFile, err := ioutil.TempFile("", name)
-	if err != nil {
-		return "", fmt.Errorf("error while creating temp file for downloading chart: %s", err)
-	}
-
-	chartGetter, err := newHTTPGetter(chartURL, "", "", "")
+// getManifestDiff returns human-readable unified diff between previous and current manifests of the release
+func getManifestDiff(prevManifest, currManifest string) (string, error) {
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(prevManifest),
+		B:        difflib.SplitLines(currManifest),
+		FromFile: "Previous",
+		ToFile:   "Current",
+		Context:  3,
+	})
 	if err != nil {
-		return "", fmt.Errorf("error while creating chart downloader: %s", err)
+		return "", err
 	}
 
-	resp, err := chartGetter.Get(chartURL)
-	if err != nil {
-		return "", fmt.Errorf("error while downloading chart: %s", err)
-	}
-
-	_, err = chartFile.Write(resp.Bytes())
-	if err != nil {
-		return "", fmt.Errorf("error while writing downloaded chart to the temp file")
+	if len(diff) == 0 {
+		return "without changes", nil
 	}
 
-	return chartFile.Name(), nil
+	return "with diff: \n\n" + diff, nil
 }
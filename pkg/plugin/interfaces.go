@@ -1,16 +1,24 @@
 package plugin
 
 import (
+	"context"
 	"github.com/Aptomi/aptomi/pkg/config"
 	"github.com/Aptomi/aptomi/pkg/event"
 	"github.com/Aptomi/aptomi/pkg/lang"
+	"github.com/Aptomi/aptomi/pkg/runtime"
 	"github.com/Aptomi/aptomi/pkg/util"
 )
 
-// Registry is a registry of all Aptomi engine plugins
+// Registry is a registry of all Aptomi engine plugins. It also serves as a type registry for policy validation,
+// providing the list of cluster and code types for which plugins are registered
 type Registry interface {
+	lang.TypeRegistry
+
 	ForCluster(cluster *lang.Cluster) (ClusterPlugin, error)
 	ForCodeType(cluster *lang.Cluster, codeType string) (CodePlugin, error)
+
+	// ClusterPlugins returns all cluster plugins, which have been created by the registry so far
+	ClusterPlugins() []ClusterPlugin
 }
 
 // RegistryFactory returns plugins registry on demand
@@ -33,16 +41,75 @@ type ClusterPlugin interface {
 type ClusterPluginConstructor func(cluster *lang.Cluster, cfg config.Plugins) (ClusterPlugin, error)
 
 // CodePlugin is a definition of deployment plugin which takes care of creating, updating and destroying
-// component instances in the cloud. It's created for specific cluster and enforcement cycle or API call.
+// component instances in the cloud. It's created for specific cluster and enforcement cycle or API call. All methods
+// accept a context, which gets cancelled on timeout or when applying revision gets aborted, and plugins should stop
+// what they are doing and return as soon as possible after that.
 type CodePlugin interface {
 	Base
 
-	Create(deployName string, params util.NestedParameterMap, eventLog *event.Log) error
-	Update(deployName string, params util.NestedParameterMap, eventLog *event.Log) error
-	Destroy(deployName string, params util.NestedParameterMap, eventLog *event.Log) error
-	Endpoints(deployName string, params util.NestedParameterMap, eventLog *event.Log) (map[string]string, error)
-	Resources(deployName string, params util.NestedParameterMap, eventLog *event.Log) (Resources, error)
+	Create(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) error
+	Update(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) error
+	Destroy(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) error
+	Endpoints(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) (map[string]string, error)
+	Resources(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) (Resources, error)
 }
 
 // CodePluginConstructor represents constructor the the code plugin
 type CodePluginConstructor func(cluster ClusterPlugin, cfg config.Plugins) (CodePlugin, error)
+
+// DeploymentStatus represents a status of a single deployment in the cloud, as observed by a code plugin
+type DeploymentStatus struct {
+	// Exists is true if deployment is present in the cloud
+	Exists bool
+
+	// Matches is true if deployment in the cloud matches provided parameters
+	Matches bool
+
+	// Message is a human-readable explanation of the status, if deployment is missing or doesn't match
+	Message string
+}
+
+// DeploymentInspector is an optional capability of the code plugin, which allows to check whether a deployment
+// exists in the cloud and matches provided parameters. It's used to detect drift between actual state stored by
+// Aptomi and what is actually running in the cloud.
+type DeploymentInspector interface {
+	Inspect(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) (*DeploymentStatus, error)
+}
+
+// ReadinessChecker is an optional capability of the code plugin, which allows to wait until a deployment becomes ready
+// (e.g. all of its workloads are running). It's used by update strategies to switch traffic to a new deployment only
+// once it's ready. Error is returned if deployment doesn't become ready in time or if context is done.
+type ReadinessChecker interface {
+	WaitForReady(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) error
+}
+
+// DeploymentLister is an optional capability of the code plugin, which allows to list all deployments in the cloud
+// created by Aptomi (i.e. having deploy names generated by Aptomi). It's used to find orphaned deployments, which don't
+// have corresponding component instances in actual state.
+type DeploymentLister interface {
+	// List returns a map from deploy name to parameters, which are required to destroy the deployment
+	List(ctx context.Context, eventLog *event.Log) (map[string]util.NestedParameterMap, error)
+}
+
+// RevisionCommitter is an optional capability of the cluster plugin, which allows to commit all changes made by its
+// code plugins once all actions of the revision have been applied. It's used by plugins, which don't change the cloud
+// right away, but record changes to be picked up by other tools (e.g. commit them into git repository).
+type RevisionCommitter interface {
+	CommitRevision(gen runtime.Generation, eventLog *event.Log) error
+}
+
+// IngressPolicy represents restrictions of ingress traffic to a single deployment in the cloud
+type IngressPolicy struct {
+	// AllowIngress is true if ingress traffic to the deployment isn't restricted
+	AllowIngress bool
+
+	// AllowedFrom is a list of deploy names of other deployments, which are allowed to send traffic to the deployment
+	// when ingress traffic is restricted
+	AllowedFrom []string
+}
+
+// IngressPolicyManager is an optional capability of the code plugin, which allows to restrict ingress traffic to the
+// deployment. It's used to enforce rules rejecting ingress traffic for component instances.
+type IngressPolicyManager interface {
+	ApplyIngressPolicy(ctx context.Context, deployName string, policy *IngressPolicy, eventLog *event.Log) error
+}
@@ -4,35 +4,54 @@ import (
 	"fmt"
 	"github.com/Aptomi/aptomi/pkg/event"
 	"github.com/Aptomi/aptomi/pkg/util"
+	"k8s.io/apimachinery/pkg/api/errors"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
 	api "k8s.io/client-go/pkg/api/v1"
-	"strings"
+	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
 )
 
-// EndpointsForManifests returns endpoints for specified manifest
+// EndpointsForManifests returns endpoints for specified manifest. They are discovered from NodePort and LoadBalancer
+// services, as well as from ingress rules
 func (p *Plugin) EndpointsForManifests(deployName, targetManifest string, eventLog *event.Log) (map[string]string, error) {
 	kubeClient, err := p.NewClient()
 	if err != nil {
 		return nil, err
 	}
 
-	helmKube := p.NewHelmKube(deployName, eventLog)
-
-	infos, err := helmKube.BuildUnstructured(p.Namespace, strings.NewReader(targetManifest))
+	objects, err := ParseManifest(targetManifest)
 	if err != nil {
 		return nil, err
 	}
 
+	return p.endpointsForObjects(kubeClient, objects)
+}
+
+func (p *Plugin) endpointsForObjects(kubeClient kubernetes.Interface, objects []*ManifestObject) (map[string]string, error) {
 	endpoints := make(map[string]string)
 
-	for _, info := range infos {
-		if info.Mapping.GroupVersionKind.Kind == "Service" {
-			service, getErr := kubeClient.CoreV1().Services(p.Namespace).Get(info.Name, meta.GetOptions{})
+	for _, obj := range objects {
+		namespace := obj.getNamespace(p.Namespace)
+
+		switch obj.Kind {
+		case "Service":
+			service, getErr := kubeClient.CoreV1().Services(namespace).Get(obj.Metadata.Name, meta.GetOptions{})
 			if getErr != nil {
 				return nil, getErr
 			}
 
 			p.addEndpointsFromService(service, endpoints)
+		case "Ingress":
+			ingress, getErr := kubeClient.ExtensionsV1beta1().Ingresses(namespace).Get(obj.Metadata.Name, meta.GetOptions{})
+			if getErr != nil {
+				// ingresses could be disabled in the cluster, while endpoints of services are still useful
+				if errors.IsNotFound(getErr) {
+					continue
+				}
+				return nil, getErr
+			}
+
+			p.addEndpointsFromIngress(ingress, endpoints)
 		}
 	}
 
@@ -41,24 +60,97 @@ func (p *Plugin) EndpointsForManifests(deployName, targetManifest string, eventL
 
 // addEndpointsFromService searches for the available endpoints in specified service and writes them into provided map
 func (p *Plugin) addEndpointsFromService(service *api.Service, endpoints map[string]string) {
-	// todo(slukjanov): support not only node ports
-	if service.Spec.Type == "NodePort" {
+	// load balancer could be still provisioning, node ports are used in the meantime
+	if service.Spec.Type == "LoadBalancer" && len(service.Status.LoadBalancer.Ingress) > 0 {
+		address := getLoadBalancerAddress(service.Status.LoadBalancer.Ingress[0])
 		for _, port := range service.Spec.Ports {
-			sURL := fmt.Sprintf("%s:%d", p.ExternalAddress, port.NodePort)
-
-			// todo(slukjanov): could we somehow detect real schema? I think no :(
-			if util.StringContainsAny(port.Name, "https") {
-				sURL = "https://" + sURL
-			} else if util.StringContainsAny(port.Name, "ui", "rest", "http", "grafana") {
-				sURL = "http://" + sURL
+			endpoints[getPortEndpointName(port)] = getPortEndpointURL(fmt.Sprintf("%s:%d", address, port.Port), port.Name)
+		}
+	} else if service.Spec.Type == "NodePort" || service.Spec.Type == "LoadBalancer" {
+		for _, port := range service.Spec.Ports {
+			if port.NodePort == 0 {
+				continue
 			}
 
-			name := port.Name
-			if len(name) == 0 {
-				name = port.TargetPort.String()
+			endpoints[getPortEndpointName(port)] = getPortEndpointURL(fmt.Sprintf("%s:%d", p.ExternalAddress, port.NodePort), port.Name)
+		}
+	}
+}
+
+// addEndpointsFromIngress adds endpoint for every host and path from ingress rules, using https for hosts with TLS
+// enabled. Rules without host are exposed through the ingress address, if it's already assigned
+func (p *Plugin) addEndpointsFromIngress(ingress *extensions.Ingress, endpoints map[string]string) {
+	tlsHosts := make(map[string]bool)
+	for _, tls := range ingress.Spec.TLS {
+		for _, host := range tls.Hosts {
+			tlsHosts[host] = true
+		}
+	}
+
+	address := ""
+	if len(ingress.Status.LoadBalancer.Ingress) > 0 {
+		address = getLoadBalancerAddress(ingress.Status.LoadBalancer.Ingress[0])
+	}
+
+	for _, rule := range ingress.Spec.Rules {
+		host := rule.Host
+		if len(host) == 0 {
+			host = address
+		}
+		if len(host) == 0 {
+			continue
+		}
+
+		scheme := "http://"
+		if tlsHosts[rule.Host] {
+			scheme = "https://"
+		}
+
+		paths := []string{""}
+		if rule.HTTP != nil && len(rule.HTTP.Paths) > 0 {
+			paths = paths[:0]
+			for _, path := range rule.HTTP.Paths {
+				paths = append(paths, path.Path)
 			}
+		}
 
-			endpoints[name] = sURL
+		for _, path := range paths {
+			name := ingress.Name + ":" + host + path
+			endpoints[name] = scheme + host + path
 		}
 	}
+
+	// default backend is exposed through the ingress address only
+	if ingress.Spec.Backend != nil && len(address) > 0 {
+		endpoints[ingress.Name] = "http://" + address
+	}
+}
+
+// getLoadBalancerAddress returns address of the load balancer, which could be either hostname (e.g. on AWS) or IP
+func getLoadBalancerAddress(lbIngress api.LoadBalancerIngress) string {
+	if len(lbIngress.Hostname) > 0 {
+		return lbIngress.Hostname
+	}
+
+	return lbIngress.IP
+}
+
+func getPortEndpointName(port api.ServicePort) string {
+	if len(port.Name) > 0 {
+		return port.Name
+	}
+
+	return port.TargetPort.String()
+}
+
+// getPortEndpointURL returns URL for the service port exposed on a given address, trying to guess its schema
+func getPortEndpointURL(address string, portName string) string {
+	// todo(slukjanov): could we somehow detect real schema? I think no :(
+	if util.StringContainsAny(portName, "https") {
+		return "https://" + address
+	} else if util.StringContainsAny(portName, "ui", "rest", "http", "grafana") {
+		return "http://" + address
+	}
+
+	return address
 }
@@ -0,0 +1,93 @@
+package k8s
+
+import (
+	"github.com/stretchr/testify/assert"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+	api "k8s.io/client-go/pkg/api/v1"
+	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+	"testing"
+)
+
+const testEndpointsManifest = `---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: api
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: pending
+---
+apiVersion: extensions/v1beta1
+kind: Ingress
+metadata:
+  name: web
+---
+apiVersion: extensions/v1beta1
+kind: Ingress
+metadata:
+  name: missing
+`
+
+func TestEndpointsForObjects(t *testing.T) {
+	p := &Plugin{Namespace: "test", ExternalAddress: "192.168.0.1"}
+
+	client := fake.NewSimpleClientset(
+		&api.Service{
+			ObjectMeta: meta.ObjectMeta{Name: "web", Namespace: "test"},
+			Spec: api.ServiceSpec{
+				Type:  api.ServiceTypeNodePort,
+				Ports: []api.ServicePort{{Name: "http", Port: 80, NodePort: 30080}},
+			},
+		},
+		&api.Service{
+			ObjectMeta: meta.ObjectMeta{Name: "api", Namespace: "test"},
+			Spec: api.ServiceSpec{
+				Type:  api.ServiceTypeLoadBalancer,
+				Ports: []api.ServicePort{{Name: "https-api", Port: 443, NodePort: 30443}},
+			},
+			Status: api.ServiceStatus{LoadBalancer: api.LoadBalancerStatus{Ingress: []api.LoadBalancerIngress{{Hostname: "api.elb.example.com"}}}},
+		},
+		&api.Service{
+			ObjectMeta: meta.ObjectMeta{Name: "pending", Namespace: "test"},
+			Spec: api.ServiceSpec{
+				Type:  api.ServiceTypeLoadBalancer,
+				Ports: []api.ServicePort{{Port: 9000, NodePort: 30900, TargetPort: intstr.FromString("metrics")}},
+			},
+		},
+		&extensions.Ingress{
+			ObjectMeta: meta.ObjectMeta{Name: "web", Namespace: "test"},
+			Spec: extensions.IngressSpec{
+				Rules: []extensions.IngressRule{
+					{Host: "web.example.com"},
+					{Host: "admin.example.com", IngressRuleValue: extensions.IngressRuleValue{HTTP: &extensions.HTTPIngressRuleValue{
+						Paths: []extensions.HTTPIngressPath{{Path: "/admin"}},
+					}}},
+				},
+				TLS: []extensions.IngressTLS{{Hosts: []string{"web.example.com"}}},
+			},
+		},
+	)
+
+	objects, err := ParseManifest(testEndpointsManifest)
+	assert.NoError(t, err, "Manifest should be parsed")
+
+	endpoints, err := p.endpointsForObjects(client, objects)
+	assert.NoError(t, err, "Endpoints should be discovered")
+
+	assert.Equal(t, map[string]string{
+		"http":                        "http://192.168.0.1:30080",
+		"https-api":                   "https://api.elb.example.com:443",
+		"metrics":                     "192.168.0.1:30900",
+		"web:web.example.com":         "https://web.example.com",
+		"web:admin.example.com/admin": "http://admin.example.com/admin",
+	}, endpoints, "Endpoints should be discovered from services and ingresses")
+}
@@ -0,0 +1,87 @@
+package k8s
+
+import (
+	"github.com/Aptomi/aptomi/pkg/event"
+	"github.com/Aptomi/aptomi/pkg/plugin"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	networking "k8s.io/client-go/pkg/apis/networking/v1"
+	"strings"
+)
+
+// DeploymentPodLabel is a label, which pods of a deployment are expected to have set to the deploy name. Helm charts
+// set it to the release name (which is the same as deploy name) by convention
+const DeploymentPodLabel = "release"
+
+// getIngressPolicyName returns name of the NetworkPolicy restricting ingress traffic to a given deployment
+func getIngressPolicyName(deployName string) string {
+	return "aptomi-ingress-" + deployName
+}
+
+// ApplyIngressPolicy creates or updates NetworkPolicy, which blocks ingress traffic to the pods of a given deployment,
+// except traffic from its own pods and pods of allowed deployments. If ingress traffic is allowed, NetworkPolicy gets
+// deleted instead
+func (p *Plugin) ApplyIngressPolicy(client kubernetes.Interface, deployName string, policy *plugin.IngressPolicy, eventLog *event.Log) error {
+	if policy.AllowIngress {
+		return p.DeleteIngressPolicy(client, deployName, eventLog)
+	}
+
+	allowedFrom := append([]string{deployName}, policy.AllowedFrom...)
+	spec := networking.NetworkPolicySpec{
+		PodSelector: meta.LabelSelector{
+			MatchLabels: map[string]string{DeploymentPodLabel: deployName},
+		},
+		Ingress: []networking.NetworkPolicyIngressRule{{
+			From: []networking.NetworkPolicyPeer{{
+				PodSelector: &meta.LabelSelector{
+					MatchExpressions: []meta.LabelSelectorRequirement{{
+						Key:      DeploymentPodLabel,
+						Operator: meta.LabelSelectorOpIn,
+						Values:   allowedFrom,
+					}},
+				},
+			}},
+		}},
+	}
+
+	eventLog.WithFields(event.Fields{}).Infof("Rejecting ingress traffic to %s, except from: %s", deployName, strings.Join(allowedFrom, ", "))
+
+	name := getIngressPolicyName(deployName)
+	networkPolicy, err := client.NetworkingV1().NetworkPolicies(p.Namespace).Get(name, meta.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			networkPolicy = &networking.NetworkPolicy{
+				ObjectMeta: meta.ObjectMeta{
+					Name: name,
+				},
+				Spec: spec,
+			}
+
+			_, err = client.NetworkingV1().NetworkPolicies(p.Namespace).Create(networkPolicy)
+		}
+
+		return err
+	}
+
+	networkPolicy.Spec = spec
+
+	_, err = client.NetworkingV1().NetworkPolicies(p.Namespace).Update(networkPolicy)
+
+	return err
+}
+
+// DeleteIngressPolicy deletes NetworkPolicy restricting ingress traffic to a given deployment, if it exists
+func (p *Plugin) DeleteIngressPolicy(client kubernetes.Interface, deployName string, eventLog *event.Log) error {
+	err := client.NetworkingV1().NetworkPolicies(p.Namespace).Delete(getIngressPolicyName(deployName), &meta.DeleteOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	eventLog.WithFields(event.Fields{}).Infof("Allowed ingress traffic to %s", deployName)
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package k8s
+
+import (
+	"github.com/Aptomi/aptomi/pkg/event"
+	"github.com/Aptomi/aptomi/pkg/plugin"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"testing"
+)
+
+func TestIngressPolicy(t *testing.T) {
+	p := &Plugin{Namespace: "test"}
+	client := fake.NewSimpleClientset()
+	eventLog := event.NewLog("test-ingress", false)
+
+	// allowed ingress doesn't create anything
+	err := p.ApplyIngressPolicy(client, "a-0123456789abc", &plugin.IngressPolicy{AllowIngress: true}, eventLog)
+	assert.NoError(t, err, "Allowed ingress should be applied")
+	_, err = client.NetworkingV1().NetworkPolicies("test").Get("aptomi-ingress-a-0123456789abc", meta.GetOptions{})
+	assert.True(t, errors.IsNotFound(err), "NetworkPolicy should not be created for allowed ingress")
+
+	// rejected ingress creates network policy, which allows traffic from the deployment itself and allowed sources
+	err = p.ApplyIngressPolicy(client, "a-0123456789abc", &plugin.IngressPolicy{AllowedFrom: []string{"frontend"}}, eventLog)
+	assert.NoError(t, err, "Rejected ingress should be applied")
+
+	networkPolicy, err := client.NetworkingV1().NetworkPolicies("test").Get("aptomi-ingress-a-0123456789abc", meta.GetOptions{})
+	if assert.NoError(t, err, "NetworkPolicy should be created for rejected ingress") {
+		assert.Equal(t, map[string]string{"release": "a-0123456789abc"}, networkPolicy.Spec.PodSelector.MatchLabels, "NetworkPolicy should select pods of the deployment")
+		if assert.Len(t, networkPolicy.Spec.Ingress, 1, "NetworkPolicy should have a single ingress rule") && assert.Len(t, networkPolicy.Spec.Ingress[0].From, 1, "Ingress rule should have a single peer") {
+			selector := networkPolicy.Spec.Ingress[0].From[0].PodSelector
+			assert.Equal(t, []string{"a-0123456789abc", "frontend"}, selector.MatchExpressions[0].Values, "Traffic from the deployment and allowed sources should be accepted")
+		}
+	}
+
+	// allowed sources get updated
+	err = p.ApplyIngressPolicy(client, "a-0123456789abc", &plugin.IngressPolicy{AllowedFrom: []string{"frontend", "worker"}}, eventLog)
+	assert.NoError(t, err, "Rejected ingress should be updated")
+
+	networkPolicy, err = client.NetworkingV1().NetworkPolicies("test").Get("aptomi-ingress-a-0123456789abc", meta.GetOptions{})
+	if assert.NoError(t, err, "NetworkPolicy should be updated") {
+		selector := networkPolicy.Spec.Ingress[0].From[0].PodSelector
+		assert.Equal(t, []string{"a-0123456789abc", "frontend", "worker"}, selector.MatchExpressions[0].Values, "Allowed sources should be updated")
+	}
+
+	// allowing ingress again deletes network policy
+	err = p.ApplyIngressPolicy(client, "a-0123456789abc", &plugin.IngressPolicy{AllowIngress: true}, eventLog)
+	assert.NoError(t, err, "Allowed ingress should be applied")
+	_, err = client.NetworkingV1().NetworkPolicies("test").Get("aptomi-ingress-a-0123456789abc", meta.GetOptions{})
+	assert.True(t, errors.IsNotFound(err), "NetworkPolicy should be deleted once ingress is allowed")
+
+	assert.NoError(t, p.DeleteIngressPolicy(client, "a-0123456789abc", eventLog), "Missing NetworkPolicy should be deleted without error")
+}
@@ -0,0 +1,167 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/Aptomi/aptomi/pkg/event"
+	"gopkg.in/yaml.v2"
+	"regexp"
+	"strings"
+)
+
+// manifestSeparator splits manifest into separate yaml documents
+var manifestSeparator = regexp.MustCompile("(?:^|\\s*\n)---\\s*")
+
+// ManifestObject represents a single k8s object from the manifest
+type ManifestObject struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	Metadata   struct {
+		Name        string            `yaml:"name"`
+		Namespace   string            `yaml:"namespace"`
+		Annotations map[string]string `yaml:"annotations"`
+	} `yaml:"metadata"`
+
+	// Content is a yaml representation of the object, as it's defined in the manifest
+	Content string `yaml:"-"`
+}
+
+// ParseManifest splits manifest into separate k8s objects, while skipping empty documents and documents with comments only
+func ParseManifest(manifest string) ([]*ManifestObject, error) {
+	result := []*ManifestObject{}
+	for _, content := range manifestSeparator.Split(manifest, -1) {
+		if len(strings.TrimSpace(content)) == 0 {
+			continue
+		}
+
+		obj := &ManifestObject{}
+		err := yaml.Unmarshal([]byte(content), obj)
+		if err != nil {
+			return nil, fmt.Errorf("error while parsing manifest: %s", err)
+		}
+		if len(obj.Kind) == 0 {
+			// templates rendered into comments only (e.g. disabled ones) don't define any objects
+			if len(obj.APIVersion) == 0 && len(obj.Metadata.Name) == 0 {
+				continue
+			}
+			return nil, fmt.Errorf("object without kind found in manifest")
+		}
+		obj.Content = strings.TrimSpace(content)
+
+		result = append(result, obj)
+	}
+
+	return result, nil
+}
+
+// JoinManifest joins k8s objects back into a single manifest
+func JoinManifest(objects []*ManifestObject) string {
+	var manifest bytes.Buffer
+	for _, obj := range objects {
+		fmt.Fprintf(&manifest, "---\n%s\n", obj.Content)
+	}
+
+	return manifest.String()
+}
+
+// getNamespace returns namespace of the object, while falling back to the default one if it isn't set in the manifest
+func (obj *ManifestObject) getNamespace(defaultNamespace string) string {
+	if len(obj.Metadata.Namespace) > 0 {
+		return obj.Metadata.Namespace
+	}
+
+	return defaultNamespace
+}
+
+// key returns identifier of the object, which is unique inside the cluster
+func (obj *ManifestObject) key(defaultNamespace string) string {
+	return obj.Kind + "/" + obj.getNamespace(defaultNamespace) + "/" + obj.Metadata.Name
+}
+
+// String returns human-readable representation of the object
+func (obj *ManifestObject) String() string {
+	return obj.Kind + " '" + obj.Metadata.Name + "'"
+}
+
+// removedObjects returns objects, which are present in the current manifest, but missing in the target one
+func (p *Plugin) removedObjects(currentManifest, targetManifest string) ([]*ManifestObject, error) {
+	current, err := ParseManifest(currentManifest)
+	if err != nil {
+		return nil, err
+	}
+	target, err := ParseManifest(targetManifest)
+	if err != nil {
+		return nil, err
+	}
+
+	targetKeys := make(map[string]bool)
+	for _, obj := range target {
+		targetKeys[obj.key(p.Namespace)] = true
+	}
+
+	result := []*ManifestObject{}
+	for _, obj := range current {
+		if !targetKeys[obj.key(p.Namespace)] {
+			result = append(result, obj)
+		}
+	}
+
+	return result, nil
+}
+
+// ApplyManifest creates all k8s objects from the target manifest in the cluster namespace. If current manifest isn't
+// empty, objects get updated instead and objects missing in the target manifest get pruned. Nothing gets changed in
+// the cluster once context is done
+func (p *Plugin) ApplyManifest(ctx context.Context, deployName, currentManifest, targetManifest string, eventLog *event.Log) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	client := p.NewHelmKube(deployName, eventLog)
+
+	if len(currentManifest) == 0 {
+		return client.Create(p.Namespace, strings.NewReader(targetManifest), 42, false)
+	}
+
+	err := client.Update(p.Namespace, strings.NewReader(currentManifest), strings.NewReader(targetManifest), false, false, 42, false)
+	if err != nil {
+		return err
+	}
+
+	return p.pruneManifest(ctx, deployName, currentManifest, targetManifest, eventLog)
+}
+
+// pruneManifest deletes objects, which have been removed from the manifest. Helm kube client tries to delete them
+// while updating objects as well, but it only logs failed deletions, so they are explicitly deleted here to make sure
+// nothing is left behind
+func (p *Plugin) pruneManifest(ctx context.Context, deployName, currentManifest, targetManifest string, eventLog *event.Log) error {
+	removed, err := p.removedObjects(currentManifest, targetManifest)
+	if err != nil {
+		return err
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+
+	eventLog.WithFields(event.Fields{}).Infof("Pruning objects removed from manifest of %s: %s", deployName, removed)
+
+	// already deleted objects are skipped by helm kube client
+	err = p.DeleteManifest(ctx, deployName, JoinManifest(removed), eventLog)
+	if err != nil {
+		return fmt.Errorf("error while pruning objects removed from manifest of %s: %s", deployName, err)
+	}
+
+	return nil
+}
+
+// DeleteManifest deletes all k8s objects from the manifest from the cluster namespace, unless context is already done
+func (p *Plugin) DeleteManifest(ctx context.Context, deployName, manifest string, eventLog *event.Log) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	client := p.NewHelmKube(deployName, eventLog)
+
+	return client.Delete(p.Namespace, strings.NewReader(manifest))
+}
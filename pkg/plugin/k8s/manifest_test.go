@@ -0,0 +1,104 @@
+package k8s
+
+import (
+	"context"
+	"github.com/Aptomi/aptomi/pkg/event"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const testManifest = `---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+---
+apiVersion: apps/v1beta1
+kind: Deployment
+metadata:
+  name: web
+  namespace: other
+---
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web-config
+`
+
+func TestParseManifest(t *testing.T) {
+	objects, err := ParseManifest(testManifest)
+	assert.NoError(t, err, "Manifest should be parsed")
+	if !assert.Len(t, objects, 3, "Empty documents should be skipped") {
+		return
+	}
+
+	assert.Equal(t, "Service 'web'", objects[0].String(), "Object should be parsed")
+	assert.Equal(t, "other", objects[1].Metadata.Namespace, "Object namespace should be parsed")
+	assert.Equal(t, "Deployment/other/web", objects[1].key("test"), "Object key should use its own namespace")
+	assert.Equal(t, "ConfigMap/test/web-config", objects[2].key("test"), "Object key should use default namespace")
+
+	joined, err := ParseManifest(JoinManifest(objects))
+	assert.NoError(t, err, "Joined manifest should be parsed")
+	assert.Equal(t, objects, joined, "Joined manifest should contain the same objects")
+
+	_, err = ParseManifest("apiVersion: v1\nmetadata:\n  name: web\n")
+	assert.Error(t, err, "Object without kind should not be parsed")
+}
+
+func TestParseManifestCommentsOnly(t *testing.T) {
+	manifest := `---
+# Source: test/templates/disabled.yaml
+---
+# Source: test/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+---
+# Source: test/templates/ingress.yaml
+# ingress is disabled
+`
+	objects, err := ParseManifest(manifest)
+	assert.NoError(t, err, "Manifest with comment-only documents should be parsed")
+	if assert.Len(t, objects, 1, "Documents with comments only should be skipped") {
+		assert.Equal(t, "Service 'web'", objects[0].String(), "Object after comment-only document should be parsed")
+	}
+
+	objects, err = ParseManifest("# Source: test/templates/disabled.yaml\n")
+	assert.NoError(t, err, "Manifest with comments only should be parsed")
+	assert.Empty(t, objects, "Manifest with comments only should not contain objects")
+}
+
+func TestRemovedObjects(t *testing.T) {
+	p := &Plugin{Namespace: "test"}
+
+	target := `---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  namespace: test
+`
+	removed, err := p.removedObjects(testManifest, target)
+	assert.NoError(t, err, "Removed objects should be calculated")
+	if assert.Len(t, removed, 2, "Objects missing in target manifest should be removed") {
+		assert.Equal(t, "Deployment 'web'", removed[0].String(), "Deployment should be removed")
+		assert.Equal(t, "ConfigMap 'web-config'", removed[1].String(), "ConfigMap should be removed")
+	}
+
+	removed, err = p.removedObjects(testManifest, testManifest)
+	assert.NoError(t, err, "Removed objects should be calculated")
+	assert.Empty(t, removed, "Nothing should be removed if manifest didn't change")
+}
+
+func TestManifestContextDone(t *testing.T) {
+	p := &Plugin{Namespace: "test"}
+	eventLog := event.NewLog("test-manifest", false)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.Equal(t, context.Canceled, p.ApplyManifest(ctx, "test", "", testManifest, eventLog), "Manifest should not be applied once context is done")
+	assert.Equal(t, context.Canceled, p.DeleteManifest(ctx, "test", testManifest, eventLog), "Manifest should not be deleted once context is done")
+}
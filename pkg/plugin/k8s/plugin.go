@@ -30,6 +30,11 @@ func New(cluster *lang.Cluster, cfg config.Plugins) (plugin.ClusterPlugin, error
 	}, nil
 }
 
+// GetCluster returns cluster, for which plugin has been created
+func (p *Plugin) GetCluster() *lang.Cluster {
+	return p.Cluster
+}
+
 // Validate checks Kubernetes cluster by connecting to it and ensuring configured namespace
 func (p *Plugin) Validate() error {
 	err := p.Init()
@@ -0,0 +1,139 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"github.com/Aptomi/aptomi/pkg/event"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	api "k8s.io/client-go/pkg/api/v1"
+	batch "k8s.io/client-go/pkg/apis/batch/v1"
+	"strings"
+	"time"
+)
+
+// readinessPollInterval is an interval between readiness checks of the workloads
+var readinessPollInterval = 2 * time.Second
+
+// WaitForReady waits until all workloads from the manifest become ready: Deployments available, StatefulSets ready and
+// Jobs complete. Other objects are considered ready right after creation. Progress is reported through the event log
+// and error is returned if workloads aren't ready within a given timeout, if any Job failed or if context is done
+func (p *Plugin) WaitForReady(ctx context.Context, client kubernetes.Interface, deployName, manifest string, timeout time.Duration, eventLog *event.Log) error {
+	objects, err := ParseManifest(manifest)
+	if err != nil {
+		return err
+	}
+
+	eventLog.WithFields(event.Fields{}).Infof("Waiting up to %s for workloads of %s to become ready", timeout, deployName)
+
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	lastStatus := ""
+	for {
+		notReady := []string{}
+		for _, obj := range objects {
+			ready, status, checkErr := p.checkReady(client, obj)
+			if checkErr != nil {
+				return fmt.Errorf("%s of %s failed: %s", obj, deployName, checkErr)
+			}
+			if !ready {
+				notReady = append(notReady, fmt.Sprintf("%s (%s)", obj, status))
+			}
+		}
+
+		if len(notReady) == 0 {
+			eventLog.WithFields(event.Fields{}).Infof("All workloads of %s are ready", deployName)
+			return nil
+		}
+
+		status := strings.Join(notReady, ", ")
+		if status != lastStatus {
+			eventLog.WithFields(event.Fields{}).Infof("Waiting for workloads of %s: %s", deployName, status)
+			lastStatus = status
+		}
+
+		select {
+		case <-waitCtx.Done():
+			if ctx.Err() != nil {
+				return fmt.Errorf("stopped waiting for workloads of %s: %s", deployName, ctx.Err())
+			}
+			return fmt.Errorf("workloads of %s aren't ready after %s: %s", deployName, timeout, status)
+		case <-time.After(readinessPollInterval):
+		}
+	}
+}
+
+// checkReady returns whether a given object is ready and its human-readable status if it isn't. Error is returned if
+// object will never become ready (e.g. Job failed)
+func (p *Plugin) checkReady(client kubernetes.Interface, obj *ManifestObject) (bool, string, error) {
+	namespace := obj.getNamespace(p.Namespace)
+
+	switch obj.Kind {
+	case "Deployment":
+		deployment, err := client.AppsV1beta1().Deployments(namespace).Get(obj.Metadata.Name, meta.GetOptions{})
+		if err != nil {
+			return notFoundAsNotReady(err)
+		}
+
+		replicas := int32(1)
+		if deployment.Spec.Replicas != nil {
+			replicas = *deployment.Spec.Replicas
+		}
+		if deployment.Status.ObservedGeneration < deployment.Generation {
+			return false, "new generation not observed yet", nil
+		}
+		if deployment.Status.UpdatedReplicas < replicas {
+			return false, fmt.Sprintf("%d of %d replicas updated", deployment.Status.UpdatedReplicas, replicas), nil
+		}
+		if deployment.Status.AvailableReplicas < replicas {
+			return false, fmt.Sprintf("%d of %d replicas available", deployment.Status.AvailableReplicas, replicas), nil
+		}
+	case "StatefulSet":
+		statefulSet, err := client.AppsV1beta1().StatefulSets(namespace).Get(obj.Metadata.Name, meta.GetOptions{})
+		if err != nil {
+			return notFoundAsNotReady(err)
+		}
+
+		replicas := int32(1)
+		if statefulSet.Spec.Replicas != nil {
+			replicas = *statefulSet.Spec.Replicas
+		}
+		if statefulSet.Status.ObservedGeneration == nil || *statefulSet.Status.ObservedGeneration < statefulSet.Generation {
+			return false, "new generation not observed yet", nil
+		}
+		if statefulSet.Status.ReadyReplicas < replicas {
+			return false, fmt.Sprintf("%d of %d replicas ready", statefulSet.Status.ReadyReplicas, replicas), nil
+		}
+	case "Job":
+		job, err := client.BatchV1().Jobs(namespace).Get(obj.Metadata.Name, meta.GetOptions{})
+		if err != nil {
+			return notFoundAsNotReady(err)
+		}
+
+		for _, condition := range job.Status.Conditions {
+			if condition.Status != api.ConditionTrue {
+				continue
+			}
+			if condition.Type == batch.JobComplete {
+				return true, "", nil
+			}
+			if condition.Type == batch.JobFailed {
+				return false, "", fmt.Errorf("%s", condition.Message)
+			}
+		}
+
+		return false, fmt.Sprintf("%d succeeded, %d active", job.Status.Succeeded, job.Status.Active), nil
+	}
+
+	return true, "", nil
+}
+
+func notFoundAsNotReady(err error) (bool, string, error) {
+	if errors.IsNotFound(err) {
+		return false, "not found", nil
+	}
+
+	return false, "", err
+}
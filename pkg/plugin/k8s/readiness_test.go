@@ -0,0 +1,106 @@
+package k8s
+
+import (
+	"context"
+	"github.com/Aptomi/aptomi/pkg/event"
+	"github.com/stretchr/testify/assert"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	api "k8s.io/client-go/pkg/api/v1"
+	apps "k8s.io/client-go/pkg/apis/apps/v1beta1"
+	batch "k8s.io/client-go/pkg/apis/batch/v1"
+	"testing"
+	"time"
+)
+
+const testWorkloadsManifest = `---
+apiVersion: apps/v1beta1
+kind: Deployment
+metadata:
+  name: web
+---
+apiVersion: apps/v1beta1
+kind: StatefulSet
+metadata:
+  name: db
+---
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: migrate
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`
+
+func int32Ptr(value int32) *int32 {
+	return &value
+}
+
+func int64Ptr(value int64) *int64 {
+	return &value
+}
+
+func testObjectMeta(name string) meta.ObjectMeta {
+	return meta.ObjectMeta{Name: name, Namespace: "test", Generation: 2}
+}
+
+func TestWaitForReady(t *testing.T) {
+	readinessPollInterval = 10 * time.Millisecond
+	p := &Plugin{Namespace: "test"}
+	eventLog := event.NewLog("test-readiness", false)
+
+	deployment := &apps.Deployment{
+		ObjectMeta: testObjectMeta("web"),
+		Spec:       apps.DeploymentSpec{Replicas: int32Ptr(2)},
+		Status:     apps.DeploymentStatus{ObservedGeneration: 2, UpdatedReplicas: 2, AvailableReplicas: 1},
+	}
+	statefulSet := &apps.StatefulSet{
+		ObjectMeta: testObjectMeta("db"),
+		Status:     apps.StatefulSetStatus{ObservedGeneration: int64Ptr(2), ReadyReplicas: 1},
+	}
+	job := &batch.Job{
+		ObjectMeta: testObjectMeta("migrate"),
+		Status:     batch.JobStatus{Active: 1},
+	}
+
+	// deployment isn't available and job isn't complete yet
+	client := fake.NewSimpleClientset(deployment, statefulSet, job)
+	err := p.WaitForReady(context.Background(), client, "test", testWorkloadsManifest, 50*time.Millisecond, eventLog)
+	assert.Error(t, err, "Workloads should not be ready")
+	assert.Contains(t, err.Error(), "Deployment 'web' (1 of 2 replicas available)", "Not ready deployment should be reported")
+	assert.Contains(t, err.Error(), "Job 'migrate' (0 succeeded, 1 active)", "Not complete job should be reported")
+	assert.NotContains(t, err.Error(), "StatefulSet", "Ready stateful set should not be reported")
+
+	// missing objects aren't ready
+	err = p.WaitForReady(context.Background(), fake.NewSimpleClientset(), "test", testWorkloadsManifest, 50*time.Millisecond, eventLog)
+	assert.Error(t, err, "Missing workloads should not be ready")
+	assert.Contains(t, err.Error(), "not found", "Missing workloads should be reported")
+
+	// everything is ready
+	deployment.Status.AvailableReplicas = 2
+	job.Status = batch.JobStatus{Succeeded: 1, Conditions: []batch.JobCondition{{Type: batch.JobComplete, Status: api.ConditionTrue}}}
+	client = fake.NewSimpleClientset(deployment, statefulSet, job)
+	err = p.WaitForReady(context.Background(), client, "test", testWorkloadsManifest, time.Second, eventLog)
+	assert.NoError(t, err, "Workloads should be ready")
+
+	// failed job is reported right away
+	job.Status = batch.JobStatus{Failed: 1, Conditions: []batch.JobCondition{{Type: batch.JobFailed, Status: api.ConditionTrue, Message: "backoff limit exceeded"}}}
+	client = fake.NewSimpleClientset(deployment, statefulSet, job)
+	err = p.WaitForReady(context.Background(), client, "test", testWorkloadsManifest, time.Minute, eventLog)
+	assert.Error(t, err, "Failed job should fail waiting")
+	assert.Contains(t, err.Error(), "backoff limit exceeded", "Job failure message should be reported")
+
+	// waiting stops once context is done, even if timeout isn't reached yet
+	job.Status = batch.JobStatus{Active: 1}
+	client = fake.NewSimpleClientset(deployment, statefulSet, job)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	started := time.Now()
+	err = p.WaitForReady(ctx, client, "test", testWorkloadsManifest, time.Minute, eventLog)
+	assert.Error(t, err, "Waiting should stop once context is done")
+	assert.Contains(t, err.Error(), "stopped waiting", "Context error should be reported")
+	assert.True(t, time.Since(started) < time.Minute/2, "Waiting should not last until timeout once context is done")
+}
@@ -4,36 +4,42 @@ import (
 	"fmt"
 	"github.com/Aptomi/aptomi/pkg/event"
 	"github.com/Aptomi/aptomi/pkg/plugin"
+	"k8s.io/apimachinery/pkg/api/errors"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/pkg/api/v1"
 	"k8s.io/client-go/pkg/apis/apps/v1beta1"
+	batch "k8s.io/client-go/pkg/apis/batch/v1"
+	batchv2alpha1 "k8s.io/client-go/pkg/apis/batch/v2alpha1"
+	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+	"sort"
 	"strconv"
 	"strings"
 )
 
 var resourceRegistry = buildResourceRegistry()
 
-// ResourcesForManifest returns resources for specified manifest
+// ResourcesForManifest returns resources for specified manifest, including pods created by its workloads
 func (p *Plugin) ResourcesForManifest(deployName, targetManifest string, eventLog *event.Log) (plugin.Resources, error) {
 	kubeClient, err := p.NewClient()
 	if err != nil {
 		return nil, err
 	}
 
-	helmKube := p.NewHelmKube(deployName, eventLog)
-
-	infos, err := helmKube.BuildUnstructured(p.Namespace, strings.NewReader(targetManifest))
+	objects, err := ParseManifest(targetManifest)
 	if err != nil {
 		return nil, err
 	}
 
-	resources := make(plugin.Resources)
-	for _, info := range infos {
-		gvk := info.ResourceMapping().GroupVersionKind
-		resourceType := "k8s/" + gvk.Kind
+	return p.resourcesForObjects(kubeClient, objects)
+}
 
+func (p *Plugin) resourcesForObjects(kubeClient kubernetes.Interface, objects []*ManifestObject) (plugin.Resources, error) {
+	resources := make(plugin.Resources)
+	addResource := func(kind string, obj interface{}) {
+		resourceType := "k8s/" + kind
 		if !resourceRegistry.IsSupported(resourceType) {
-			continue
+			return
 		}
 
 		table, exist := resources[resourceType]
@@ -43,49 +49,147 @@ func (p *Plugin) ResourcesForManifest(deployName, targetManifest string, eventLo
 			table.Headers = resourceRegistry.Headers(resourceType)
 		}
 
+		table.Items = append(table.Items, resourceRegistry.Handle(resourceType, obj))
+	}
+
+	// pods aren't defined in the manifest, they are found using selectors of the workloads
+	podSelectors := make(map[string][]*meta.LabelSelector)
+
+	for _, object := range objects {
 		var getErr error
 		var obj interface{}
 
-		switch kind := info.Mapping.GroupVersionKind.Kind; kind {
+		namespace := object.getNamespace(p.Namespace)
+		name := object.Metadata.Name
+
+		switch object.Kind {
 		case "Service":
-			obj, getErr = kubeClient.CoreV1().Services(p.Namespace).Get(info.Name, meta.GetOptions{})
+			obj, getErr = kubeClient.CoreV1().Services(namespace).Get(name, meta.GetOptions{})
+		case "Pod":
+			obj, getErr = kubeClient.CoreV1().Pods(namespace).Get(name, meta.GetOptions{})
 		case "ConfigMap":
-			//obj, getErr = kubeClient.CoreV1().ConfigMaps(p.Namespace).Get(info.Name, meta.GetOptions{})
+			obj, getErr = kubeClient.CoreV1().ConfigMaps(namespace).Get(name, meta.GetOptions{})
 		case "Secret":
-			//obj, getErr = kubeClient.CoreV1().Secrets(p.Namespace).Get(info.Name, meta.GetOptions{})
+			obj, getErr = kubeClient.CoreV1().Secrets(namespace).Get(name, meta.GetOptions{})
 		case "PersistentVolumeClaim":
-			//obj, getErr = kubeClient.CoreV1().PersistentVolumeClaims(p.Namespace).Get(info.Name, meta.GetOptions{})
+			obj, getErr = kubeClient.CoreV1().PersistentVolumeClaims(namespace).Get(name, meta.GetOptions{})
+		case "Ingress":
+			obj, getErr = kubeClient.ExtensionsV1beta1().Ingresses(namespace).Get(name, meta.GetOptions{})
 		case "Deployment":
-			obj, getErr = kubeClient.AppsV1beta1().Deployments(p.Namespace).Get(info.Name, meta.GetOptions{})
+			var deployment *v1beta1.Deployment
+			deployment, getErr = kubeClient.AppsV1beta1().Deployments(namespace).Get(name, meta.GetOptions{})
+			if getErr == nil {
+				obj = deployment
+				podSelectors[namespace] = append(podSelectors[namespace], deployment.Spec.Selector)
+			}
 		case "StatefulSet":
-			obj, getErr = kubeClient.AppsV1beta1().StatefulSets(p.Namespace).Get(info.Name, meta.GetOptions{})
+			var statefulSet *v1beta1.StatefulSet
+			statefulSet, getErr = kubeClient.AppsV1beta1().StatefulSets(namespace).Get(name, meta.GetOptions{})
+			if getErr == nil {
+				obj = statefulSet
+				podSelectors[namespace] = append(podSelectors[namespace], statefulSet.Spec.Selector)
+			}
+		case "DaemonSet":
+			var daemonSet *extensions.DaemonSet
+			daemonSet, getErr = kubeClient.ExtensionsV1beta1().DaemonSets(namespace).Get(name, meta.GetOptions{})
+			if getErr == nil {
+				obj = daemonSet
+				podSelectors[namespace] = append(podSelectors[namespace], daemonSet.Spec.Selector)
+			}
 		case "Job":
-			//obj, getErr = kubeClient.BatchV1().Jobs(p.Namespace).Get(info.Name, meta.GetOptions{})
+			var job *batch.Job
+			job, getErr = kubeClient.BatchV1().Jobs(namespace).Get(name, meta.GetOptions{})
+			if getErr == nil {
+				obj = job
+				podSelectors[namespace] = append(podSelectors[namespace], job.Spec.Selector)
+			}
+		case "CronJob":
+			obj, getErr = kubeClient.BatchV2alpha1().CronJobs(namespace).Get(name, meta.GetOptions{})
 		}
 
+		// object could be already deleted or not created yet
+		if getErr != nil && errors.IsNotFound(getErr) {
+			continue
+		}
 		if getErr != nil {
 			return nil, getErr
 		}
 
-		// missing object or don't know how to load it
+		// don't know how to load it
 		if obj == nil {
 			continue
 		}
 
-		table.Items = append(table.Items, resourceRegistry.Handle(resourceType, obj))
+		addResource(object.Kind, obj)
+	}
+
+	pods, err := p.findPods(kubeClient, podSelectors)
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range pods {
+		addResource("Pod", pod)
 	}
 
 	return resources, nil
 }
 
+// findPods returns pods matching any of the given selectors (grouped by namespace), sorted by namespace and name
+func (p *Plugin) findPods(kubeClient kubernetes.Interface, podSelectors map[string][]*meta.LabelSelector) ([]*v1.Pod, error) {
+	found := make(map[string]*v1.Pod)
+	for namespace, selectors := range podSelectors {
+		for _, labelSelector := range selectors {
+			// workload without selector (or with an empty one) would match all pods in the namespace
+			if labelSelector == nil || (len(labelSelector.MatchLabels) == 0 && len(labelSelector.MatchExpressions) == 0) {
+				continue
+			}
+
+			selector, err := meta.LabelSelectorAsSelector(labelSelector)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pod selector %v: %s", labelSelector, err)
+			}
+
+			pods, err := kubeClient.CoreV1().Pods(namespace).List(meta.ListOptions{LabelSelector: selector.String()})
+			if err != nil {
+				return nil, err
+			}
+
+			for idx := range pods.Items {
+				pod := &pods.Items[idx]
+				found[pod.Namespace+"/"+pod.Name] = pod
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(found))
+	for key := range found {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := make([]*v1.Pod, len(keys))
+	for idx, key := range keys {
+		result[idx] = found[key]
+	}
+
+	return result, nil
+}
+
 func buildResourceRegistry() *plugin.ResourceRegistry {
 	reg := plugin.NewResourceRegistry()
 
-	// todo(slukjanov): support all other resources
 	// k8s/Service handling is temporarily disabled
 	reg.AddHandler("_k8s/Service", serviceResourceHeaders, serviceResourceHandler)
+	reg.AddHandler("k8s/Pod", podResourceHeaders, podResourceHandler)
+	reg.AddHandler("k8s/Ingress", ingressResourceHeaders, ingressResourceHandler)
+	reg.AddHandler("k8s/ConfigMap", configMapResourceHeaders, configMapResourceHandler)
+	reg.AddHandler("k8s/Secret", secretResourceHeaders, secretResourceHandler)
+	reg.AddHandler("k8s/PersistentVolumeClaim", pvcResourceHeaders, pvcResourceHandler)
 	reg.AddHandler("k8s/Deployment", deploymentResourceHeaders, deploymentResourceHandler)
 	reg.AddHandler("k8s/StatefulSet", statefulSetResourceHeaders, statefulSetResourceHandler)
+	reg.AddHandler("k8s/DaemonSet", daemonSetResourceHeaders, daemonSetResourceHandler)
+	reg.AddHandler("k8s/Job", jobResourceHeaders, jobResourceHandler)
+	reg.AddHandler("k8s/CronJob", cronJobResourceHeaders, cronJobResourceHandler)
 
 	return reg
 }
@@ -118,6 +222,154 @@ func serviceResourceHandler(obj interface{}) []string {
 	return []string{service.Namespace, service.Name, string(service.Spec.Type), ports, service.CreationTimestamp.String()}
 }
 
+// k8s/Pod
+
+var podResourceHeaders = []string{
+	"Namespace",
+	"Name",
+	"Ready",
+	"Status",
+	"Restarts",
+	"Containers",
+	"Node",
+	"Created",
+}
+
+func podResourceHandler(obj interface{}) []string {
+	pod := obj.(*v1.Pod)
+
+	readyContainers := 0
+	restarts := int32(0)
+	status := string(pod.Status.Phase)
+	containers := make([]string, len(pod.Status.ContainerStatuses))
+	for idx, container := range pod.Status.ContainerStatuses {
+		if container.Ready {
+			readyContainers++
+		}
+		restarts += container.RestartCount
+
+		state := "unknown"
+		if container.State.Running != nil {
+			state = "running"
+		} else if container.State.Waiting != nil {
+			state = "waiting(" + container.State.Waiting.Reason + ")"
+			if len(container.State.Waiting.Reason) > 0 {
+				status = container.State.Waiting.Reason
+			}
+		} else if container.State.Terminated != nil {
+			state = fmt.Sprintf("terminated(%s, exit code %d)", container.State.Terminated.Reason, container.State.Terminated.ExitCode)
+		}
+		containers[idx] = container.Name + ":" + state
+	}
+	if pod.DeletionTimestamp != nil {
+		status = "Terminating"
+	}
+
+	ready := fmt.Sprintf("%d/%d", readyContainers, len(pod.Spec.Containers))
+
+	return []string{pod.Namespace, pod.Name, ready, status, fmt.Sprintf("%d", restarts), strings.Join(containers, ","), pod.Spec.NodeName, pod.CreationTimestamp.String()}
+}
+
+// k8s/Ingress
+
+var ingressResourceHeaders = []string{
+	"Namespace",
+	"Name",
+	"Hosts",
+	"TLS",
+	"Address",
+	"Created",
+}
+
+func ingressResourceHandler(obj interface{}) []string {
+	ingress := obj.(*extensions.Ingress)
+
+	hosts := []string{}
+	for _, rule := range ingress.Spec.Rules {
+		if len(rule.Host) > 0 {
+			hosts = append(hosts, rule.Host)
+		}
+	}
+
+	tlsHosts := []string{}
+	for _, tls := range ingress.Spec.TLS {
+		tlsHosts = append(tlsHosts, tls.Hosts...)
+	}
+
+	addresses := []string{}
+	for _, lbIngress := range ingress.Status.LoadBalancer.Ingress {
+		addresses = append(addresses, getLoadBalancerAddress(lbIngress))
+	}
+
+	return []string{ingress.Namespace, ingress.Name, strings.Join(hosts, ","), strings.Join(tlsHosts, ","), strings.Join(addresses, ","), ingress.CreationTimestamp.String()}
+}
+
+// k8s/ConfigMap
+
+var configMapResourceHeaders = []string{
+	"Namespace",
+	"Name",
+	"Data",
+	"Created",
+}
+
+func configMapResourceHandler(obj interface{}) []string {
+	configMap := obj.(*v1.ConfigMap)
+
+	return []string{configMap.Namespace, configMap.Name, fmt.Sprintf("%d", len(configMap.Data)), configMap.CreationTimestamp.String()}
+}
+
+// k8s/Secret
+
+var secretResourceHeaders = []string{
+	"Namespace",
+	"Name",
+	"Type",
+	"Data",
+	"Created",
+}
+
+// secretResourceHandler reports only names and number of keys of the secret, never its data
+func secretResourceHandler(obj interface{}) []string {
+	secret := obj.(*v1.Secret)
+
+	return []string{secret.Namespace, secret.Name, string(secret.Type), fmt.Sprintf("%d", len(secret.Data)), secret.CreationTimestamp.String()}
+}
+
+// k8s/PersistentVolumeClaim
+
+var pvcResourceHeaders = []string{
+	"Namespace",
+	"Name",
+	"Status",
+	"Volume",
+	"Capacity",
+	"Access Modes",
+	"Storage Class",
+	"Created",
+}
+
+func pvcResourceHandler(obj interface{}) []string {
+	pvc := obj.(*v1.PersistentVolumeClaim)
+
+	capacity := ""
+	if storage, exist := pvc.Status.Capacity[v1.ResourceStorage]; exist {
+		capacity = storage.String()
+	}
+
+	accessModes := make([]string, len(pvc.Status.AccessModes))
+	for idx, accessMode := range pvc.Status.AccessModes {
+		accessModes[idx] = string(accessMode)
+	}
+
+	storageClass := ""
+	if pvc.Spec.StorageClassName != nil {
+		storageClass = *pvc.Spec.StorageClassName
+	}
+
+	return []string{pvc.Namespace, pvc.Name, string(pvc.Status.Phase), pvc.Spec.VolumeName, capacity, strings.Join(accessModes, ","), storageClass, pvc.CreationTimestamp.String()}
+}
+
 // k8s/Deployment
 
 var deploymentResourceHeaders = []string{
@@ -165,3 +417,97 @@ func statefulSetResourceHandler(obj interface{}) []string {
 
 	return []string{statefulSet.Namespace, statefulSet.Name, ready, desiredReplicas, currentReplicas}
 }
+
+// k8s/DaemonSet
+
+var daemonSetResourceHeaders = []string{
+	"Namespace",
+	"Name",
+	"Ready",
+	"Desired",
+	"Current",
+	"Up-to-date",
+	"Available",
+	"Created",
+}
+
+func daemonSetResourceHandler(obj interface{}) []string {
+	daemonSet := obj.(*extensions.DaemonSet)
+
+	desired := daemonSet.Status.DesiredNumberScheduled
+	ready := strconv.FormatBool(desired == daemonSet.Status.NumberReady && desired == daemonSet.Status.UpdatedNumberScheduled && desired == daemonSet.Status.NumberAvailable)
+
+	return []string{
+		daemonSet.Namespace,
+		daemonSet.Name,
+		ready,
+		fmt.Sprintf("%d", desired),
+		fmt.Sprintf("%d", daemonSet.Status.CurrentNumberScheduled),
+		fmt.Sprintf("%d", daemonSet.Status.UpdatedNumberScheduled),
+		fmt.Sprintf("%d", daemonSet.Status.NumberAvailable),
+		daemonSet.CreationTimestamp.String(),
+	}
+}
+
+// k8s/Job
+
+var jobResourceHeaders = []string{
+	"Namespace",
+	"Name",
+	"Completions",
+	"Successful",
+	"Active",
+	"Failed",
+	"Created",
+}
+
+func jobResourceHandler(obj interface{}) []string {
+	job := obj.(*batch.Job)
+
+	completions := int32(1)
+	if job.Spec.Completions != nil {
+		completions = *job.Spec.Completions
+	}
+
+	return []string{
+		job.Namespace,
+		job.Name,
+		fmt.Sprintf("%d", completions),
+		fmt.Sprintf("%d", job.Status.Succeeded),
+		fmt.Sprintf("%d", job.Status.Active),
+		fmt.Sprintf("%d", job.Status.Failed),
+		job.CreationTimestamp.String(),
+	}
+}
+
+// k8s/CronJob
+
+var cronJobResourceHeaders = []string{
+	"Namespace",
+	"Name",
+	"Schedule",
+	"Suspend",
+	"Active",
+	"Last Schedule",
+	"Created",
+}
+
+func cronJobResourceHandler(obj interface{}) []string {
+	cronJob := obj.(*batchv2alpha1.CronJob)
+
+	suspend := cronJob.Spec.Suspend != nil && *cronJob.Spec.Suspend
+	lastSchedule := ""
+	if cronJob.Status.LastScheduleTime != nil {
+		lastSchedule = cronJob.Status.LastScheduleTime.String()
+	}
+
+	return []string{
+		cronJob.Namespace,
+		cronJob.Name,
+		cronJob.Spec.Schedule,
+		strconv.FormatBool(suspend),
+		fmt.Sprintf("%d", len(cronJob.Status.Active)),
+		lastSchedule,
+		cronJob.CreationTimestamp.String(),
+	}
+}
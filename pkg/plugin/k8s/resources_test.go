@@ -0,0 +1,148 @@
+package k8s
+
+import (
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	api "k8s.io/client-go/pkg/api/v1"
+	apps "k8s.io/client-go/pkg/apis/apps/v1beta1"
+	batchv2alpha1 "k8s.io/client-go/pkg/apis/batch/v2alpha1"
+	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+	"testing"
+)
+
+const testResourcesManifest = `---
+apiVersion: apps/v1beta1
+kind: Deployment
+metadata:
+  name: web
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web-config
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: web-secret
+---
+apiVersion: v1
+kind: PersistentVolumeClaim
+metadata:
+  name: web-data
+---
+apiVersion: extensions/v1beta1
+kind: Ingress
+metadata:
+  name: web
+---
+apiVersion: batch/v2alpha1
+kind: CronJob
+metadata:
+  name: cleanup
+---
+apiVersion: extensions/v1beta1
+kind: DaemonSet
+metadata:
+  name: missing
+`
+
+func TestResourcesForObjects(t *testing.T) {
+	p := &Plugin{Namespace: "test"}
+	storageClass := "fast"
+
+	client := fake.NewSimpleClientset(
+		&apps.Deployment{
+			ObjectMeta: meta.ObjectMeta{Name: "web", Namespace: "test"},
+			Spec: apps.DeploymentSpec{
+				Replicas: int32Ptr(1),
+				Selector: &meta.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+			},
+		},
+		&api.Pod{
+			ObjectMeta: meta.ObjectMeta{Name: "web-1", Namespace: "test", Labels: map[string]string{"app": "web"}},
+			Spec:       api.PodSpec{NodeName: "node-1", Containers: []api.Container{{Name: "web"}, {Name: "sidecar"}}},
+			Status: api.PodStatus{
+				Phase: api.PodRunning,
+				ContainerStatuses: []api.ContainerStatus{
+					{Name: "web", Ready: true, RestartCount: 1, State: api.ContainerState{Running: &api.ContainerStateRunning{}}},
+					{Name: "sidecar", RestartCount: 2, State: api.ContainerState{Waiting: &api.ContainerStateWaiting{Reason: "CrashLoopBackOff"}}},
+				},
+			},
+		},
+		&api.Pod{
+			ObjectMeta: meta.ObjectMeta{Name: "other", Namespace: "test", Labels: map[string]string{"app": "other"}},
+		},
+		&api.ConfigMap{
+			ObjectMeta: meta.ObjectMeta{Name: "web-config", Namespace: "test"},
+			Data:       map[string]string{"a": "1", "b": "2"},
+		},
+		&api.Secret{
+			ObjectMeta: meta.ObjectMeta{Name: "web-secret", Namespace: "test"},
+			Type:       api.SecretTypeOpaque,
+			Data:       map[string][]byte{"password": []byte("very-secret")},
+		},
+		&api.PersistentVolumeClaim{
+			ObjectMeta: meta.ObjectMeta{Name: "web-data", Namespace: "test"},
+			Spec:       api.PersistentVolumeClaimSpec{VolumeName: "pv-1", StorageClassName: &storageClass},
+			Status: api.PersistentVolumeClaimStatus{
+				Phase:       api.ClaimBound,
+				AccessModes: []api.PersistentVolumeAccessMode{api.ReadWriteOnce},
+				Capacity:    api.ResourceList{api.ResourceStorage: resource.MustParse("1Gi")},
+			},
+		},
+		&extensions.Ingress{
+			ObjectMeta: meta.ObjectMeta{Name: "web", Namespace: "test"},
+			Spec: extensions.IngressSpec{
+				Rules: []extensions.IngressRule{{Host: "web.example.com"}},
+				TLS:   []extensions.IngressTLS{{Hosts: []string{"web.example.com"}}},
+			},
+			Status: extensions.IngressStatus{LoadBalancer: api.LoadBalancerStatus{Ingress: []api.LoadBalancerIngress{{IP: "10.0.0.1"}}}},
+		},
+		&batchv2alpha1.CronJob{
+			ObjectMeta: meta.ObjectMeta{Name: "cleanup", Namespace: "test"},
+			Spec:       batchv2alpha1.CronJobSpec{Schedule: "*/5 * * * *"},
+		},
+	)
+
+	objects, err := ParseManifest(testResourcesManifest)
+	assert.NoError(t, err, "Manifest should be parsed")
+
+	resources, err := p.resourcesForObjects(client, objects)
+	assert.NoError(t, err, "Resources should be loaded")
+
+	assert.Len(t, resources, 7, "All existing objects should be reported, missing ones should be skipped")
+
+	if assert.Contains(t, resources, "k8s/Pod", "Pods of the deployment should be reported") {
+		pods := resources["k8s/Pod"]
+		if assert.Len(t, pods.Items, 1, "Only pods matching deployment selector should be reported") {
+			assert.Equal(t, []string{"test", "web-1", "1/2", "CrashLoopBackOff", "3", "web:running,sidecar:waiting(CrashLoopBackOff)", "node-1", pods.Items[0][7]}, pods.Items[0], "Pod should be reported with restarts and container states")
+		}
+	}
+
+	if assert.Contains(t, resources, "k8s/Secret", "Secrets should be reported") {
+		secret := resources["k8s/Secret"].Items[0]
+		assert.Equal(t, []string{"test", "web-secret", "Opaque", "1"}, secret[:4], "Secret should be reported")
+		for _, column := range secret {
+			assert.NotContains(t, column, "very-secret", "Secret data should never be reported")
+		}
+	}
+
+	if assert.Contains(t, resources, "k8s/PersistentVolumeClaim", "PVCs should be reported") {
+		assert.Equal(t, []string{"test", "web-data", "Bound", "pv-1", "1Gi", "ReadWriteOnce", "fast"}, resources["k8s/PersistentVolumeClaim"].Items[0][:7], "PVC should be reported")
+	}
+
+	if assert.Contains(t, resources, "k8s/Ingress", "Ingresses should be reported") {
+		assert.Equal(t, []string{"test", "web", "web.example.com", "web.example.com", "10.0.0.1"}, resources["k8s/Ingress"].Items[0][:5], "Ingress should be reported")
+	}
+
+	if assert.Contains(t, resources, "k8s/ConfigMap", "ConfigMaps should be reported") {
+		assert.Equal(t, []string{"test", "web-config", "2"}, resources["k8s/ConfigMap"].Items[0][:3], "ConfigMap should be reported")
+	}
+
+	if assert.Contains(t, resources, "k8s/CronJob", "CronJobs should be reported") {
+		assert.Equal(t, []string{"test", "cleanup", "*/5 * * * *", "false", "0", ""}, resources["k8s/CronJob"].Items[0][:6], "CronJob should be reported")
+	}
+}
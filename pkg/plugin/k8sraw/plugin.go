@@ -1,6 +1,7 @@
 package k8sraw
 
 import (
+	"context"
 	"fmt"
 	"github.com/Aptomi/aptomi/pkg/config"
 	"github.com/Aptomi/aptomi/pkg/event"
@@ -9,7 +10,9 @@ import (
 	"github.com/Aptomi/aptomi/pkg/plugin/k8s"
 	"github.com/Aptomi/aptomi/pkg/util"
 	"github.com/Aptomi/aptomi/pkg/util/sync"
+	"k8s.io/apimachinery/pkg/api/errors"
 	"strings"
+	"time"
 )
 
 // Plugin represents Kubernetes Raw code plugin that supports deploying specified k8s objects into the cluster
@@ -21,6 +24,12 @@ type Plugin struct {
 	dataNamespace string
 }
 
+var _ plugin.CodePlugin = &Plugin{}
+var _ plugin.DeploymentInspector = &Plugin{}
+var _ plugin.DeploymentLister = &Plugin{}
+var _ plugin.IngressPolicyManager = &Plugin{}
+var _ plugin.ReadinessChecker = &Plugin{}
+
 // New returns new instance of the Kubernetes Raw code (objects) plugin for specified Kubernetes cluster plugin and plugins config
 func New(clusterPlugin plugin.ClusterPlugin, cfg config.Plugins) (plugin.CodePlugin, error) {
 	kubePlugin, ok := clusterPlugin.(*k8s.Plugin)
@@ -28,9 +37,14 @@ func New(clusterPlugin plugin.ClusterPlugin, cfg config.Plugins) (plugin.CodePlu
 		return nil, fmt.Errorf("k8s cluster plugin expected for k8sraw code plugin creation but received: %T", clusterPlugin)
 	}
 
+	k8sRawConfig := cfg.K8sRaw
+	if k8sRawConfig.WaitTimeout == 0 {
+		k8sRawConfig.WaitTimeout = 5 * time.Minute
+	}
+
 	return &Plugin{
 		cluster: kubePlugin.Cluster,
-		config:  cfg.K8sRaw,
+		config:  k8sRawConfig,
 		kube:    kubePlugin,
 	}, nil
 }
@@ -62,7 +76,13 @@ func (p *Plugin) Cleanup() error {
 }
 
 // Create implements creation of a new component instance in the cloud by deploying raw k8s objects
-func (p *Plugin) Create(deployName string, params util.NestedParameterMap, eventLog *event.Log) error {
+func (p *Plugin) Create(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) error {
+	return sync.RunWithContext(ctx, func() error {
+		return p.create(ctx, deployName, params, eventLog)
+	})
+}
+
+func (p *Plugin) create(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) error {
 	err := p.init()
 	if err != nil {
 		return err
@@ -78,18 +98,40 @@ func (p *Plugin) Create(deployName string, params util.NestedParameterMap, event
 		return fmt.Errorf("manifest is a mandatory parameter")
 	}
 
-	client := p.kube.NewHelmKube(deployName, eventLog)
+	waitTimeout, err := p.getWaitTimeout(params)
+	if err != nil {
+		return err
+	}
+
+	// manifest could be already stored by the previous attempt, which failed after creating objects (e.g. they didn't
+	// become ready in time), so objects get updated against it instead of failing to be created again
+	currentManifest, _, err := p.findManifest(kubeClient, deployName)
+	if err != nil {
+		return err
+	}
 
-	err = client.Create(p.kube.Namespace, strings.NewReader(targetManifest), 42, false)
+	applyErr := p.kube.ApplyManifest(ctx, deployName, currentManifest, targetManifest, eventLog)
+
+	// manifest gets stored even if apply failed or context is done, as some objects could be already created
+	err = p.storeManifest(kubeClient, deployName, targetManifest)
 	if err != nil {
 		return err
 	}
+	if applyErr != nil {
+		return applyErr
+	}
 
-	return p.storeManifest(kubeClient, deployName, targetManifest)
+	return p.waitForReady(ctx, kubeClient, deployName, targetManifest, waitTimeout, eventLog)
 }
 
 // Update implements update of an existing component instance in the cloud by updating raw k8s objects
-func (p *Plugin) Update(deployName string, params util.NestedParameterMap, eventLog *event.Log) error {
+func (p *Plugin) Update(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) error {
+	return sync.RunWithContext(ctx, func() error {
+		return p.update(ctx, deployName, params, eventLog)
+	})
+}
+
+func (p *Plugin) update(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) error {
 	err := p.init()
 	if err != nil {
 		return err
@@ -110,18 +152,33 @@ func (p *Plugin) Update(deployName string, params util.NestedParameterMap, event
 		return fmt.Errorf("manifest is a mandatory parameter")
 	}
 
-	client := p.kube.NewHelmKube(deployName, eventLog)
+	waitTimeout, err := p.getWaitTimeout(params)
+	if err != nil {
+		return err
+	}
 
-	err = client.Update(p.kube.Namespace, strings.NewReader(currentManifest), strings.NewReader(targetManifest), false, false, 42, false)
+	err = p.kube.ApplyManifest(ctx, deployName, currentManifest, targetManifest, eventLog)
 	if err != nil {
 		return err
 	}
 
-	return p.storeManifest(kubeClient, deployName, targetManifest)
+	// manifest gets stored even if context is done, as objects have been already updated
+	err = p.storeManifest(kubeClient, deployName, targetManifest)
+	if err != nil {
+		return err
+	}
+
+	return p.waitForReady(ctx, kubeClient, deployName, targetManifest, waitTimeout, eventLog)
 }
 
 // Destroy implements destruction of an existing component instance in the cloud by deleting raw k8s objects
-func (p *Plugin) Destroy(deployName string, params util.NestedParameterMap, eventLog *event.Log) error {
+func (p *Plugin) Destroy(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) error {
+	return sync.RunWithContext(ctx, func() error {
+		return p.destroy(ctx, deployName, params, eventLog)
+	})
+}
+
+func (p *Plugin) destroy(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) error {
 	err := p.init()
 	if err != nil {
 		return err
@@ -137,9 +194,12 @@ func (p *Plugin) Destroy(deployName string, params util.NestedParameterMap, even
 		return fmt.Errorf("manifest is a mandatory parameter")
 	}
 
-	client := p.kube.NewHelmKube(deployName, eventLog)
+	err = p.kube.DeleteManifest(ctx, deployName, deleteManifest, eventLog)
+	if err != nil {
+		return err
+	}
 
-	err = client.Delete(p.kube.Namespace, strings.NewReader(deleteManifest))
+	err = p.kube.DeleteIngressPolicy(kubeClient, deployName, eventLog)
 	if err != nil {
 		return err
 	}
@@ -148,7 +208,17 @@ func (p *Plugin) Destroy(deployName string, params util.NestedParameterMap, even
 }
 
 // Endpoints returns map from port type to url for all services of the deployed raw k8s objects
-func (p *Plugin) Endpoints(deployName string, params util.NestedParameterMap, eventLog *event.Log) (map[string]string, error) {
+func (p *Plugin) Endpoints(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) (map[string]string, error) {
+	var result map[string]string
+	err := sync.RunWithContext(ctx, func() error {
+		var err error
+		result, err = p.endpoints(deployName, params, eventLog)
+		return err
+	})
+	return result, err
+}
+
+func (p *Plugin) endpoints(deployName string, params util.NestedParameterMap, eventLog *event.Log) (map[string]string, error) {
 	err := p.init()
 	if err != nil {
 		return nil, err
@@ -163,7 +233,17 @@ func (p *Plugin) Endpoints(deployName string, params util.NestedParameterMap, ev
 }
 
 // Resources returns list of all resources (like services, config maps, etc.) into the cluster by specified component instance
-func (p *Plugin) Resources(deployName string, params util.NestedParameterMap, eventLog *event.Log) (plugin.Resources, error) {
+func (p *Plugin) Resources(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) (plugin.Resources, error) {
+	var result plugin.Resources
+	err := sync.RunWithContext(ctx, func() error {
+		var err error
+		result, err = p.resources(deployName, params, eventLog)
+		return err
+	})
+	return result, err
+}
+
+func (p *Plugin) resources(deployName string, params util.NestedParameterMap, eventLog *event.Log) (plugin.Resources, error) {
 	err := p.init()
 	if err != nil {
 		return nil, err
@@ -176,3 +256,122 @@ func (p *Plugin) Resources(deployName string, params util.NestedParameterMap, ev
 
 	return p.kube.ResourcesForManifest(deployName, targetManifest, eventLog)
 }
+
+// Inspect checks that stored manifest for the specified component instance exists and matches the provided one, as
+// well as that all objects from the manifest exist in the cluster
+func (p *Plugin) Inspect(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) (*plugin.DeploymentStatus, error) {
+	var result *plugin.DeploymentStatus
+	err := sync.RunWithContext(ctx, func() error {
+		var err error
+		result, err = p.inspect(deployName, params, eventLog)
+		return err
+	})
+	return result, err
+}
+
+func (p *Plugin) inspect(deployName string, params util.NestedParameterMap, eventLog *event.Log) (*plugin.DeploymentStatus, error) {
+	err := p.init()
+	if err != nil {
+		return nil, err
+	}
+
+	kubeClient, err := p.kube.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	targetManifest, ok := params["manifest"].(string)
+	if !ok {
+		return nil, fmt.Errorf("manifest is a mandatory parameter")
+	}
+
+	currentManifest, found, err := p.findManifest(kubeClient, deployName)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return &plugin.DeploymentStatus{
+			Message: fmt.Sprintf("no stored manifest found for deployment %s", deployName),
+		}, nil
+	}
+
+	client := p.kube.NewHelmKube(deployName, eventLog)
+	infos, err := client.BuildUnstructured(p.kube.Namespace, strings.NewReader(currentManifest))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, info := range infos {
+		getErr := info.Get()
+		if getErr != nil {
+			if errors.IsNotFound(getErr) {
+				return &plugin.DeploymentStatus{
+					Message: fmt.Sprintf("%s '%s' not found in the cluster", info.Mapping.GroupVersionKind.Kind, info.Name),
+				}, nil
+			}
+			return nil, getErr
+		}
+	}
+
+	if currentManifest != targetManifest {
+		return &plugin.DeploymentStatus{
+			Exists:  true,
+			Message: fmt.Sprintf("stored manifest for deployment %s differs from the code params", deployName),
+		}, nil
+	}
+
+	return &plugin.DeploymentStatus{Exists: true, Matches: true}, nil
+}
+
+// List returns all deployments of raw k8s objects in the cluster, which manifests are stored by Aptomi
+func (p *Plugin) List(ctx context.Context, eventLog *event.Log) (map[string]util.NestedParameterMap, error) {
+	var result map[string]util.NestedParameterMap
+	err := sync.RunWithContext(ctx, func() error {
+		var err error
+		result, err = p.list(eventLog)
+		return err
+	})
+	return result, err
+}
+
+func (p *Plugin) list(eventLog *event.Log) (map[string]util.NestedParameterMap, error) {
+	err := p.init()
+	if err != nil {
+		return nil, err
+	}
+
+	kubeClient, err := p.kube.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	manifests, err := p.listManifests(kubeClient)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]util.NestedParameterMap)
+	for deployName, manifest := range manifests {
+		result[deployName] = util.NestedParameterMap{"manifest": manifest}
+	}
+
+	return result, nil
+}
+
+// ApplyIngressPolicy restricts ingress traffic to the pods of the deployed raw k8s objects, which are expected to be
+// labeled with the deploy name (see k8s.DeploymentPodLabel)
+func (p *Plugin) ApplyIngressPolicy(ctx context.Context, deployName string, policy *plugin.IngressPolicy, eventLog *event.Log) error {
+	return sync.RunWithContext(ctx, func() error {
+		err := p.init()
+		if err != nil {
+			return err
+		}
+
+		kubeClient, err := p.kube.NewClient()
+		if err != nil {
+			return err
+		}
+
+		return p.kube.ApplyIngressPolicy(kubeClient, deployName, policy, eventLog)
+	})
+}
@@ -0,0 +1,90 @@
+package k8sraw
+
+import (
+	"github.com/Aptomi/aptomi/pkg/config"
+	"github.com/Aptomi/aptomi/pkg/lang"
+	"github.com/Aptomi/aptomi/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/kubernetes/fake"
+	"testing"
+	"time"
+)
+
+func newTestPlugin() *Plugin {
+	return &Plugin{
+		cluster:       &lang.Cluster{Metadata: lang.Metadata{Name: "cluster-us-east"}},
+		config:        config.K8sRaw{WaitTimeout: 5 * time.Minute},
+		dataNamespace: "aptomi",
+	}
+}
+
+func TestManifestStore(t *testing.T) {
+	p := newTestPlugin()
+	client := fake.NewSimpleClientset()
+
+	// missing manifest isn't an error for create, so it could be told apart from the one stored by a failed attempt
+	manifest, found, err := p.findManifest(client, "a-0123456789abc")
+	assert.NoError(t, err, "Missing manifest should not be an error")
+	assert.False(t, found, "Missing manifest should not be found")
+	assert.Empty(t, manifest, "Missing manifest should be empty")
+	_, err = p.loadManifest(client, "a-0123456789abc")
+	assert.Error(t, err, "Missing manifest should not be loaded")
+
+	// manifest stored by create, which failed waiting for objects, is found by the next create attempt
+	assert.NoError(t, p.storeManifest(client, "a-0123456789abc", "kind: Service\n"), "Manifest should be stored")
+	manifest, found, err = p.findManifest(client, "a-0123456789abc")
+	assert.NoError(t, err, "Manifest should be found")
+	assert.True(t, found, "Stored manifest should be found")
+	assert.Equal(t, "kind: Service\n", manifest, "Stored manifest should be found")
+
+	// manifest gets overwritten by the next attempt
+	assert.NoError(t, p.storeManifest(client, "a-0123456789abc", "kind: Deployment\n"), "Manifest should be updated")
+	manifest, err = p.loadManifest(client, "a-0123456789abc")
+	assert.NoError(t, err, "Manifest should be loaded")
+	assert.Equal(t, "kind: Deployment\n", manifest, "Manifest should be updated")
+
+	// only manifests of deployments created by Aptomi are listed
+	assert.NoError(t, p.storeManifest(client, "redis", "kind: Service\n"), "Manifest should be stored")
+	manifests, err := p.listManifests(client)
+	assert.NoError(t, err, "Manifests should be listed")
+	assert.Equal(t, map[string]string{"a-0123456789abc": "kind: Deployment\n"}, manifests, "Only manifests of deployments created by Aptomi should be listed")
+
+	// delete manifest
+	assert.NoError(t, p.deleteManifest(client, "a-0123456789abc"), "Manifest should be deleted")
+	assert.NoError(t, p.deleteManifest(client, "a-0123456789abc"), "Missing manifest should be deleted without error")
+	_, found, err = p.findManifest(client, "a-0123456789abc")
+	assert.NoError(t, err, "Missing manifest should not be an error")
+	assert.False(t, found, "Deleted manifest should not be found")
+}
+
+func TestWaitTimeout(t *testing.T) {
+	p := newTestPlugin()
+
+	for _, tc := range []struct {
+		params  util.NestedParameterMap
+		wait    time.Duration
+		ready   time.Duration
+		invalid bool
+	}{
+		{params: util.NestedParameterMap{}, wait: 0, ready: 5 * time.Minute},
+		{params: util.NestedParameterMap{"waitForReady": true}, wait: 5 * time.Minute, ready: 5 * time.Minute},
+		{params: util.NestedParameterMap{"waitForReady": true, "waitTimeout": "30s"}, wait: 30 * time.Second, ready: 30 * time.Second},
+		{params: util.NestedParameterMap{"waitForReady": false, "waitTimeout": "30s"}, wait: 0, ready: 30 * time.Second},
+		{params: util.NestedParameterMap{"waitForReady": "yes"}, invalid: true},
+		{params: util.NestedParameterMap{"waitForReady": true, "waitTimeout": "soon"}, invalid: true},
+		{params: util.NestedParameterMap{"waitForReady": true, "waitTimeout": "-5s"}, invalid: true},
+	} {
+		wait, err := p.getWaitTimeout(tc.params)
+		if tc.invalid {
+			assert.Error(t, err, "Invalid params should be rejected: %v", tc.params)
+			continue
+		}
+		assert.NoError(t, err, "Valid params should be accepted: %v", tc.params)
+		assert.Equal(t, tc.wait, wait, "Wait timeout should be taken from params: %v", tc.params)
+
+		// readiness check requested by update strategy waits regardless of waitForReady param
+		ready, err := p.getReadinessTimeout(tc.params)
+		assert.NoError(t, err, "Valid params should be accepted: %v", tc.params)
+		assert.Equal(t, tc.ready, ready, "Readiness timeout should be taken from params: %v", tc.params)
+	}
+}
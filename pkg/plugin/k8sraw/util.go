@@ -2,6 +2,7 @@ package k8sraw
 
 import (
 	"fmt"
+	"github.com/Aptomi/aptomi/pkg/engine/resolve"
 	"k8s.io/apimachinery/pkg/api/errors"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
@@ -48,22 +49,58 @@ func (p *Plugin) storeManifest(client kubernetes.Interface, deployName, manifest
 }
 
 func (p *Plugin) loadManifest(client kubernetes.Interface, deployName string) (string, error) {
+	manifest, found, err := p.findManifest(client, deployName)
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return "", fmt.Errorf("can't find data for deployment %s (should be stored in configmap %s/%s)", deployName, p.dataNamespace, p.getManifestConfigMapName(deployName))
+	}
+
+	return manifest, nil
+}
+
+// findManifest returns stored manifest for the deployment, while not treating missing manifest as an error
+func (p *Plugin) findManifest(client kubernetes.Interface, deployName string) (string, bool, error) {
 	name := p.getManifestConfigMapName(deployName)
 
 	cm, err := client.CoreV1().ConfigMaps(p.dataNamespace).Get(name, meta.GetOptions{})
 	if err != nil {
 		if errors.IsNotFound(err) {
-			return "", fmt.Errorf("can't find data for deployment %s (should be stored in namespace %s): %s", deployName, p.dataNamespace, err)
+			return "", false, nil
 		}
-		return "", err
+		return "", false, err
 	}
 
 	manifest := cm.Data["manifest"]
 	if len(manifest) == 0 {
-		return "", fmt.Errorf("no manifest found in data for deployment %s (stored in configmap %s/%s", deployName, p.dataNamespace, name)
+		return "", false, nil
 	}
 
-	return manifest, nil
+	return manifest, true, nil
+}
+
+// listManifests returns a map from deploy name to stored manifest for all deployments in the cluster
+func (p *Plugin) listManifests(client kubernetes.Interface) (map[string]string, error) {
+	prefix := p.getManifestConfigMapName("")
+
+	cms, err := client.CoreV1().ConfigMaps(p.dataNamespace).List(meta.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]string)
+	for _, cm := range cms.Items {
+		if !strings.HasPrefix(cm.Name, prefix) {
+			continue
+		}
+		deployName := strings.TrimPrefix(cm.Name, prefix)
+		if resolve.IsDeployName(deployName) && len(cm.Data["manifest"]) > 0 {
+			result[deployName] = cm.Data["manifest"]
+		}
+	}
+
+	return result, nil
 }
 
 func (p *Plugin) deleteManifest(client kubernetes.Interface, deployName string) error {
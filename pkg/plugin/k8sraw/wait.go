@@ -0,0 +1,86 @@
+package k8sraw
+
+import (
+	"context"
+	"fmt"
+	"github.com/Aptomi/aptomi/pkg/event"
+	"github.com/Aptomi/aptomi/pkg/util"
+	"github.com/Aptomi/aptomi/pkg/util/sync"
+	"k8s.io/client-go/kubernetes"
+	"time"
+)
+
+// getWaitTimeout returns how long to wait for workloads to become ready, based on waitForReady and waitTimeout code
+// params. Zero is returned if waiting is disabled
+func (p *Plugin) getWaitTimeout(params util.NestedParameterMap) (time.Duration, error) {
+	waitForReady := false
+	if value, exist := params["waitForReady"]; exist {
+		boolValue, ok := value.(bool)
+		if !ok {
+			return 0, fmt.Errorf("waitForReady should be a bool, but got: %T", value)
+		}
+		waitForReady = boolValue
+	}
+
+	if !waitForReady {
+		return 0, nil
+	}
+
+	return p.getReadinessTimeout(params)
+}
+
+// getReadinessTimeout returns how long to wait for workloads to become ready, based on waitTimeout code param
+func (p *Plugin) getReadinessTimeout(params util.NestedParameterMap) (time.Duration, error) {
+	value, exist := params["waitTimeout"]
+	if !exist {
+		return p.config.WaitTimeout, nil
+	}
+
+	strValue, ok := value.(string)
+	if !ok {
+		return 0, fmt.Errorf("waitTimeout should be a duration string (e.g. 5m), but got: %T", value)
+	}
+	timeout, err := time.ParseDuration(strValue)
+	if err != nil || timeout <= 0 {
+		return 0, fmt.Errorf("waitTimeout should be a positive duration (e.g. 5m), but got: %s", strValue)
+	}
+
+	return timeout, nil
+}
+
+// waitForReady waits for workloads from the manifest to become ready, if waiting is enabled (timeout isn't zero)
+func (p *Plugin) waitForReady(ctx context.Context, client kubernetes.Interface, deployName, manifest string, timeout time.Duration, eventLog *event.Log) error {
+	if timeout == 0 {
+		return nil
+	}
+
+	return p.kube.WaitForReady(ctx, client, deployName, manifest, timeout, eventLog)
+}
+
+// WaitForReady waits for workloads of the deployed raw k8s objects to become ready, regardless of whether waiting is
+// enabled by the waitForReady code param
+func (p *Plugin) WaitForReady(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) error {
+	return sync.RunWithContext(ctx, func() error {
+		err := p.init()
+		if err != nil {
+			return err
+		}
+
+		kubeClient, err := p.kube.NewClient()
+		if err != nil {
+			return err
+		}
+
+		targetManifest, ok := params["manifest"].(string)
+		if !ok {
+			return fmt.Errorf("manifest is a mandatory parameter")
+		}
+
+		timeout, err := p.getReadinessTimeout(params)
+		if err != nil {
+			return err
+		}
+
+		return p.kube.WaitForReady(ctx, kubeClient, deployName, targetManifest, timeout, eventLog)
+	})
+}
@@ -0,0 +1,176 @@
+package local
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/Aptomi/aptomi/pkg/util"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ClusterConfig represents local cluster plugin configuration
+type ClusterConfig struct {
+	// Host is an address used in endpoints of the processes (127.0.0.1 by default)
+	Host string `yaml:",omitempty"`
+
+	// Dir is a base directory for processes working directories (server working directory by default)
+	Dir string `yaml:",omitempty"`
+}
+
+func (p *Plugin) parseClusterConfig() error {
+	clusterConfig := &ClusterConfig{}
+	if p.Cluster.Config != nil {
+		err := p.Cluster.ParseConfigInto(clusterConfig)
+		if err != nil {
+			return fmt.Errorf("error while parsing local specific config of cluster %s: %s", p.Cluster.Name, err)
+		}
+	}
+
+	p.Host = clusterConfig.Host
+	if len(p.Host) == 0 {
+		p.Host = "127.0.0.1"
+	}
+	p.Dir = clusterConfig.Dir
+
+	return nil
+}
+
+// Restart policies for the process
+const (
+	RestartAlways    = "always"
+	RestartOnFailure = "on-failure"
+	RestartNever     = "never"
+)
+
+// processSpec is a specification of the process, which is parsed from code params
+type processSpec struct {
+	Command string
+	Args    []string
+	Env     []string
+	Dir     string
+	Restart string
+	Ports   map[string]int
+}
+
+func (p *Plugin) parseProcessSpec(params util.NestedParameterMap) (*processSpec, error) {
+	spec := &processSpec{
+		Ports: make(map[string]int),
+	}
+
+	var ok bool
+	if spec.Command, ok = params["command"].(string); !ok || len(spec.Command) == 0 {
+		return nil, fmt.Errorf("command is a mandatory parameter")
+	}
+
+	args, err := params.GetString("args", "")
+	if err != nil {
+		return nil, err
+	}
+	spec.Args, err = splitArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	if env, exist := params["env"]; exist {
+		envMap, isMap := env.(util.NestedParameterMap)
+		if !isMap {
+			return nil, fmt.Errorf("env should be a map")
+		}
+		for _, name := range util.GetSortedStringKeys(envMap) {
+			spec.Env = append(spec.Env, fmt.Sprintf("%s=%v", name, envMap[name]))
+		}
+	}
+
+	spec.Dir, err = params.GetString("dir", "")
+	if err != nil {
+		return nil, err
+	}
+	if len(spec.Dir) == 0 {
+		spec.Dir = p.Dir
+	} else if len(p.Dir) > 0 && !filepath.IsAbs(spec.Dir) {
+		spec.Dir = filepath.Join(p.Dir, spec.Dir)
+	}
+
+	spec.Restart, err = params.GetString("restart", RestartOnFailure)
+	if err != nil {
+		return nil, err
+	}
+	if spec.Restart != RestartAlways && spec.Restart != RestartOnFailure && spec.Restart != RestartNever {
+		return nil, fmt.Errorf("restart should be one of %s, %s, %s, but got: %s", RestartAlways, RestartOnFailure, RestartNever, spec.Restart)
+	}
+
+	if ports, exist := params["ports"]; exist {
+		portsMap, isMap := ports.(util.NestedParameterMap)
+		if !isMap {
+			return nil, fmt.Errorf("ports should be a map")
+		}
+		for name, value := range portsMap {
+			port, convErr := strconv.Atoi(fmt.Sprint(value))
+			if convErr != nil || port <= 0 || port > 65535 {
+				return nil, fmt.Errorf("port %s is not valid: %v", name, value)
+			}
+			spec.Ports[name] = port
+		}
+	}
+
+	return spec, nil
+}
+
+// endpoints returns endpoints of the process derived from its declared ports
+func (p *Plugin) endpoints(spec *processSpec) map[string]string {
+	endpoints := make(map[string]string)
+	for name, port := range spec.Ports {
+		sURL := fmt.Sprintf("%s:%d", p.Host, port)
+		if strings.Contains(name, "https") {
+			sURL = "https://" + sURL
+		} else if util.StringContainsAny(name, "ui", "rest", "http") {
+			sURL = "http://" + sURL
+		}
+		endpoints[name] = sURL
+	}
+	return endpoints
+}
+
+// String returns a command line of the process
+func (spec *processSpec) String() string {
+	return strings.Join(append([]string{spec.Command}, spec.Args...), " ")
+}
+
+// splitArgs splits a given string into arguments by whitespace, respecting single and double quotes
+func splitArgs(str string) ([]string, error) {
+	result := []string{}
+	var arg bytes.Buffer
+	inArg := false
+	var quote rune
+	for _, r := range str {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			arg.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case unicode.IsSpace(r):
+			if inArg {
+				result = append(result, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in args: %s", str)
+	}
+	if inArg {
+		result = append(result, arg.String())
+	}
+
+	return result, nil
+}
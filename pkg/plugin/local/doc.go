@@ -0,0 +1,19 @@
+// Package local implements support for the local cluster type, which runs components as processes on the Aptomi
+// host. It provides exec code plugin, which starts a component as a local process supervised by Aptomi server
+// (restarting it according to its restart policy and capturing its output into the event log).
+//
+// Local cluster is a lightweight target for dev laptops and CI, as it doesn't require Kubernetes or any other cloud.
+// Processes are supervised by the running server only, so they are not re-attached after server restart. Their PIDs are
+// persisted in the state dir (plugins.local.statedir) and processes left running by the previous server run are killed
+// on startup, so there are never two copies of the same process. Drift detection will report them as missing and
+// they will be re-created.
+//
+// Exec code plugin accepts the following code params:
+//
+//	command - executable to run (mandatory)
+//	args    - whitespace-separated list of arguments (single and double quotes could be used to group them)
+//	env     - map of environment variables to set in addition to the server environment
+//	dir     - working directory (relative paths are resolved against cluster dir)
+//	restart - restart policy: always, on-failure (default) or never
+//	ports   - map from endpoint name to the port process listens on, endpoints are derived from it
+package local
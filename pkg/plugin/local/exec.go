@@ -0,0 +1,208 @@
+package local
+
+import (
+	"context"
+	"fmt"
+	"github.com/Aptomi/aptomi/pkg/config"
+	"github.com/Aptomi/aptomi/pkg/event"
+	"github.com/Aptomi/aptomi/pkg/plugin"
+	"github.com/Aptomi/aptomi/pkg/util"
+	"strconv"
+	"time"
+)
+
+// how long to watch a newly started process before reporting it as successfully started
+const startupCheckTime = 500 * time.Millisecond
+
+// ExecPlugin represents exec code plugin, which runs components as supervised processes on the local cluster
+type ExecPlugin struct {
+	local *Plugin
+}
+
+var _ plugin.CodePlugin = &ExecPlugin{}
+var _ plugin.DeploymentInspector = &ExecPlugin{}
+var _ plugin.DeploymentLister = &ExecPlugin{}
+
+// NewExec returns new instance of the exec code plugin for specified local cluster plugin and plugins config
+func NewExec(clusterPlugin plugin.ClusterPlugin, cfg config.Plugins) (plugin.CodePlugin, error) {
+	localPlugin, ok := clusterPlugin.(*Plugin)
+	if !ok {
+		return nil, fmt.Errorf("local cluster plugin expected for exec code plugin creation but received: %T", clusterPlugin)
+	}
+
+	return &ExecPlugin{
+		local: localPlugin,
+	}, nil
+}
+
+// Cleanup implements cleanup phase for the exec plugin, processes are left running as they are supervised globally
+func (p *ExecPlugin) Cleanup() error {
+	return nil
+}
+
+// Create implements creation of a new component instance by starting a supervised process
+func (p *ExecPlugin) Create(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) error {
+	spec, err := p.init(params)
+	if err != nil {
+		return err
+	}
+
+	proc := processes.start(p.key(deployName), deployName, spec, params)
+	eventLog.WithFields(event.Fields{}).Infof("Started process %s: %s", deployName, spec)
+
+	return p.checkStarted(ctx, proc, eventLog)
+}
+
+// Update implements update of an existing component instance by restarting its process, if process spec has changed
+// or process isn't supervised anymore
+func (p *ExecPlugin) Update(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) error {
+	spec, err := p.init(params)
+	if err != nil {
+		return err
+	}
+
+	proc := processes.get(p.key(deployName))
+	if proc != nil && proc.matches(spec) {
+		p.writeLogs(proc, eventLog)
+		return nil
+	}
+
+	proc = processes.start(p.key(deployName), deployName, spec, params)
+	eventLog.WithFields(event.Fields{}).Infof("Restarted process %s: %s", deployName, spec)
+
+	return p.checkStarted(ctx, proc, eventLog)
+}
+
+// Destroy implements destruction of an existing component instance by stopping its process
+func (p *ExecPlugin) Destroy(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) error {
+	err := p.local.Init()
+	if err != nil {
+		return err
+	}
+
+	proc := processes.stop(p.key(deployName))
+	if proc != nil {
+		p.writeLogs(proc, eventLog)
+		eventLog.WithFields(event.Fields{}).Infof("Stopped process %s", deployName)
+	}
+
+	return nil
+}
+
+// Endpoints returns endpoints of the component instance, which are derived from the ports declared in code params
+func (p *ExecPlugin) Endpoints(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) (map[string]string, error) {
+	spec, err := p.init(params)
+	if err != nil {
+		return nil, err
+	}
+
+	if proc := processes.get(p.key(deployName)); proc != nil {
+		p.writeLogs(proc, eventLog)
+	}
+
+	return p.local.endpoints(spec), nil
+}
+
+// Resources returns PID and status of the process running the component instance
+func (p *ExecPlugin) Resources(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) (plugin.Resources, error) {
+	err := p.local.Init()
+	if err != nil {
+		return nil, err
+	}
+
+	proc := processes.get(p.key(deployName))
+	if proc == nil {
+		return nil, nil
+	}
+	p.writeLogs(proc, eventLog)
+
+	state := proc.state()
+	pid := ""
+	if state.PID > 0 {
+		pid = strconv.Itoa(state.PID)
+	}
+	lastErr := ""
+	if state.LastErr != nil {
+		lastErr = state.LastErr.Error()
+	}
+
+	return plugin.Resources{
+		"process": &plugin.ResourceTable{
+			Headers: []string{"Name", "PID", "Status", "Restarts", "Last Error", "Command"},
+			Items: []plugin.Resource{
+				{deployName, pid, state.Status, strconv.Itoa(state.Restarts), lastErr, proc.spec.String()},
+			},
+		},
+	}, nil
+}
+
+// Inspect checks whether the process for a given deploy name is supervised and has been started with provided params
+func (p *ExecPlugin) Inspect(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) (*plugin.DeploymentStatus, error) {
+	spec, err := p.init(params)
+	if err != nil {
+		return nil, err
+	}
+
+	proc := processes.get(p.key(deployName))
+	if proc == nil {
+		return &plugin.DeploymentStatus{Exists: false, Message: fmt.Sprintf("process %s is not running", deployName)}, nil
+	}
+	p.writeLogs(proc, eventLog)
+
+	if !proc.matches(spec) {
+		return &plugin.DeploymentStatus{Exists: true, Matches: false, Message: fmt.Sprintf("process %s is running with different params", deployName)}, nil
+	}
+
+	return &plugin.DeploymentStatus{Exists: true, Matches: true}, nil
+}
+
+// List returns all processes supervised for the local cluster
+func (p *ExecPlugin) List(ctx context.Context, eventLog *event.Log) (map[string]util.NestedParameterMap, error) {
+	result := make(map[string]util.NestedParameterMap)
+	for _, proc := range processes.list(p.key("")) {
+		result[proc.deployName] = proc.params
+	}
+	return result, nil
+}
+
+func (p *ExecPlugin) init(params util.NestedParameterMap) (*processSpec, error) {
+	err := p.local.Init()
+	if err != nil {
+		return nil, err
+	}
+
+	return p.local.parseProcessSpec(params)
+}
+
+func (p *ExecPlugin) key(deployName string) string {
+	return processKey(p.local.Cluster.Name, deployName)
+}
+
+// checkStarted watches a newly started process for a short time and returns an error if it fails right away
+func (p *ExecPlugin) checkStarted(ctx context.Context, proc *process, eventLog *event.Log) error {
+	timer := time.NewTimer(startupCheckTime)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-proc.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	p.writeLogs(proc, eventLog)
+
+	state := proc.state()
+	if state.Status == StatusFailed || state.Restarts > 0 {
+		return fmt.Errorf("process %s failed right after start: %s", proc.deployName, state.LastErr)
+	}
+
+	return nil
+}
+
+// writeLogs writes output of the process captured since the last call into event log
+func (p *ExecPlugin) writeLogs(proc *process, eventLog *event.Log) {
+	for _, line := range proc.drainLogs() {
+		eventLog.WithFields(event.Fields{}).Infof("[%s] %s", proc.deployName, line)
+	}
+}
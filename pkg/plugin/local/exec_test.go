@@ -0,0 +1,189 @@
+package local
+
+import (
+	"context"
+	"github.com/Aptomi/aptomi/pkg/config"
+	"github.com/Aptomi/aptomi/pkg/event"
+	"github.com/Aptomi/aptomi/pkg/lang"
+	"github.com/Aptomi/aptomi/pkg/plugin"
+	"github.com/Aptomi/aptomi/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestExecPlugin(t *testing.T, clusterName string) plugin.CodePlugin {
+	t.Helper()
+	cluster := &lang.Cluster{
+		Metadata: lang.Metadata{Name: clusterName},
+		Type:     "local",
+		Config:   map[string]interface{}{"host": "localhost"},
+	}
+
+	cfg := config.Plugins{Local: config.Local{StateDir: filepath.Join(os.TempDir(), "aptomi-local-test")}}
+	clusterPlugin, err := New(cluster, cfg)
+	assert.NoError(t, err, "Local cluster plugin should be created")
+	assert.NoError(t, clusterPlugin.Validate(), "Local cluster should be valid")
+
+	codePlugin, err := NewExec(clusterPlugin, cfg)
+	assert.NoError(t, err, "Exec code plugin should be created")
+
+	return codePlugin
+}
+
+func matchedLogs(eventLog *event.Log, message string) int {
+	verifier := event.NewLogVerifier(message, false)
+	eventLog.Save(verifier)
+	return verifier.MatchedErrorsCount()
+}
+
+// waitForLogs polls process resources (which writes captured output into event log), until a given message appears in
+// event log
+func waitForLogs(t *testing.T, p plugin.CodePlugin, deployName string, params util.NestedParameterMap, eventLog *event.Log, message string) {
+	t.Helper()
+	for i := 0; i < 50 && matchedLogs(eventLog, message) == 0; i++ {
+		time.Sleep(100 * time.Millisecond)
+		_, err := p.Resources(context.Background(), deployName, params, eventLog)
+		assert.NoError(t, err, "Resources should be returned")
+	}
+}
+
+func TestExecCreateDestroy(t *testing.T) {
+	p := newTestExecPlugin(t, "cluster-create")
+	ctx := context.Background()
+	eventLog := event.NewLog("test-exec", false)
+	params := util.NestedParameterMap{
+		"command": "sh",
+		"args":    "-c 'echo hello from process; sleep 30'",
+		"ports":   util.NestedParameterMap{"http": 8080, "db": "5432"},
+	}
+
+	err := p.Create(ctx, "proc", params, eventLog)
+	assert.NoError(t, err, "Process should be started")
+	assert.Equal(t, 1, matchedLogs(eventLog, "[proc] hello from process"), "Process output should be captured into event log")
+
+	// endpoints are derived from ports
+	endpoints, err := p.Endpoints(ctx, "proc", params, eventLog)
+	assert.NoError(t, err, "Endpoints should be returned")
+	assert.Equal(t, map[string]string{"http": "http://localhost:8080", "db": "localhost:5432"}, endpoints, "Endpoints should be derived from ports")
+
+	// resources report PID and status
+	resources, err := p.Resources(ctx, "proc", params, eventLog)
+	assert.NoError(t, err, "Resources should be returned")
+	item := resources["process"].Items[0]
+	assert.NotEmpty(t, item[1], "Resources should contain PID")
+	assert.Equal(t, StatusRunning, item[2], "Process should be running")
+
+	// process is listed and matches its params
+	list, err := p.(plugin.DeploymentLister).List(ctx, eventLog)
+	assert.NoError(t, err, "Processes should be listed")
+	assert.Equal(t, params, list["proc"], "Process should be listed with its params")
+
+	status, err := p.(plugin.DeploymentInspector).Inspect(ctx, "proc", params, eventLog)
+	assert.NoError(t, err, "Process should be inspected")
+	assert.True(t, status.Exists && status.Matches, "Process should exist and match params")
+
+	// update with the same params doesn't restart process
+	err = p.Update(ctx, "proc", params, eventLog)
+	assert.NoError(t, err, "Process should be updated")
+	resources, _ = p.Resources(ctx, "proc", params, eventLog)
+	assert.Equal(t, item[1], resources["process"].Items[0][1], "Process should not be restarted if params are the same")
+
+	// destroy stops process
+	err = p.Destroy(ctx, "proc", params, eventLog)
+	assert.NoError(t, err, "Process should be stopped")
+	status, err = p.(plugin.DeploymentInspector).Inspect(ctx, "proc", params, eventLog)
+	assert.NoError(t, err, "Process should be inspected")
+	assert.False(t, status.Exists, "Process should not exist after destroy")
+}
+
+func TestExecRestartPolicy(t *testing.T) {
+	p := newTestExecPlugin(t, "cluster-restart")
+	ctx := context.Background()
+	eventLog := event.NewLog("test-exec", false)
+
+	// failing process gets restarted and reported as failed
+	params := util.NestedParameterMap{"command": "sh", "args": "-c 'exit 1'"}
+	err := p.Create(ctx, "failing", params, eventLog)
+	assert.Error(t, err, "Process failing right after start should be reported")
+	resources, _ := p.Resources(ctx, "failing", params, eventLog)
+	assert.Equal(t, StatusRestarting, resources["process"].Items[0][2], "Failed process should be restarted with on-failure policy")
+	assert.NoError(t, p.Destroy(ctx, "failing", params, eventLog), "Process should be stopped")
+
+	// completed process isn't restarted
+	params = util.NestedParameterMap{"command": "sh", "args": "-c 'exit 0'", "restart": RestartOnFailure}
+	err = p.Create(ctx, "completed", params, eventLog)
+	assert.NoError(t, err, "Completed process should not be reported as failed")
+	resources, _ = p.Resources(ctx, "completed", params, eventLog)
+	assert.Equal(t, StatusExited, resources["process"].Items[0][2], "Completed process should not be restarted with on-failure policy")
+	assert.NoError(t, p.Destroy(ctx, "completed", params, eventLog), "Process should be stopped")
+
+	// failed process isn't restarted with never policy
+	params = util.NestedParameterMap{"command": "sh", "args": "-c 'exit 1'", "restart": RestartNever}
+	err = p.Create(ctx, "never", params, eventLog)
+	assert.Error(t, err, "Process failing right after start should be reported")
+	resources, _ = p.Resources(ctx, "never", params, eventLog)
+	assert.Equal(t, StatusFailed, resources["process"].Items[0][2], "Failed process should not be restarted with never policy")
+	assert.NoError(t, p.Destroy(ctx, "never", params, eventLog), "Process should be stopped")
+}
+
+func TestExecOutput(t *testing.T) {
+	p := newTestExecPlugin(t, "cluster-output")
+	ctx := context.Background()
+	eventLog := event.NewLog("test-exec", false)
+
+	// stderr is captured along with stdout
+	params := util.NestedParameterMap{"command": "sh", "args": "-c 'echo from stderr >&2; sleep 30'"}
+	err := p.Create(ctx, "stderr", params, eventLog)
+	assert.NoError(t, err, "Process should be started")
+	waitForLogs(t, p, "stderr", params, eventLog, "[stderr] from stderr")
+	assert.Equal(t, 1, matchedLogs(eventLog, "[stderr] from stderr"), "Stderr should be captured")
+	assert.NoError(t, p.Destroy(ctx, "stderr", params, eventLog), "Process should be stopped")
+
+	// only the latest lines are kept if there are too many of them
+	params = util.NestedParameterMap{"command": "sh", "args": "-c 'seq 1 1500; sleep 30'"}
+	err = p.Create(ctx, "output", params, eventLog)
+	assert.NoError(t, err, "Process should be started")
+	waitForLogs(t, p, "output", params, eventLog, "[output] 1500")
+	assert.Equal(t, 1, matchedLogs(eventLog, "[output] 1500"), "Last line of stdout should be captured")
+	assert.Equal(t, 0, matchedLogs(eventLog, "[output] 499"), "Old lines of stdout should be dropped")
+	assert.Equal(t, 1, matchedLogs(eventLog, "lines of output dropped"), "Dropped lines should be reported")
+
+	// pid of the running process is persisted
+	pids, err := newPIDFile(filepath.Join(os.TempDir(), "aptomi-local-test", "pids.json"))
+	assert.NoError(t, err, "Persisted pids should be loaded")
+	assert.NotZero(t, pids.pids[processKey("cluster-output", "output")], "Pid of the running process should be persisted")
+
+	assert.NoError(t, p.Destroy(ctx, "output", params, eventLog), "Process should be stopped")
+	pids, err = newPIDFile(filepath.Join(os.TempDir(), "aptomi-local-test", "pids.json"))
+	assert.NoError(t, err, "Persisted pids should be loaded")
+	assert.Zero(t, pids.pids[processKey("cluster-output", "output")], "Pid of the stopped process should be removed")
+}
+
+func TestExecInvalidParams(t *testing.T) {
+	p := newTestExecPlugin(t, "cluster-invalid")
+	ctx := context.Background()
+	eventLog := event.NewLog("test-exec", false)
+
+	for _, params := range []util.NestedParameterMap{
+		{},
+		{"command": "sh", "args": "-c 'unterminated"},
+		{"command": "sh", "restart": "sometimes"},
+		{"command": "sh", "ports": util.NestedParameterMap{"http": "not-a-port"}},
+		{"command": "sh", "env": "not-a-map"},
+	} {
+		assert.Error(t, p.Create(ctx, "invalid", params, eventLog), "Process should not be started with invalid params: %v", params)
+	}
+}
+
+func TestSplitArgs(t *testing.T) {
+	args, err := splitArgs(`-c "echo 'a b'"  --flag 'x y' z`)
+	assert.NoError(t, err, "Args should be split")
+	assert.Equal(t, []string{"-c", "echo 'a b'", "--flag", "x y", "z"}, args, "Args should be split respecting quotes")
+
+	args, err = splitArgs("")
+	assert.NoError(t, err, "Empty args should be split")
+	assert.Empty(t, args, "Empty args should result in no args")
+}
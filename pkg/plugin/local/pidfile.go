@@ -0,0 +1,93 @@
+package local
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// pidFile persists PIDs of the supervised processes, so processes left running by the previous server run could be
+// stopped on startup instead of running along with their new copies
+type pidFile struct {
+	mu   sync.Mutex
+	path string
+	pids map[string]int
+}
+
+// newPIDFile loads PIDs persisted in a given file, if there are any
+func newPIDFile(path string) (*pidFile, error) {
+	f := &pidFile{
+		path: path,
+		pids: make(map[string]int),
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error while reading pid file %s: %s", path, err)
+	}
+
+	err = json.Unmarshal(data, &f.pids)
+	if err != nil {
+		return nil, fmt.Errorf("error while parsing pid file %s: %s", path, err)
+	}
+
+	return f, nil
+}
+
+// killAll kills process groups of all persisted PIDs and forgets them. Processes are started as group leaders and
+// only whole groups are killed, so it's unlikely to hit an unrelated process that has got the same PID
+func (f *pidFile) killAll() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for key, pid := range f.pids {
+		err := killProcessGroup(pid)
+		if err != nil && !isProcessNotFound(err) {
+			return fmt.Errorf("error while killing process %s (pid %d) left by previous run: %s", key, pid, err)
+		}
+		delete(f.pids, key)
+	}
+
+	return f.save()
+}
+
+// set persists PID of the process with a given key, zero PID removes the process from the file
+func (f *pidFile) set(key string, pid int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if pid > 0 {
+		f.pids[key] = pid
+	} else {
+		delete(f.pids, key)
+	}
+
+	return f.save()
+}
+
+func (f *pidFile) save() error {
+	data, err := json.Marshal(f.pids)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(f.path), 0755)
+	if err != nil {
+		return err
+	}
+
+	// write through the temp file, so the file is never left partially written if server dies
+	tmpPath := f.path + ".tmp"
+	err = ioutil.WriteFile(tmpPath, data, 0644)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, f.path)
+}
@@ -0,0 +1,57 @@
+package local
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPIDFileKillAll(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aptomi-local-pids")
+	if !assert.NoError(t, err, "Temp dir should be created") {
+		t.FailNow()
+	}
+	defer os.RemoveAll(dir) // nolint: errcheck
+	path := filepath.Join(dir, "pids.json")
+
+	// process left running by the "previous run"
+	cmd := exec.Command("sleep", "30")
+	setProcessGroup(cmd)
+	if !assert.NoError(t, cmd.Start(), "Process should be started") {
+		t.FailNow()
+	}
+	waitCh := make(chan error, 1)
+	go func() {
+		waitCh <- cmd.Wait()
+	}()
+
+	pids, err := newPIDFile(path)
+	assert.NoError(t, err, "Pid file should be created")
+	assert.NoError(t, pids.set("cluster#running", cmd.Process.Pid), "Pid should be persisted")
+	assert.NoError(t, pids.set("cluster#exited", 999999), "Pid should be persisted")
+
+	// "next run" kills it on startup
+	pids, err = newPIDFile(path)
+	assert.NoError(t, err, "Pid file should be loaded")
+	assert.Len(t, pids.pids, 2, "Persisted pids should be loaded")
+	assert.NoError(t, pids.killAll(), "Processes left by previous run should be killed")
+
+	select {
+	case <-waitCh:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Process left by previous run should be killed")
+	}
+
+	pids, err = newPIDFile(path)
+	assert.NoError(t, err, "Pid file should be loaded")
+	assert.Empty(t, pids.pids, "Killed processes should be forgotten")
+
+	// corrupted pid file is reported
+	assert.NoError(t, ioutil.WriteFile(path, []byte("garbage"), 0644), "File should be written")
+	_, err = newPIDFile(path)
+	assert.Error(t, err, "Corrupted pid file should be reported")
+}
@@ -0,0 +1,83 @@
+package local
+
+import (
+	"fmt"
+	"github.com/Aptomi/aptomi/pkg/config"
+	"github.com/Aptomi/aptomi/pkg/lang"
+	"github.com/Aptomi/aptomi/pkg/plugin"
+	"github.com/Aptomi/aptomi/pkg/util/sync"
+	"os"
+	"path/filepath"
+)
+
+// Plugin represents local cluster plugin, which runs components as processes on the Aptomi host
+type Plugin struct {
+	once    sync.Init
+	config  config.Local
+	Cluster *lang.Cluster
+	Host    string
+	Dir     string
+}
+
+var _ plugin.ClusterPlugin = &Plugin{}
+
+// New creates new instance of the local cluster plugin for specified Cluster and plugins config
+func New(cluster *lang.Cluster, cfg config.Plugins) (plugin.ClusterPlugin, error) {
+	return &Plugin{
+		config:  cfg.Local,
+		Cluster: cluster,
+	}, nil
+}
+
+// GetCluster returns cluster, for which plugin has been created
+func (p *Plugin) GetCluster() *lang.Cluster {
+	return p.Cluster
+}
+
+// Validate checks local cluster config and makes sure its working directory exists
+func (p *Plugin) Validate() error {
+	err := p.Init()
+	if err != nil {
+		return err
+	}
+
+	if len(p.Dir) > 0 {
+		info, statErr := os.Stat(p.Dir)
+		if statErr != nil {
+			return fmt.Errorf("error while checking dir of local cluster %s: %s", p.Cluster.Name, statErr)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("dir of local cluster %s is not a directory: %s", p.Cluster.Name, p.Dir)
+		}
+	}
+
+	return nil
+}
+
+// Init parses local cluster config and makes sure that processes left running by the previous server run are stopped
+func (p *Plugin) Init() error {
+	return p.once.Do(func() error {
+		err := p.parseClusterConfig()
+		if err != nil {
+			return err
+		}
+
+		return processes.init(p.stateDir())
+	})
+}
+
+// Cleanup intended to run cleanup operations for plugin, but it's not used in local cluster plugin. Processes are
+// supervised independently of plugin instances
+func (p *Plugin) Cleanup() error {
+	// no cleanup needed
+	return nil
+}
+
+// stateDir returns directory, where PIDs of the started processes are persisted
+func (p *Plugin) stateDir() string {
+	if len(p.config.StateDir) > 0 {
+		return p.config.StateDir
+	}
+
+	return filepath.Join(os.TempDir(), "aptomi-local")
+}
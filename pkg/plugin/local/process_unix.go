@@ -0,0 +1,29 @@
+//go:build !windows
+// +build !windows
+
+package local
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup makes the process a leader of a new process group, so it could be terminated along with its children
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// signalProcessGroup sends a given signal to the whole process group of the process
+func signalProcessGroup(cmd *exec.Cmd, sig syscall.Signal) error {
+	return syscall.Kill(-cmd.Process.Pid, sig)
+}
+
+// killProcessGroup kills the whole process group led by the process with a given PID
+func killProcessGroup(pid int) error {
+	return syscall.Kill(-pid, syscall.SIGKILL)
+}
+
+// isProcessNotFound returns true if error means that there is no such process (e.g. it has already exited)
+func isProcessNotFound(err error) bool {
+	return err == syscall.ESRCH
+}
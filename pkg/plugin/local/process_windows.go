@@ -0,0 +1,34 @@
+package local
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup does nothing on windows, as there are no process groups
+func setProcessGroup(cmd *exec.Cmd) {
+}
+
+// signalProcessGroup sends a given signal to the process only, as there are no process groups on windows
+func signalProcessGroup(cmd *exec.Cmd, sig syscall.Signal) error {
+	if sig == syscall.SIGKILL {
+		return cmd.Process.Kill()
+	}
+	return cmd.Process.Signal(sig)
+}
+
+// killProcessGroup kills the process with a given PID only, as there are no process groups on windows
+func killProcessGroup(pid int) error {
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return proc.Kill()
+}
+
+// isProcessNotFound returns true for any error, as process which can't be opened on windows has already exited in most
+// cases
+func isProcessNotFound(err error) bool {
+	return err != nil
+}
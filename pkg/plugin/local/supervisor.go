@@ -0,0 +1,375 @@
+package local
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/Aptomi/aptomi/pkg/util"
+	log "github.com/Sirupsen/logrus"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Statuses of the supervised process
+const (
+	StatusRunning    = "running"
+	StatusRestarting = "restarting"
+	StatusExited     = "exited"
+	StatusFailed     = "failed"
+	StatusStopped    = "stopped"
+)
+
+const (
+	// max number of output lines kept for the process until they are written into event log
+	maxLogLines = 1000
+
+	// max length of the output line, longer lines are split
+	maxLineLength = 4096
+
+	// restart delay is doubled on every restart, until it reaches max value
+	minRestartDelay = 1 * time.Second
+	maxRestartDelay = 30 * time.Second
+
+	// how long to wait for process to terminate gracefully before killing it
+	stopTimeout = 5 * time.Second
+)
+
+// processes is a global supervisor for all local processes, as processes should outlive plugin instances (which are
+// created for every enforcement cycle or API call)
+var processes = &supervisor{
+	processes: make(map[string]*process),
+}
+
+// supervisor keeps track of supervised processes by their keys (cluster name + deploy name)
+type supervisor struct {
+	mu        sync.Mutex
+	processes map[string]*process
+	pids      *pidFile
+}
+
+func processKey(clusterName, deployName string) string {
+	return clusterName + "#" + deployName
+}
+
+// init loads PIDs persisted in a given state dir and kills processes left running by the previous server run, as they
+// can't be re-attached to. It's done only once, before any process gets started by the current run
+func (s *supervisor) init(stateDir string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.pids != nil {
+		return nil
+	}
+
+	pids, err := newPIDFile(filepath.Join(stateDir, "pids.json"))
+	if err != nil {
+		return err
+	}
+
+	err = pids.killAll()
+	if err != nil {
+		return err
+	}
+
+	s.pids = pids
+	return nil
+}
+
+// start starts a new process with a given key, stopping the existing one first if there is any
+func (s *supervisor) start(key string, deployName string, spec *processSpec, params util.NestedParameterMap) *process {
+	s.stop(key)
+
+	s.mu.Lock()
+	pids := s.pids
+	s.mu.Unlock()
+
+	proc := &process{
+		key:        key,
+		pids:       pids,
+		deployName: deployName,
+		spec:       spec,
+		params:     params,
+		status:     StatusRunning,
+		stopCh:     make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+
+	s.mu.Lock()
+	s.processes[key] = proc
+	s.mu.Unlock()
+
+	go proc.run()
+
+	return proc
+}
+
+// stop stops the process with a given key (if there is any) and waits for it to terminate
+func (s *supervisor) stop(key string) *process {
+	s.mu.Lock()
+	proc, exist := s.processes[key]
+	delete(s.processes, key)
+	s.mu.Unlock()
+
+	if exist {
+		proc.stop()
+	}
+	return proc
+}
+
+// get returns the process with a given key or nil if there is no such process
+func (s *supervisor) get(key string) *process {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.processes[key]
+}
+
+// list returns all processes with keys having a given prefix
+func (s *supervisor) list(prefix string) []*process {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := []*process{}
+	for key, proc := range s.processes {
+		if len(key) > len(prefix) && key[:len(prefix)] == prefix {
+			result = append(result, proc)
+		}
+	}
+	return result
+}
+
+// process is a single supervised process, which gets restarted according to its restart policy
+type process struct {
+	key        string
+	pids       *pidFile
+	deployName string
+	spec       *processSpec
+	params     util.NestedParameterMap
+
+	stopOnce sync.Once
+	stopCh   chan struct{}
+	done     chan struct{}
+
+	mu       sync.Mutex
+	pid      int
+	status   string
+	restarts int
+	lastErr  error
+	logs     []string
+	dropped  int
+}
+
+// processState is a snapshot of the process state
+type processState struct {
+	PID      int
+	Status   string
+	Restarts int
+	LastErr  error
+}
+
+// run starts the process and restarts it according to restart policy, until the process gets stopped
+func (proc *process) run() {
+	defer close(proc.done)
+
+	delay := minRestartDelay
+	for {
+		err := proc.runOnce()
+		if err == errStopped {
+			proc.setStatus(StatusStopped, nil)
+			return
+		}
+
+		if err != nil {
+			proc.setStatus(StatusFailed, err)
+		} else {
+			proc.setStatus(StatusExited, nil)
+		}
+
+		if !proc.shouldRestart(err) {
+			return
+		}
+
+		proc.mu.Lock()
+		proc.status = StatusRestarting
+		proc.restarts++
+		proc.mu.Unlock()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-proc.stopCh:
+			timer.Stop()
+			proc.setStatus(StatusStopped, nil)
+			return
+		}
+
+		delay *= 2
+		if delay > maxRestartDelay {
+			delay = maxRestartDelay
+		}
+	}
+}
+
+var errStopped = fmt.Errorf("process stopped")
+
+// runOnce starts the process and waits for it to exit or to be stopped
+func (proc *process) runOnce() error {
+	cmd := exec.Command(proc.spec.Command, proc.spec.Args...) // nolint: gas
+	cmd.Env = append(os.Environ(), proc.spec.Env...)
+	cmd.Dir = proc.spec.Dir
+	setProcessGroup(cmd)
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+
+	err = cmd.Start()
+	if err != nil {
+		return err
+	}
+
+	proc.mu.Lock()
+	proc.pid = cmd.Process.Pid
+	proc.status = StatusRunning
+	proc.mu.Unlock()
+	proc.savePID(cmd.Process.Pid)
+
+	// output is drained by separate go routines for stdout and stderr all the time process is running, so it never
+	// blocks on the full pipe and lines from different streams don't get mixed up
+	drainWg := &sync.WaitGroup{}
+	drainWg.Add(2)
+	go proc.drain(stdout, drainWg)
+	go proc.drain(stderr, drainWg)
+
+	waitCh := make(chan error, 1)
+	go func() {
+		// pipes should be fully read before waiting for the process, as wait closes them
+		drainWg.Wait()
+		waitErr := cmd.Wait()
+		proc.savePID(0)
+		waitCh <- waitErr
+	}()
+
+	select {
+	case err = <-waitCh:
+		return err
+	case <-proc.stopCh:
+		// ask process and its children to terminate gracefully and kill them if they don't
+		if sigErr := signalProcessGroup(cmd, syscall.SIGTERM); sigErr != nil {
+			_ = signalProcessGroup(cmd, syscall.SIGKILL)
+		}
+		timer := time.NewTimer(stopTimeout)
+		defer timer.Stop()
+		select {
+		case <-waitCh:
+		case <-timer.C:
+			_ = signalProcessGroup(cmd, syscall.SIGKILL)
+			<-waitCh
+		}
+		return errStopped
+	}
+}
+
+func (proc *process) shouldRestart(err error) bool {
+	switch proc.spec.Restart {
+	case RestartAlways:
+		return true
+	case RestartOnFailure:
+		return err != nil
+	}
+	return false
+}
+
+func (proc *process) setStatus(status string, err error) {
+	proc.mu.Lock()
+	defer proc.mu.Unlock()
+	proc.status = status
+	proc.lastErr = err
+}
+
+// stop stops the process and waits for it to terminate
+func (proc *process) stop() {
+	proc.stopOnce.Do(func() {
+		close(proc.stopCh)
+	})
+	<-proc.done
+}
+
+// state returns a snapshot of the process state
+func (proc *process) state() processState {
+	proc.mu.Lock()
+	defer proc.mu.Unlock()
+	return processState{
+		PID:      proc.pid,
+		Status:   proc.status,
+		Restarts: proc.restarts,
+		LastErr:  proc.lastErr,
+	}
+}
+
+// matches returns true if process has been started with a given spec
+func (proc *process) matches(spec *processSpec) bool {
+	return reflect.DeepEqual(proc.spec, spec)
+}
+
+// savePID persists PID of the running process (or removes it once process exits), so it could be killed if server
+// gets restarted
+func (proc *process) savePID(pid int) {
+	if proc.pids == nil {
+		return
+	}
+
+	err := proc.pids.set(proc.key, pid)
+	if err != nil {
+		log.Warnf("Error while saving pid of process %s: %s", proc.deployName, err)
+	}
+}
+
+// drain reads process output line by line until the pipe is closed and captures it
+func (proc *process) drain(reader io.Reader, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	bufReader := bufio.NewReaderSize(reader, maxLineLength)
+	for {
+		line, _, err := bufReader.ReadLine()
+		if err != nil {
+			return
+		}
+		proc.addLogLine(string(line))
+	}
+}
+
+// addLogLine captures a single line of process output, dropping the oldest lines if there are too many of them
+func (proc *process) addLogLine(line string) {
+	proc.mu.Lock()
+	defer proc.mu.Unlock()
+
+	proc.logs = append(proc.logs, line)
+	if len(proc.logs) > maxLogLines {
+		proc.dropped += len(proc.logs) - maxLogLines
+		proc.logs = proc.logs[len(proc.logs)-maxLogLines:]
+	}
+}
+
+// drainLogs returns output lines captured since the last call
+func (proc *process) drainLogs() []string {
+	proc.mu.Lock()
+	defer proc.mu.Unlock()
+
+	logs := proc.logs
+	if proc.dropped > 0 {
+		logs = append([]string{fmt.Sprintf("... %d lines of output dropped", proc.dropped)}, logs...)
+	}
+	proc.logs = nil
+	proc.dropped = 0
+	return logs
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/Aptomi/aptomi/pkg/config"
 	"github.com/Aptomi/aptomi/pkg/lang"
+	"github.com/Aptomi/aptomi/pkg/util"
 	"sync"
 )
 
@@ -34,6 +35,15 @@ func NewRegistry(config config.Plugins, clusterTypes map[string]ClusterPluginCon
 	}
 }
 
+// SupportedTypes returns a map from cluster type to the sorted list of code types, for which plugins are registered
+func (registry *defaultRegistry) SupportedTypes() map[string][]string {
+	result := make(map[string][]string)
+	for clusterType := range registry.clusterTypes {
+		result[clusterType] = util.GetSortedStringKeys(registry.codeTypes[clusterType])
+	}
+	return result
+}
+
 func (registry *defaultRegistry) ForCluster(cluster *lang.Cluster) (ClusterPlugin, error) {
 	constructor, exist := registry.clusterTypes[cluster.Type]
 	if !exist {
@@ -56,6 +66,17 @@ func (registry *defaultRegistry) ForCluster(cluster *lang.Cluster) (ClusterPlugi
 	return clusterPlugin, nil
 }
 
+func (registry *defaultRegistry) ClusterPlugins() []ClusterPlugin {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	result := []ClusterPlugin{}
+	for _, name := range util.GetSortedStringKeys(registry.clusterPlugins) {
+		result = append(result, registry.clusterPlugins[name])
+	}
+	return result
+}
+
 func (registry *defaultRegistry) ForCodeType(cluster *lang.Cluster, codeType string) (CodePlugin, error) {
 	clusterPlugin, err := registry.ForCluster(cluster)
 	if err != nil {
@@ -0,0 +1,17 @@
+// Package webhook implements support for Webhook code plugin, which translates all calls to the code plugin into HTTP
+// requests to a webhook URL. It allows to plug Aptomi into internal provisioning systems (DNS, DB-as-a-service, etc)
+// through a thin HTTP adapter.
+//
+// Webhook URL is taken from the "url" code param. Every call is sent as POST request with JSON body containing
+// action (create, update, destroy, endpoints or resources), deploy name and code params. Webhook responds with JSON
+// containing endpoints or resources (for corresponding actions) or an error message.
+//
+// Webhook could also process actions asynchronously by responding with 202 Accepted and an operation id. In that case
+// plugin will keep polling the same URL with "operation" action and operation id, until webhook responds that the
+// operation is done or max poll duration passes.
+//
+// Requests failed due to network errors, 5xx or 429 responses are retried. Every request carries Idempotency-Key header,
+// which stays the same for all its retries. Webhook should use it to avoid performing non-idempotent actions (create,
+// update) twice, if it has already processed the request, but the response got lost. Authorization header is added
+// to requests from the secret loader, if a secret is stored for the webhook host (see config.Webhook).
+package webhook
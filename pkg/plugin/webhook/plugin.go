@@ -0,0 +1,271 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/Aptomi/aptomi/pkg/config"
+	"github.com/Aptomi/aptomi/pkg/event"
+	"github.com/Aptomi/aptomi/pkg/external/secrets"
+	"github.com/Aptomi/aptomi/pkg/plugin"
+	"github.com/Aptomi/aptomi/pkg/util"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// IdempotencyKeyHeader is a header with the key, which is unique for every request, but the same for all its retries.
+// Webhook should use it to detect retries of the requests it has already processed (e.g. if response got lost)
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// Plugin represents Webhook code plugin, which translates calls into HTTP requests to the webhook URL
+type Plugin struct {
+	config       config.Webhook
+	secretLoader secrets.SecretLoader
+	client       *http.Client
+}
+
+var _ plugin.CodePlugin = &Plugin{}
+
+// New returns new instance of the Webhook code plugin. It works with any cluster plugin, as all work is done by the
+// webhook. Auth headers for the webhook are loaded using a given secret loader
+func New(clusterPlugin plugin.ClusterPlugin, cfg config.Plugins, secretLoader secrets.SecretLoader) (plugin.CodePlugin, error) {
+	webhookConfig := cfg.Webhook
+	if webhookConfig.Timeout == 0 {
+		webhookConfig.Timeout = 30 * time.Second
+	}
+	if webhookConfig.Retries == 0 {
+		webhookConfig.Retries = 3
+	}
+	if webhookConfig.RetryDelay == 0 {
+		webhookConfig.RetryDelay = 1 * time.Second
+	}
+	if webhookConfig.PollInterval == 0 {
+		webhookConfig.PollInterval = 2 * time.Second
+	}
+	if webhookConfig.MaxPollDuration == 0 {
+		webhookConfig.MaxPollDuration = 30 * time.Minute
+	}
+	if len(webhookConfig.SecretsUser) == 0 {
+		webhookConfig.SecretsUser = "webhook"
+	}
+
+	return &Plugin{
+		config:       webhookConfig,
+		secretLoader: secretLoader,
+		client:       &http.Client{Timeout: webhookConfig.Timeout},
+	}, nil
+}
+
+// Cleanup implements cleanup phase for the Webhook plugin
+func (p *Plugin) Cleanup() error {
+	return nil
+}
+
+// Create implements creation of a new component instance by sending create action to the webhook
+func (p *Plugin) Create(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) error {
+	_, err := p.call(ctx, ActionCreate, deployName, params, eventLog)
+	return err
+}
+
+// Update implements update of an existing component instance by sending update action to the webhook
+func (p *Plugin) Update(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) error {
+	_, err := p.call(ctx, ActionUpdate, deployName, params, eventLog)
+	return err
+}
+
+// Destroy implements destruction of an existing component instance by sending destroy action to the webhook
+func (p *Plugin) Destroy(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) error {
+	_, err := p.call(ctx, ActionDestroy, deployName, params, eventLog)
+	return err
+}
+
+// Endpoints returns endpoints of the component instance, as reported by the webhook
+func (p *Plugin) Endpoints(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) (map[string]string, error) {
+	response, err := p.call(ctx, ActionEndpoints, deployName, params, eventLog)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.Endpoints == nil {
+		return make(map[string]string), nil
+	}
+	return response.Endpoints, nil
+}
+
+// Resources returns resources of the component instance, as reported by the webhook
+func (p *Plugin) Resources(ctx context.Context, deployName string, params util.NestedParameterMap, eventLog *event.Log) (plugin.Resources, error) {
+	response, err := p.call(ctx, ActionResources, deployName, params, eventLog)
+	if err != nil {
+		return nil, err
+	}
+
+	return response.Resources, nil
+}
+
+// call sends a given action to the webhook and waits for asynchronous operation to complete, if webhook started one
+func (p *Plugin) call(ctx context.Context, action string, deployName string, params util.NestedParameterMap, eventLog *event.Log) (*Response, error) {
+	webhookURL, ok := params["url"].(string)
+	if !ok || len(webhookURL) == 0 {
+		return nil, fmt.Errorf("url is a mandatory parameter")
+	}
+	parsedURL, err := url.Parse(webhookURL)
+	if err != nil || len(parsedURL.Host) == 0 {
+		return nil, fmt.Errorf("url is not valid: %s", webhookURL)
+	}
+
+	request := &Request{
+		Action:     action,
+		DeployName: deployName,
+		Params:     params,
+	}
+	response, accepted, err := p.send(ctx, parsedURL, request, eventLog)
+	if err != nil {
+		return nil, fmt.Errorf("webhook %s failed to %s %s: %s", parsedURL.Host, action, deployName, err)
+	}
+	if !accepted {
+		if len(response.Error) > 0 {
+			return nil, fmt.Errorf("webhook %s failed to %s %s: %s", parsedURL.Host, action, deployName, response.Error)
+		}
+		return response, nil
+	}
+
+	if len(response.OperationID) == 0 {
+		return nil, fmt.Errorf("webhook %s accepted %s of %s, but returned no operation id", parsedURL.Host, action, deployName)
+	}
+	eventLog.WithFields(event.Fields{}).Infof("Webhook %s started operation %s to %s %s", parsedURL.Host, response.OperationID, action, deployName)
+
+	// poll webhook until operation is done, but not longer than max poll duration
+	request = &Request{
+		Action:      ActionOperation,
+		DeployName:  deployName,
+		Params:      params,
+		OperationID: response.OperationID,
+	}
+	pollCtx, cancel := context.WithTimeout(ctx, p.config.MaxPollDuration)
+	defer cancel()
+	for {
+		err = sleep(pollCtx, p.config.PollInterval)
+		if err == nil {
+			response, _, err = p.send(pollCtx, parsedURL, request, eventLog)
+		}
+		if err != nil && ctx.Err() == nil && pollCtx.Err() != nil {
+			return nil, fmt.Errorf("webhook %s didn't complete operation %s to %s %s in %s", parsedURL.Host, request.OperationID, action, deployName, p.config.MaxPollDuration)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("webhook %s failed to report status of operation %s: %s", parsedURL.Host, request.OperationID, err)
+		}
+		if response.Done {
+			if len(response.Error) > 0 {
+				return nil, fmt.Errorf("webhook %s operation %s to %s %s failed: %s", parsedURL.Host, request.OperationID, action, deployName, response.Error)
+			}
+			return response, nil
+		}
+	}
+}
+
+// send sends a single request to the webhook, retrying it on network errors and 5xx responses. All retries are sent
+// with the same idempotency key, so webhook could tell them from the new requests. It returns decoded response and
+// whether webhook accepted request for asynchronous processing
+func (p *Plugin) send(ctx context.Context, webhookURL *url.URL, request *Request, eventLog *event.Log) (*Response, bool, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, false, fmt.Errorf("error while marshaling request: %s", err)
+	}
+
+	idempotencyKey, err := newIdempotencyKey()
+	if err != nil {
+		return nil, false, fmt.Errorf("error while generating idempotency key: %s", err)
+	}
+
+	for attempt := 0; ; attempt++ {
+		response, accepted, retry, sendErr := p.sendOnce(ctx, webhookURL, body, idempotencyKey)
+		if sendErr == nil {
+			return response, accepted, nil
+		}
+		if !retry || attempt >= p.config.Retries {
+			return nil, false, sendErr
+		}
+
+		eventLog.WithFields(event.Fields{}).Debugf("Retrying %s request to webhook %s (attempt %d): %s", request.Action, webhookURL.Host, attempt+1, sendErr)
+		err = sleep(ctx, p.config.RetryDelay)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+}
+
+// sendOnce sends a single request to the webhook and returns decoded response, whether webhook accepted request for
+// asynchronous processing and whether request should be retried in case of error
+func (p *Plugin) sendOnce(ctx context.Context, webhookURL *url.URL, body []byte, idempotencyKey string) (*Response, bool, bool, error) {
+	httpRequest, err := http.NewRequest(http.MethodPost, webhookURL.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, false, false, err
+	}
+	httpRequest = httpRequest.WithContext(ctx)
+	httpRequest.Header.Set("Content-Type", "application/json")
+	httpRequest.Header.Set(IdempotencyKeyHeader, idempotencyKey)
+	if p.secretLoader != nil {
+		if auth, exist := p.secretLoader.LoadSecretsByUserName(p.config.SecretsUser)[webhookURL.Host]; exist {
+			httpRequest.Header.Set("Authorization", auth)
+		}
+	}
+
+	httpResponse, err := p.client.Do(httpRequest)
+	if err != nil {
+		// don't retry if context was cancelled
+		return nil, false, ctx.Err() == nil, err
+	}
+	defer httpResponse.Body.Close() // nolint: errcheck
+
+	data, err := ioutil.ReadAll(httpResponse.Body)
+	if err != nil {
+		return nil, false, true, fmt.Errorf("error while reading response: %s", err)
+	}
+
+	response := &Response{}
+	if len(bytes.TrimSpace(data)) > 0 {
+		err = json.Unmarshal(data, response)
+		if err != nil && httpResponse.StatusCode < 300 {
+			return nil, false, false, fmt.Errorf("error while unmarshaling response: %s", err)
+		}
+	}
+
+	if httpResponse.StatusCode >= 300 {
+		message := response.Error
+		if len(message) == 0 {
+			message = string(data)
+		}
+		retry := httpResponse.StatusCode >= 500 || httpResponse.StatusCode == http.StatusTooManyRequests
+		return nil, false, retry, fmt.Errorf("%s: %s", httpResponse.Status, message)
+	}
+
+	return response, httpResponse.StatusCode == http.StatusAccepted, false, nil
+}
+
+// newIdempotencyKey generates a random key for the request
+func newIdempotencyKey() (string, error) {
+	key := make([]byte, 16)
+	_, err := rand.Read(key)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(key), nil
+}
+
+// sleep sleeps a given time amount, unless context gets cancelled
+func sleep(ctx context.Context, duration time.Duration) error {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,212 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/Aptomi/aptomi/pkg/config"
+	"github.com/Aptomi/aptomi/pkg/event"
+	"github.com/Aptomi/aptomi/pkg/plugin"
+	"github.com/Aptomi/aptomi/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testSecretLoader map[string]map[string]string
+
+func (loader testSecretLoader) LoadSecretsByUserName(userName string) map[string]string {
+	return loader[userName]
+}
+
+// testWebhook is a fake webhook, which records received requests and responds using a given handler
+type testWebhook struct {
+	mu       sync.Mutex
+	requests []*Request
+	auth     []string
+	keys     []string
+	handler  func(request *Request, calls int) (int, *Response)
+}
+
+func (webhook *testWebhook) ServeHTTP(writer http.ResponseWriter, httpRequest *http.Request) {
+	request := &Request{}
+	err := json.NewDecoder(httpRequest.Body).Decode(request)
+	if err != nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	webhook.mu.Lock()
+	webhook.requests = append(webhook.requests, request)
+	webhook.auth = append(webhook.auth, httpRequest.Header.Get("Authorization"))
+	webhook.keys = append(webhook.keys, httpRequest.Header.Get(IdempotencyKeyHeader))
+	calls := len(webhook.requests)
+	webhook.mu.Unlock()
+
+	status, response := webhook.handler(request, calls)
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(status)
+	_ = json.NewEncoder(writer).Encode(response)
+}
+
+func newTestPlugin(t *testing.T, handler func(request *Request, calls int) (int, *Response)) (plugin.CodePlugin, *testWebhook, util.NestedParameterMap, func()) {
+	t.Helper()
+	webhook := &testWebhook{handler: handler}
+	server := httptest.NewServer(webhook)
+
+	serverURL, err := url.Parse(server.URL)
+	assert.NoError(t, err, "Test server URL should be valid")
+	secretLoader := testSecretLoader{"webhook": {serverURL.Host: "Bearer secret-token"}}
+
+	cfg := config.Plugins{Webhook: config.Webhook{RetryDelay: time.Millisecond, PollInterval: time.Millisecond}}
+	p, err := New(nil, cfg, secretLoader)
+	assert.NoError(t, err, "Webhook plugin should be created")
+
+	params := util.NestedParameterMap{"url": server.URL, "zone": "example.com"}
+	return p, webhook, params, server.Close
+}
+
+func TestWebhookSync(t *testing.T) {
+	p, webhook, params, closeServer := newTestPlugin(t, func(request *Request, calls int) (int, *Response) {
+		switch request.Action {
+		case ActionEndpoints:
+			return http.StatusOK, &Response{Endpoints: map[string]string{"dns": request.DeployName + ".example.com"}}
+		case ActionResources:
+			return http.StatusOK, &Response{Resources: plugin.Resources{"record": &plugin.ResourceTable{Headers: []string{"Name"}, Items: []plugin.Resource{{request.DeployName}}}}}
+		}
+		return http.StatusOK, &Response{}
+	})
+	defer closeServer()
+
+	ctx := context.Background()
+	eventLog := event.NewLog("test-webhook", false)
+
+	assert.NoError(t, p.Create(ctx, "record", params, eventLog), "Create should succeed")
+	assert.NoError(t, p.Update(ctx, "record", params, eventLog), "Update should succeed")
+
+	endpoints, err := p.Endpoints(ctx, "record", params, eventLog)
+	assert.NoError(t, err, "Endpoints should succeed")
+	assert.Equal(t, map[string]string{"dns": "record.example.com"}, endpoints, "Endpoints should be returned by webhook")
+
+	resources, err := p.Resources(ctx, "record", params, eventLog)
+	assert.NoError(t, err, "Resources should succeed")
+	assert.Equal(t, []string{"record"}, resources["record"].Items[0], "Resources should be returned by webhook")
+
+	assert.NoError(t, p.Destroy(ctx, "record", params, eventLog), "Destroy should succeed")
+
+	// check what webhook received
+	actions := []string{}
+	for i, request := range webhook.requests {
+		actions = append(actions, request.Action)
+		assert.Equal(t, "record", request.DeployName, "Deploy name should be sent to webhook")
+		assert.Equal(t, "example.com", request.Params["zone"], "Params should be sent to webhook")
+		assert.Equal(t, "Bearer secret-token", webhook.auth[i], "Auth header should be loaded from secrets")
+	}
+	assert.Equal(t, []string{ActionCreate, ActionUpdate, ActionEndpoints, ActionResources, ActionDestroy}, actions, "All actions should be sent to webhook")
+}
+
+func TestWebhookAsync(t *testing.T) {
+	p, webhook, params, closeServer := newTestPlugin(t, func(request *Request, calls int) (int, *Response) {
+		if request.Action == ActionCreate {
+			return http.StatusAccepted, &Response{OperationID: "op-1"}
+		}
+		// operation is done on the third status check
+		return http.StatusOK, &Response{OperationID: request.OperationID, Done: calls >= 4}
+	})
+	defer closeServer()
+
+	err := p.Create(context.Background(), "record", params, event.NewLog("test-webhook", false))
+	assert.NoError(t, err, "Create should succeed once operation is done")
+	assert.Len(t, webhook.requests, 4, "Webhook should be polled until operation is done")
+	assert.Equal(t, ActionOperation, webhook.requests[3].Action, "Operation status should be polled")
+	assert.Equal(t, "op-1", webhook.requests[3].OperationID, "Operation id should be sent when polling")
+
+	// operation, which never completes, is given up after max poll duration
+	p, _, params, closeServer = newTestPlugin(t, func(request *Request, calls int) (int, *Response) {
+		if request.Action == ActionUpdate {
+			return http.StatusAccepted, &Response{OperationID: "op-3"}
+		}
+		return http.StatusOK, &Response{OperationID: request.OperationID}
+	})
+	defer closeServer()
+	p.(*Plugin).config.MaxPollDuration = 50 * time.Millisecond
+
+	err = p.Update(context.Background(), "record", params, event.NewLog("test-webhook", false))
+	assert.Error(t, err, "Update should fail if operation doesn't complete in time")
+	assert.Contains(t, err.Error(), "didn't complete operation op-3", "Error should say that operation hasn't completed")
+
+	// polling stops once context is cancelled
+	p.(*Plugin).config.MaxPollDuration = time.Hour
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err = p.Update(ctx, "record", params, event.NewLog("test-webhook", false))
+	assert.Error(t, err, "Update should fail once context is done")
+	assert.Contains(t, err.Error(), context.DeadlineExceeded.Error(), "Error should contain context error")
+
+	// failed operation
+	p, _, params, closeServer = newTestPlugin(t, func(request *Request, calls int) (int, *Response) {
+		if request.Action == ActionDestroy {
+			return http.StatusAccepted, &Response{OperationID: "op-2"}
+		}
+		return http.StatusOK, &Response{Done: true, Error: "record is locked"}
+	})
+	defer closeServer()
+
+	err = p.Destroy(context.Background(), "record", params, event.NewLog("test-webhook", false))
+	assert.Error(t, err, "Destroy should fail if operation failed")
+	assert.Contains(t, err.Error(), "record is locked", "Error should contain message from webhook")
+}
+
+func TestWebhookRetries(t *testing.T) {
+	// server errors are retried
+	p, webhook, params, closeServer := newTestPlugin(t, func(request *Request, calls int) (int, *Response) {
+		if calls < 3 {
+			return http.StatusServiceUnavailable, &Response{Error: "try again later"}
+		}
+		return http.StatusOK, &Response{}
+	})
+	defer closeServer()
+
+	err := p.Create(context.Background(), "record", params, event.NewLog("test-webhook", false))
+	assert.NoError(t, err, "Create should succeed after retries")
+	assert.Len(t, webhook.requests, 3, "Failed requests should be retried")
+	assert.NotEmpty(t, webhook.keys[0], "Idempotency key should be sent")
+	assert.Equal(t, []string{webhook.keys[0], webhook.keys[0], webhook.keys[0]}, webhook.keys, "Retries should be sent with the same idempotency key")
+
+	err = p.Update(context.Background(), "record", params, event.NewLog("test-webhook", false))
+	assert.NoError(t, err, "Update should succeed")
+	assert.NotEqual(t, webhook.keys[0], webhook.keys[3], "New request should be sent with a new idempotency key")
+
+	// client errors are not retried
+	p, webhook, params, closeServer = newTestPlugin(t, func(request *Request, calls int) (int, *Response) {
+		return http.StatusBadRequest, &Response{Error: "zone is not managed"}
+	})
+	defer closeServer()
+
+	err = p.Create(context.Background(), "record", params, event.NewLog("test-webhook", false))
+	assert.Error(t, err, "Create should fail")
+	assert.Contains(t, err.Error(), "zone is not managed", "Error should contain message from webhook")
+	assert.Len(t, webhook.requests, 1, "Client errors should not be retried")
+
+	// retries are limited
+	p, webhook, params, closeServer = newTestPlugin(t, func(request *Request, calls int) (int, *Response) {
+		return http.StatusInternalServerError, &Response{}
+	})
+	defer closeServer()
+
+	err = p.Create(context.Background(), "record", params, event.NewLog("test-webhook", false))
+	assert.Error(t, err, "Create should fail once retries are exhausted")
+	assert.Len(t, webhook.requests, 4, "Request should be retried configured number of times")
+}
+
+func TestWebhookInvalidURL(t *testing.T) {
+	p, err := New(nil, config.Plugins{}, nil)
+	assert.NoError(t, err, "Webhook plugin should be created")
+
+	eventLog := event.NewLog("test-webhook", false)
+	assert.Error(t, p.Create(context.Background(), "record", util.NestedParameterMap{}, eventLog), "Create should fail without url")
+	assert.Error(t, p.Create(context.Background(), "record", util.NestedParameterMap{"url": "not a url"}, eventLog), "Create should fail with invalid url")
+}
@@ -0,0 +1,49 @@
+package webhook
+
+import (
+	"github.com/Aptomi/aptomi/pkg/plugin"
+	"github.com/Aptomi/aptomi/pkg/util"
+)
+
+// Actions which are sent to the webhook
+const (
+	ActionCreate    = "create"
+	ActionUpdate    = "update"
+	ActionDestroy   = "destroy"
+	ActionEndpoints = "endpoints"
+	ActionResources = "resources"
+	ActionOperation = "operation"
+)
+
+// Request is a body of the request sent to the webhook
+type Request struct {
+	// Action is an action webhook should perform
+	Action string `json:"action"`
+
+	// DeployName is a name of the deployment in the cloud
+	DeployName string `json:"deployName"`
+
+	// Params are code params of the component instance (url param included)
+	Params util.NestedParameterMap `json:"params,omitempty"`
+
+	// OperationID is an id of the asynchronous operation, which status is requested (set for operation action only)
+	OperationID string `json:"operationId,omitempty"`
+}
+
+// Response is a body of the response returned by the webhook
+type Response struct {
+	// OperationID is an id of the asynchronous operation, webhook should return it along with 202 Accepted status
+	OperationID string `json:"operationId,omitempty"`
+
+	// Done should be set to true by the webhook once asynchronous operation is completed (for operation action only)
+	Done bool `json:"done,omitempty"`
+
+	// Error is an error message, if action has failed
+	Error string `json:"error,omitempty"`
+
+	// Endpoints are endpoints of the deployment (for endpoints action only)
+	Endpoints map[string]string `json:"endpoints,omitempty"`
+
+	// Resources are resources of the deployment (for resources action only)
+	Resources plugin.Resources `json:"resources,omitempty"`
+}
@@ -10,11 +10,16 @@ import (
 	"time"
 )
 
+// componentStatusSaveInterval is a minimal interval between saving revision on component status changes. Statuses
+// changed within the interval get saved along with the next revision update
+const componentStatusSaveInterval = time.Second
+
 // RevisionResultUpdaterImpl is a default thread-safe implementation of ApplyResultUpdater
 type RevisionResultUpdaterImpl struct {
-	store    store.Core
-	revision *engine.Revision
-	mutex    sync.Mutex
+	store     store.Core
+	revision  *engine.Revision
+	mutex     sync.Mutex
+	lastSaved time.Time
 }
 
 // NewRevisionResultUpdater creates a new default thread-safe implementation of RevisionResultUpdaterImpl, which also
@@ -51,12 +56,33 @@ func (updater *RevisionResultUpdaterImpl) AddSkipped() {
 	updater.save()
 }
 
+// SetComponentStatus safely sets status for the given component instances. Revision gets saved not more often than
+// once per componentStatusSaveInterval, as statuses are changed for every component instance being processed
+func (updater *RevisionResultUpdaterImpl) SetComponentStatus(status string, keys ...string) {
+	updater.mutex.Lock()
+	updater.revision.Result.SetComponentStatus(status, keys...)
+	throttled := time.Since(updater.lastSaved) < componentStatusSaveInterval
+	updater.mutex.Unlock()
+	if !throttled {
+		updater.save()
+	}
+}
+
+// Abort marks apply as aborted, so revision will be saved in aborted state
+func (updater *RevisionResultUpdaterImpl) Abort() {
+	updater.revision.Result.Aborted = true
+}
+
 // Done saves the revision when all actions have been processed
 func (updater *RevisionResultUpdaterImpl) Done() *action.ApplyResult {
 	if updater.revision.Result.Success+updater.revision.Result.Failed+updater.revision.Result.Skipped != updater.revision.Result.Total {
 		panic(fmt.Sprintf("error while applying actions: %d (success) + %d (failed) + %d (skipped) != %d (total)", updater.revision.Result.Success, updater.revision.Result.Failed, updater.revision.Result.Skipped, updater.revision.Result.Total))
 	}
-	updater.revision.Status = engine.RevisionStatusCompleted
+	if updater.revision.Result.Aborted {
+		updater.revision.Status = engine.RevisionStatusAborted
+	} else {
+		updater.revision.Status = engine.RevisionStatusCompleted
+	}
 	updater.revision.AppliedAt = time.Now()
 	updater.save()
 	return updater.revision.Result
@@ -69,4 +95,5 @@ func (updater *RevisionResultUpdaterImpl) save() {
 	if err != nil {
 		panic(fmt.Sprintf("error while saving revision %s: %s", updater.revision.GetGeneration(), err))
 	}
+	updater.lastSaved = time.Now()
 }
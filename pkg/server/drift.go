@@ -0,0 +1,110 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"github.com/Aptomi/aptomi/pkg/engine/drift"
+	"github.com/Aptomi/aptomi/pkg/engine/resolve"
+	"github.com/Aptomi/aptomi/pkg/event"
+	"github.com/Aptomi/aptomi/pkg/runtime"
+	log "github.com/Sirupsen/logrus"
+	"time"
+)
+
+func (server *Server) driftLoop() error {
+	for {
+		err := server.detectDrift()
+		if err != nil {
+			log.Errorf("Error while detecting drift: %s", err)
+		}
+
+		time.Sleep(server.cfg.Drift.Interval)
+	}
+}
+
+func (server *Server) detectDrift() error {
+	server.driftIdx++
+
+	policy, _, err := server.store.GetPolicy(runtime.LastGen)
+	if err != nil {
+		return fmt.Errorf("error while getting policy: %s", err)
+	}
+	if policy == nil {
+		return fmt.Errorf("policy is nil, does not exist in the store")
+	}
+
+	// drift is detected against a snapshot of actual state, so enforcer isn't blocked while the cloud is inspected
+	checkedState, err := server.store.GetActualState()
+	if err != nil {
+		return fmt.Errorf("error while getting actual state: %s", err)
+	}
+
+	timeout := server.cfg.Drift.Timeout
+	if timeout <= 0 {
+		timeout = server.cfg.Drift.Interval
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	driftLog := event.NewLog(fmt.Sprintf("drift-%d", server.driftIdx), true)
+	result := drift.NewDetector(policy, checkedState, server.pluginRegistryFactory(), driftLog).Detect(ctx)
+
+	repair, err := server.saveDrift(checkedState, result)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("(drift-%d) Checked %d component instances, %d drifted", server.driftIdx, len(result.Checked), len(result.Drifted))
+
+	// trigger enforcer, so it could repair drifted component instances
+	if repair {
+		server.policyChanged <- true
+	}
+
+	return nil
+}
+
+// saveDrift records detected drift into actual state and returns whether any component instance needs to be repaired.
+// Component instances, which have been changed or deleted by enforcer while drift was being detected, are skipped, as
+// their drift was detected against the previous state
+func (server *Server) saveDrift(checkedState *resolve.PolicyResolution, result *drift.Result) (bool, error) {
+	// actual state shouldn't be changed by enforcer while we are recording drift into it
+	server.stateLock.Lock()
+	defer server.stateLock.Unlock()
+
+	actualState, err := server.store.GetActualState()
+	if err != nil {
+		return false, fmt.Errorf("error while getting actual state: %s", err)
+	}
+
+	repair := false
+	for key := range result.Checked {
+		instance := actualState.ComponentInstanceMap[key]
+		if instance == nil || !sameDeployment(instance, checkedState.ComponentInstanceMap[key]) {
+			continue
+		}
+
+		status := result.Drifted[key]
+		if status == nil && instance.Drift == nil {
+			continue
+		}
+
+		if status != nil {
+			status.Repair = server.cfg.Drift.Repair
+			repair = repair || status.Repair
+		}
+		instance.Drift = status
+
+		err = server.store.GetActualStateUpdater().Save(instance)
+		if err != nil {
+			return false, fmt.Errorf("error while saving drift for component instance '%s': %s", key, err)
+		}
+	}
+
+	return repair, nil
+}
+
+// sameDeployment returns true if both component instances refer to the same deployment, which hasn't been updated
+func sameDeployment(instance *resolve.ComponentInstance, checked *resolve.ComponentInstance) bool {
+	return instance.GetDeployName() == checked.GetDeployName() && instance.CreatedAt.Equal(checked.CreatedAt) && instance.UpdatedAt.Equal(checked.UpdatedAt)
+}
@@ -1,12 +1,17 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	"github.com/Aptomi/aptomi/pkg/engine"
+	"github.com/Aptomi/aptomi/pkg/engine/adopt"
 	"github.com/Aptomi/aptomi/pkg/engine/apply"
+	"github.com/Aptomi/aptomi/pkg/engine/apply/hook"
 	"github.com/Aptomi/aptomi/pkg/engine/diff"
 	"github.com/Aptomi/aptomi/pkg/engine/resolve"
 	"github.com/Aptomi/aptomi/pkg/event"
+	"github.com/Aptomi/aptomi/pkg/lang"
+	"github.com/Aptomi/aptomi/pkg/plugin"
 	"github.com/Aptomi/aptomi/pkg/runtime"
 	log "github.com/Sirupsen/logrus"
 	"time"
@@ -17,16 +22,19 @@ func logError(err interface{}) {
 }
 
 func (server *Server) enforceLoop() error {
+	// revisions could be left in progress if server has been stopped while applying them
+	server.resetRevisionsInProgress()
+
 	for {
 		err := server.enforce()
 		if err != nil {
 			logError(err)
 		}
 
-		// sleep for a specified time or wait until policy has changed, whichever comes first
+		// sleep for a specified time or wait until new revision has been planned, whichever comes first
 		timer := time.NewTimer(server.cfg.Enforcer.Interval)
 		select {
-		case <-server.policyChanged:
+		case <-server.revisionPlanned:
 			break // nolint: megacheck
 		case <-timer.C:
 			break // nolint: megacheck
@@ -35,7 +43,19 @@ func (server *Server) enforceLoop() error {
 	}
 }
 
+// pendingApply represents everything needed to apply a revision
+type pendingApply struct {
+	revision      *engine.Revision
+	desiredPolicy *lang.Policy
+	desiredState  *resolve.PolicyResolution
+	actualState   *resolve.PolicyResolution
+	stateDiff     *diff.PolicyResolutionDiff
+}
+
 func (server *Server) enforce() error {
+	server.stateLock.Lock()
+	defer server.stateLock.Unlock()
+
 	server.enforcementIdx++
 
 	defer func() {
@@ -44,83 +64,259 @@ func (server *Server) enforce() error {
 		}
 	}()
 
-	// todo think about initial state when there is no revision at all
-	currRevision, err := server.store.GetRevision(runtime.LastGen)
-	if err != nil {
-		return fmt.Errorf("unable to get curr revision: %s", err)
+	next, err := server.takeRevision()
+	if err != nil || next == nil {
+		return err
+	}
+	nextRevision := next.revision
+
+	if server.cfg.Enforcer.Noop {
+		log.Infof("(enforce-%d) Applying changes in noop mode (sleep per action = %s)", server.enforcementIdx, server.cfg.Enforcer.NoopSleep)
+	} else {
+		log.Infof("(enforce-%d) Applying changes", server.enforcementIdx)
+	}
+
+	pluginRegistry := server.pluginRegistryFactory()
+	applyLog := event.NewLog(fmt.Sprintf("enforce-%d-apply", server.enforcementIdx), true)
+	applier := apply.NewEngineApply(next.desiredPolicy, next.desiredState, next.actualState, server.store.GetActualStateUpdater(), server.externalData, pluginRegistry, next.stateDiff.ActionPlan, applyLog, server.store.NewRevisionResultUpdater(nextRevision), server.cfg.Enforcer.ActionTimeout, hook.NewRunner(server.cfg.Hooks, nextRevision.GetGeneration()))
+
+	ctx, cancel := server.applyContext(nextRevision.GetGeneration())
+	_, _ = applier.Apply(ctx)
+	server.applyDone(cancel)
+
+	// let cluster plugins commit changes made while applying revision, revision isn't considered completed if they fail
+	// to do it (changes left uncommitted will be picked up by the next revision)
+	commitErr := server.commitRevision(pluginRegistry, nextRevision.GetGeneration(), applyLog)
+	if commitErr != nil {
+		log.Warnf("(enforce-%d) Revision %d wasn't committed: %s", server.enforcementIdx, nextRevision.GetGeneration(), commitErr)
+		nextRevision.Status = engine.RevisionStatusError
+	}
+
+	if nextRevision.Result.Aborted {
+		log.Warnf("(enforce-%d) Revision %d was aborted: %s", server.enforcementIdx, nextRevision.GetGeneration(), ctx.Err())
+	}
+
+	// save apply log
+	nextRevision.ApplyLog = applyLog.AsAPIEvents()
+	saveErr := server.store.UpdateRevision(nextRevision)
+	if saveErr != nil {
+		return fmt.Errorf("error while saving new revision with apply log: %s", saveErr)
 	}
 
-	// Mark last Revision as failed if it wasn't completed. If it's in 'waiting' state, that's probably ok
-	if currRevision != nil && currRevision.Status == engine.RevisionStatusInProgress {
-		currRevision.Status = engine.RevisionStatusError
-		currRevision.AppliedAt = time.Now()
-		revErr := server.store.UpdateRevision(currRevision)
-		if revErr != nil {
-			log.Warnf("(enforce-%d) Error while setting current revision that is in progress to error state: %s", server.enforcementIdx, revErr)
+	log.Infof("(enforce-%d) New revision %d processed, %d component instances", server.enforcementIdx, nextRevision.GetGeneration(), len(next.desiredState.ComponentInstanceMap))
+
+	return nil
+}
+
+// commitRevision calls all cluster plugins used while applying revision, which are able to commit changes. Failures
+// are written into apply log and the last one is returned
+func (server *Server) commitRevision(pluginRegistry plugin.Registry, gen runtime.Generation, applyLog *event.Log) error {
+	var result error
+	for _, clusterPlugin := range pluginRegistry.ClusterPlugins() {
+		if committer, ok := clusterPlugin.(plugin.RevisionCommitter); ok {
+			err := committer.CommitRevision(gen, applyLog)
+			if err != nil {
+				result = fmt.Errorf("error while committing revision %d: %s", gen, err)
+				applyLog.LogError(result)
+			}
 		}
-		log.Infof("(enforce-%d) Current revision that is in progress was reset to error state", server.enforcementIdx)
 	}
+	return result
+}
 
-	desiredPolicy, desiredPolicyGen, err := server.store.GetPolicy(runtime.LastGen)
+// takeRevision picks the latest pending revision, if it's ready to be applied, and marks it as being in progress.
+// Plan gets re-calculated against the current actual state, as it could change since revision has been planned
+func (server *Server) takeRevision() (*pendingApply, error) {
+	// revision shouldn't be superseded by planner while we are taking it
+	server.revisionLock.Lock()
+	defer server.revisionLock.Unlock()
+
+	revision, err := server.store.GetRevision(runtime.LastGen)
 	if err != nil {
-		return fmt.Errorf("error while getting desiredPolicy: %s", err)
+		return nil, fmt.Errorf("unable to get curr revision: %s", err)
+	}
+	if revision == nil || !revision.IsPending() {
+		return nil, nil
+	}
+	if revision.Status == engine.RevisionStatusBlocked && !revision.Override {
+		log.Infof("(enforce-%d) Revision %d for policy gen %d is blocked, waiting for override", server.enforcementIdx, revision.GetGeneration(), revision.Policy)
+		return nil, nil
+	}
+	if revision.Status == engine.RevisionStatusScheduled && time.Now().Before(revision.Scheduled.For) {
+		log.Infof("(enforce-%d) Revision %d for policy gen %d is scheduled for %s", server.enforcementIdx, revision.GetGeneration(), revision.Policy, revision.Scheduled.For)
+		return nil, nil
 	}
 
-	// if policy is not found, it means it somehow was not initialized correctly. let's return error
+	desiredPolicy, _, err := server.store.GetPolicy(revision.Policy)
+	if err != nil {
+		return nil, fmt.Errorf("error while getting desiredPolicy: %s", err)
+	}
 	if desiredPolicy == nil {
-		return fmt.Errorf("desiredPolicy is nil, does not exist in the store")
+		return nil, fmt.Errorf("desiredPolicy gen %d is nil, does not exist in the store", revision.Policy)
 	}
 
 	actualState, err := server.store.GetActualState()
 	if err != nil {
-		return fmt.Errorf("error while getting actual state: %s", err)
+		return nil, fmt.Errorf("error while getting actual state: %s", err)
 	}
 
 	resolveLog := event.NewLog(fmt.Sprintf("enforce-%d-resolve", server.enforcementIdx), true)
 	resolver := resolve.NewPolicyResolver(desiredPolicy, server.externalData, resolveLog)
+	resolver.SetActualState(actualState)
 	desiredState := resolver.ResolveAllDependencies()
 
-	stateDiff := diff.NewPolicyResolutionDiff(desiredState, actualState)
+	// adopt existing deployments into actual state, so they won't be created again (it makes no sense in noop mode)
+	if server.cfg.Enforcer.Adopt && !server.cfg.Enforcer.Noop {
+		timeout := server.cfg.Enforcer.AdoptTimeout
+		if timeout <= 0 {
+			timeout = server.cfg.Enforcer.Interval
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		adoptLog := event.NewLog(fmt.Sprintf("enforce-%d-adopt", server.enforcementIdx), true)
+		adopted := adopt.NewAdopter(desiredPolicy, desiredState, actualState, server.store.GetActualStateUpdater(), server.pluginRegistryFactory(), adoptLog).Adopt(ctx)
+		adoptErr := ctx.Err()
+		cancel()
+		if len(adopted) > 0 {
+			log.Infof("(enforce-%d) Adopted %d existing deployments into actual state", server.enforcementIdx, len(adopted))
+		}
+		resolveLog.Append(adoptLog)
 
-	nextRevision, err := server.store.NewRevision(desiredPolicyGen)
-	if err != nil {
-		return fmt.Errorf("unable to get next revision: %s", err)
+		// deployments, which weren't checked, shouldn't be created again, so revision waits for the next enforcement
+		if adoptErr != nil {
+			log.Warnf("(enforce-%d) Adoption of existing deployments didn't complete, revision %d will be applied later: %s", server.enforcementIdx, revision.GetGeneration(), adoptErr)
+			return nil, nil
+		}
 	}
-	nextRevision.ResolveLog = resolveLog.AsAPIEvents()
 
-	// policy changes while no actions needed to achieve desired state
-	actionCnt := stateDiff.ActionPlan.NumberOfActions()
-	if actionCnt <= 0 && currRevision != nil && currRevision.Policy == nextRevision.Policy {
-		log.Infof("(enforce-%d) No changes, policy gen %d", server.enforcementIdx, desiredPolicyGen)
-		return nil
+	stateDiff := diff.NewPolicyResolutionDiff(desiredState, actualState)
+	revision.ResolveLog = resolveLog.AsAPIEvents()
+	revision.Plan = stateDiff.GetPlanView()
+
+	// revision could still be outside of maintenance windows or exceed safety limits with the current actual state
+	if !server.checkRevision(revision, desiredPolicy, stateDiff) {
+		err = server.store.UpdateRevision(revision)
+		if err != nil {
+			return nil, fmt.Errorf("error while updating revision: %s", err)
+		}
+		return nil, nil
 	}
-	log.Infof("(enforce-%d) New revision %d, policy gen %d, %d actions need to be applied", server.enforcementIdx, nextRevision.GetGeneration(), desiredPolicyGen, actionCnt)
 
-	// Save revision
-	err = server.store.SaveRevision(nextRevision)
+	log.Infof("(enforce-%d) Revision %d, policy gen %d, %d actions need to be applied", server.enforcementIdx, revision.GetGeneration(), revision.Policy, stateDiff.ActionPlan.NumberOfActions())
+
+	revision.Status = engine.RevisionStatusInProgress
+	err = server.store.UpdateRevision(revision)
 	if err != nil {
-		return fmt.Errorf("error while saving new revision: %s", err)
+		return nil, fmt.Errorf("error while updating revision: %s", err)
 	}
 
-	if server.cfg.Enforcer.Noop {
-		log.Infof("(enforce-%d) Applying changes in noop mode (sleep per action = %s)", server.enforcementIdx, server.cfg.Enforcer.NoopSleep)
+	return &pendingApply{
+		revision:      revision,
+		desiredPolicy: desiredPolicy,
+		desiredState:  desiredState,
+		actualState:   actualState,
+		stateDiff:     stateDiff,
+	}, nil
+}
+
+// resetRevisionsInProgress marks revisions, which weren't completed, as failed. As planner supersedes all pending
+// revisions, only the last two could be in progress
+func (server *Server) resetRevisionsInProgress() {
+	gen := runtime.LastGen
+	for i := 0; i < 2; i++ {
+		revision, err := server.store.GetRevision(gen)
+		if err != nil {
+			log.Warnf("Error while getting revision: %s", err)
+			return
+		}
+		if revision == nil {
+			return
+		}
+
+		if revision.Status == engine.RevisionStatusInProgress {
+			revision.Status = engine.RevisionStatusError
+			revision.AppliedAt = time.Now()
+			revErr := server.store.UpdateRevision(revision)
+			if revErr != nil {
+				log.Warnf("Error while setting revision %d that is in progress to error state: %s", revision.GetGeneration(), revErr)
+			}
+			log.Infof("Revision %d that is in progress was reset to error state", revision.GetGeneration())
+		}
+
+		if revision.GetGeneration() <= runtime.FirstGen {
+			return
+		}
+		gen = revision.GetGeneration() - 1
+	}
+}
+
+// applyContext creates a context for applying revision with the given generation, which could be cancelled by revision
+// timeout or via abortRevision
+func (server *Server) applyContext(gen runtime.Generation) (context.Context, context.CancelFunc) {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if server.cfg.Enforcer.RevisionTimeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), server.cfg.Enforcer.RevisionTimeout)
 	} else {
-		log.Infof("(enforce-%d) Applying changes", server.enforcementIdx)
+		ctx, cancel = context.WithCancel(context.Background())
 	}
 
-	pluginRegistry := server.pluginRegistryFactory()
-	applyLog := event.NewLog(fmt.Sprintf("enforce-%d-apply", server.enforcementIdx), true)
-	applier := apply.NewEngineApply(desiredPolicy, desiredState, actualState, server.store.GetActualStateUpdater(), server.externalData, pluginRegistry, stateDiff.ActionPlan, applyLog, server.store.NewRevisionResultUpdater(nextRevision))
-	_, _ = applier.Apply()
+	server.applyLock.Lock()
+	defer server.applyLock.Unlock()
+	server.applyingRevision = gen
+	server.applyCancel = cancel
 
-	// save apply log
-	nextRevision.ApplyLog = applyLog.AsAPIEvents()
-	saveErr := server.store.UpdateRevision(nextRevision)
-	if saveErr != nil {
-		return fmt.Errorf("error while saving new revision with apply log: %s", saveErr)
+	return ctx, cancel
+}
+
+// applyDone releases the context used for applying revision
+func (server *Server) applyDone(cancel context.CancelFunc) {
+	server.applyLock.Lock()
+	defer server.applyLock.Unlock()
+	server.applyingRevision = 0
+	server.applyCancel = nil
+
+	cancel()
+}
+
+// abortRevision aborts revision with the given generation, if it's currently being applied by enforcer
+func (server *Server) abortRevision(gen runtime.Generation) error {
+	server.applyLock.Lock()
+	defer server.applyLock.Unlock()
+
+	if server.applyCancel == nil || server.applyingRevision != gen {
+		return fmt.Errorf("revision %d is not being applied", gen)
 	}
 
-	log.Infof("(enforce-%d) New revision %d processed, %d component instances", server.enforcementIdx, nextRevision.GetGeneration(), len(desiredState.ComponentInstanceMap))
+	log.Infof("Aborting revision %d", gen)
+	server.applyCancel()
 
 	return nil
 }
+
+// overrideRevision allows blocked revision with the given generation to be applied despite safety limits. Revision
+// status is checked under revision lock, so revision can't be superseded or taken by enforcer in the meantime
+func (server *Server) overrideRevision(gen runtime.Generation) (*engine.Revision, error) {
+	server.revisionLock.Lock()
+	defer server.revisionLock.Unlock()
+
+	revision, err := server.store.GetRevision(gen)
+	if err != nil {
+		return nil, fmt.Errorf("error while getting revision %d: %s", gen, err)
+	}
+	if revision == nil {
+		return nil, nil
+	}
+
+	if revision.Status != engine.RevisionStatusBlocked {
+		return nil, fmt.Errorf("revision %d is not blocked", gen)
+	}
+
+	log.Infof("Overriding safety limits for revision %d", gen)
+	revision.Override = true
+	err = server.store.UpdateRevision(revision)
+	if err != nil {
+		return nil, fmt.Errorf("error while updating revision %d: %s", gen, err)
+	}
+
+	return revision, nil
+}
@@ -0,0 +1,153 @@
+package server
+
+import (
+	"fmt"
+	"github.com/Aptomi/aptomi/pkg/engine"
+	"github.com/Aptomi/aptomi/pkg/engine/diff"
+	"github.com/Aptomi/aptomi/pkg/engine/resolve"
+	"github.com/Aptomi/aptomi/pkg/event"
+	"github.com/Aptomi/aptomi/pkg/lang"
+	"github.com/Aptomi/aptomi/pkg/runtime"
+	log "github.com/Sirupsen/logrus"
+	"strings"
+	"time"
+)
+
+// planLoop resolves every new policy generation right after it has been changed and stores calculated plan as a
+// pending revision, so feedback on policy changes doesn't depend on how long enforcer is busy applying changes
+func (server *Server) planLoop() error {
+	for {
+		err := server.plan()
+		if err != nil {
+			log.Errorf("Error while planning policy: %s", err)
+		}
+
+		// let enforcer know that there could be a new revision to apply
+		select {
+		case server.revisionPlanned <- true:
+		default:
+		}
+
+		// sleep for a specified time or wait until policy has changed, whichever comes first
+		timer := time.NewTimer(server.cfg.Enforcer.Interval)
+		select {
+		case <-server.policyChanged:
+			break // nolint: megacheck
+		case <-timer.C:
+			break // nolint: megacheck
+		}
+		timer.Stop()
+	}
+}
+
+func (server *Server) plan() error {
+	// revisions shouldn't be picked up by enforcer while we are superseding them
+	server.revisionLock.Lock()
+	defer server.revisionLock.Unlock()
+
+	server.planIdx++
+
+	defer func() {
+		if err := recover(); err != nil {
+			log.Errorf("Error while planning policy: %s", err)
+		}
+	}()
+
+	currRevision, err := server.store.GetRevision(runtime.LastGen)
+	if err != nil {
+		return fmt.Errorf("unable to get curr revision: %s", err)
+	}
+
+	desiredPolicy, desiredPolicyGen, err := server.store.GetPolicy(runtime.LastGen)
+	if err != nil {
+		return fmt.Errorf("error while getting desiredPolicy: %s", err)
+	}
+
+	// if policy is not found, it means it somehow was not initialized correctly. let's return error
+	if desiredPolicy == nil {
+		return fmt.Errorf("desiredPolicy is nil, does not exist in the store")
+	}
+
+	// policy has been planned already and its revision is either pending or being applied right now
+	if currRevision != nil && currRevision.Policy == desiredPolicyGen && (currRevision.IsPending() || currRevision.Status == engine.RevisionStatusInProgress) {
+		log.Debugf("(plan-%d) Revision %d for policy gen %d is %s", server.planIdx, currRevision.GetGeneration(), desiredPolicyGen, currRevision.Status)
+		return nil
+	}
+
+	actualState, err := server.store.GetActualState()
+	if err != nil {
+		return fmt.Errorf("error while getting actual state: %s", err)
+	}
+
+	// existing deployments are adopted by enforcer right before apply, so plan may contain create actions for them
+	resolveLog := event.NewLog(fmt.Sprintf("plan-%d-resolve", server.planIdx), true)
+	resolver := resolve.NewPolicyResolver(desiredPolicy, server.externalData, resolveLog)
+	resolver.SetActualState(actualState)
+	desiredState := resolver.ResolveAllDependencies()
+	stateDiff := diff.NewPolicyResolutionDiff(desiredState, actualState)
+
+	// policy changes while no actions needed to achieve desired state
+	actionCnt := stateDiff.ActionPlan.NumberOfActions()
+	if actionCnt <= 0 && currRevision != nil && currRevision.Policy == desiredPolicyGen {
+		log.Infof("(plan-%d) No changes, policy gen %d", server.planIdx, desiredPolicyGen)
+		return nil
+	}
+
+	nextRevision, err := server.store.NewRevision(desiredPolicyGen)
+	if err != nil {
+		return fmt.Errorf("unable to get next revision: %s", err)
+	}
+	nextRevision.ResolveLog = resolveLog.AsAPIEvents()
+	nextRevision.Plan = stateDiff.GetPlanView()
+	nextRevision.RecordDependencyComponents(desiredState)
+	server.checkRevision(nextRevision, desiredPolicy, stateDiff)
+
+	// pending revision is replaced with the new one, as only the latest policy should be applied
+	if currRevision != nil && currRevision.IsPending() {
+		currRevision.Status = engine.RevisionStatusSuperseded
+		err = server.store.UpdateRevision(currRevision)
+		if err != nil {
+			return fmt.Errorf("error while superseding revision %d: %s", currRevision.GetGeneration(), err)
+		}
+		log.Infof("(plan-%d) Revision %d has been superseded by revision %d", server.planIdx, currRevision.GetGeneration(), nextRevision.GetGeneration())
+	}
+
+	err = server.store.SaveRevision(nextRevision)
+	if err != nil {
+		return fmt.Errorf("error while saving new revision: %s", err)
+	}
+
+	log.Infof("(plan-%d) New revision %d, policy gen %d, %d actions planned, status: %s", server.planIdx, nextRevision.GetGeneration(), desiredPolicyGen, actionCnt, nextRevision.Status)
+
+	return nil
+}
+
+// checkRevision verifies that revision could be applied right now with the given state diff. If it can't be applied
+// due to maintenance windows or safety limits, revision status gets updated and false is returned
+func (server *Server) checkRevision(revision *engine.Revision, desiredPolicy *lang.Policy, stateDiff *diff.PolicyResolutionDiff) bool {
+	// make sure revision is applied within maintenance windows only. outside of them, it will be scheduled for later
+	if scheduledFor, allowed := stateDiff.CheckMaintenance(desiredPolicy, server.cfg.Enforcer.Maintenance, time.Now()); !allowed {
+		revision.Status = engine.RevisionStatusScheduled
+		revision.Scheduled = &engine.RevisionSchedule{
+			For: scheduledFor,
+		}
+		log.Infof("Revision %d is outside of maintenance windows, scheduled for %s", revision.GetGeneration(), scheduledFor)
+		return false
+	}
+
+	// make sure revision doesn't exceed safety limits. if it does, it will be blocked until explicitly overridden
+	if revision.Override {
+		log.Warnf("Blocked revision %d has been overridden, safety limits will not be checked", revision.GetGeneration())
+	} else if violations := stateDiff.CheckBlastRadius(desiredPolicy, server.cfg.Enforcer.BlastRadius); len(violations) > 0 {
+		revision.Status = engine.RevisionStatusBlocked
+		revision.Blocked = &engine.RevisionBlock{
+			Reasons: violations,
+		}
+		log.Warnf("Revision %d is blocked by safety limits: %s", revision.GetGeneration(), strings.Join(violations, "; "))
+		return false
+	}
+
+	revision.Scheduled = nil
+	revision.Blocked = nil
+	return true
+}
@@ -0,0 +1,201 @@
+package server
+
+import (
+	"fmt"
+	"github.com/Aptomi/aptomi/pkg/config"
+	"github.com/Aptomi/aptomi/pkg/external/secrets"
+	"github.com/Aptomi/aptomi/pkg/lang"
+	"github.com/Aptomi/aptomi/pkg/plugin"
+	externalplugin "github.com/Aptomi/aptomi/pkg/plugin/external"
+	"github.com/Aptomi/aptomi/pkg/plugin/fake"
+	"github.com/Aptomi/aptomi/pkg/plugin/gitops"
+	"github.com/Aptomi/aptomi/pkg/plugin/helm"
+	"github.com/Aptomi/aptomi/pkg/plugin/k8s"
+	"github.com/Aptomi/aptomi/pkg/plugin/k8sraw"
+	"github.com/Aptomi/aptomi/pkg/plugin/local"
+	"github.com/Aptomi/aptomi/pkg/plugin/webhook"
+	"github.com/Aptomi/aptomi/pkg/util"
+	log "github.com/Sirupsen/logrus"
+	"time"
+)
+
+// builtinClusterTypes returns constructors for all cluster plugins built into aptomi (see lang.BuiltinTypes)
+func builtinClusterTypes() map[string]plugin.ClusterPluginConstructor {
+	constructors := map[string]plugin.ClusterPluginConstructor{
+		"kubernetes": func(cluster *lang.Cluster, cfg config.Plugins) (plugin.ClusterPlugin, error) {
+			return k8s.New(cluster, cfg)
+		},
+		"local": func(cluster *lang.Cluster, cfg config.Plugins) (plugin.ClusterPlugin, error) {
+			return local.New(cluster, cfg)
+		},
+		"gitops": func(cluster *lang.Cluster, cfg config.Plugins) (plugin.ClusterPlugin, error) {
+			return gitops.New(cluster, cfg)
+		},
+	}
+
+	result := make(map[string]plugin.ClusterPluginConstructor)
+	for clusterType := range lang.BuiltinTypes {
+		constructor, exist := constructors[clusterType]
+		if !exist {
+			panic(fmt.Sprintf("no built-in plugin found for cluster type: %s", clusterType))
+		}
+		result[clusterType] = constructor
+	}
+
+	return result
+}
+
+// builtinCodeTypes returns constructors for all code plugins built into aptomi, grouped by cluster type. Code types
+// are taken from lang.BuiltinTypes, so the same types are used for policy validation when plugins aren't configured
+func builtinCodeTypes(secretLoader secrets.SecretLoader) map[string]map[string]plugin.CodePluginConstructor {
+	webhookConstructor := func(cluster plugin.ClusterPlugin, cfg config.Plugins) (plugin.CodePlugin, error) {
+		return webhook.New(cluster, cfg, secretLoader)
+	}
+	constructors := map[string]map[string]plugin.CodePluginConstructor{
+		"kubernetes": {
+			"helm": func(cluster plugin.ClusterPlugin, cfg config.Plugins) (plugin.CodePlugin, error) {
+				return helm.New(cluster, cfg, secretLoader)
+			},
+			"raw": func(cluster plugin.ClusterPlugin, cfg config.Plugins) (plugin.CodePlugin, error) {
+				return k8sraw.New(cluster, cfg)
+			},
+			"webhook": webhookConstructor,
+		},
+		"local": {
+			"exec": func(cluster plugin.ClusterPlugin, cfg config.Plugins) (plugin.CodePlugin, error) {
+				return local.NewExec(cluster, cfg)
+			},
+			"webhook": webhookConstructor,
+		},
+		"gitops": {
+			"helm": func(cluster plugin.ClusterPlugin, cfg config.Plugins) (plugin.CodePlugin, error) {
+				return gitops.NewHelm(cluster, cfg)
+			},
+			"raw": func(cluster plugin.ClusterPlugin, cfg config.Plugins) (plugin.CodePlugin, error) {
+				return gitops.NewRaw(cluster, cfg)
+			},
+			"webhook": webhookConstructor,
+		},
+	}
+
+	result := make(map[string]map[string]plugin.CodePluginConstructor)
+	for clusterType, codeTypes := range lang.BuiltinTypes {
+		result[clusterType] = make(map[string]plugin.CodePluginConstructor)
+		for _, codeType := range codeTypes {
+			constructor, exist := constructors[clusterType][codeType]
+			if !exist {
+				panic(fmt.Sprintf("no built-in plugin found for code type %s in cluster type: %s", codeType, clusterType))
+			}
+			result[clusterType][codeType] = constructor
+		}
+	}
+
+	return result
+}
+
+func (server *Server) initPluginRegistryFactory() {
+	// external plugins are discovered only once, as they are expected to be installed before server is started
+	var externalPlugins []*externalplugin.Plugin
+	if len(server.cfg.Plugins.External.Dir) > 0 {
+		var err error
+		externalPlugins, err = externalplugin.Discover(server.cfg.Plugins.External.Dir, server.cfg.Plugins.External.Timeout)
+		if err != nil {
+			panic(fmt.Sprintf("error while discovering external plugins: %s", err))
+		}
+		for _, p := range externalPlugins {
+			log.Infof("Discovered external plugin: %s", p)
+		}
+	}
+
+	server.pluginRegistryFactory = func() plugin.Registry {
+		clusterTypes, codeTypes, err := getConfiguredPluginTypes(server.cfg.Plugins, externalPlugins, server.externalData.SecretLoader)
+		if err != nil {
+			panic(fmt.Sprintf("error while configuring plugins: %s", err))
+		}
+
+		if server.cfg.Enforcer.Noop {
+			wrapNoOpPluginTypes(clusterTypes, codeTypes, server.cfg.Enforcer.NoopSleep)
+		}
+
+		return plugin.NewRegistry(server.cfg.Plugins, clusterTypes, codeTypes)
+	}
+
+	// make sure plugins are configured correctly before server gets started and validate all policies against the
+	// types registered in plugin registry
+	registry := server.pluginRegistryFactory()
+	for clusterType, codeTypes := range registry.SupportedTypes() {
+		log.Infof("Plugins registered for cluster type '%s' with code types %s", clusterType, codeTypes)
+	}
+	lang.SetTypeRegistry(registry)
+}
+
+// getConfiguredPluginTypes returns constructors for cluster and code types enabled in config. All types provided by
+// built-in and external plugins are available, but if config declares the mapping of cluster types to code types,
+// only the declared ones get enabled
+func getConfiguredPluginTypes(cfg config.Plugins, externalPlugins []*externalplugin.Plugin, secretLoader secrets.SecretLoader) (map[string]plugin.ClusterPluginConstructor, map[string]map[string]plugin.CodePluginConstructor, error) {
+	availableClusterTypes := builtinClusterTypes()
+	availableCodeTypes := builtinCodeTypes(secretLoader)
+	err := externalplugin.Register(externalPlugins, availableClusterTypes, availableCodeTypes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// webhook code plugin doesn't depend on the cluster, so it's available for cluster types of external plugins as well
+	for clusterType := range availableClusterTypes {
+		if _, exist := availableCodeTypes[clusterType]; !exist {
+			availableCodeTypes[clusterType] = make(map[string]plugin.CodePluginConstructor)
+		}
+		if _, exist := availableCodeTypes[clusterType]["webhook"]; !exist {
+			availableCodeTypes[clusterType]["webhook"] = func(cluster plugin.ClusterPlugin, cfg config.Plugins) (plugin.CodePlugin, error) {
+				return webhook.New(cluster, cfg, secretLoader)
+			}
+		}
+	}
+
+	types := cfg.Types
+	if len(types) == 0 {
+		types = make(map[string][]string)
+		for clusterType := range availableClusterTypes {
+			types[clusterType] = util.GetSortedStringKeys(availableCodeTypes[clusterType])
+		}
+	}
+
+	clusterTypes := make(map[string]plugin.ClusterPluginConstructor)
+	codeTypes := make(map[string]map[string]plugin.CodePluginConstructor)
+	for clusterType, codeTypeList := range types {
+		clusterConstructor, exist := availableClusterTypes[clusterType]
+		if !exist {
+			return nil, nil, fmt.Errorf("no plugin found for cluster type: %s", clusterType)
+		}
+		clusterTypes[clusterType] = clusterConstructor
+
+		codeTypes[clusterType] = make(map[string]plugin.CodePluginConstructor)
+		for _, codeType := range codeTypeList {
+			codeConstructor, exist := availableCodeTypes[clusterType][codeType]
+			if !exist {
+				return nil, nil, fmt.Errorf("no plugin found for code type %s in cluster type: %s", codeType, clusterType)
+			}
+			codeTypes[clusterType][codeType] = codeConstructor
+		}
+	}
+
+	return clusterTypes, codeTypes, nil
+}
+
+// wrapNoOpPluginTypes replaces constructors for all given cluster and code types with the ones creating no-op plugins,
+// so that every configured type is supported in noop mode
+func wrapNoOpPluginTypes(clusterTypes map[string]plugin.ClusterPluginConstructor, codeTypes map[string]map[string]plugin.CodePluginConstructor, sleepTime time.Duration) {
+	for clusterType := range clusterTypes {
+		clusterTypes[clusterType] = func(cluster *lang.Cluster, cfg config.Plugins) (plugin.ClusterPlugin, error) {
+			return fake.NewNoOpClusterPlugin(sleepTime), nil
+		}
+	}
+
+	for _, constructors := range codeTypes {
+		for codeType := range constructors {
+			constructors[codeType] = func(cluster plugin.ClusterPlugin, cfg config.Plugins) (plugin.CodePlugin, error) {
+				return fake.NewNoOpCodePlugin(sleepTime), nil
+			}
+		}
+	}
+}
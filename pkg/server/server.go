@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	"github.com/Aptomi/aptomi/pkg/api"
 	"github.com/Aptomi/aptomi/pkg/api/middleware"
@@ -8,12 +9,7 @@ import (
 	"github.com/Aptomi/aptomi/pkg/external"
 	"github.com/Aptomi/aptomi/pkg/external/secrets"
 	"github.com/Aptomi/aptomi/pkg/external/users"
-	"github.com/Aptomi/aptomi/pkg/lang"
 	"github.com/Aptomi/aptomi/pkg/plugin"
-	"github.com/Aptomi/aptomi/pkg/plugin/fake"
-	"github.com/Aptomi/aptomi/pkg/plugin/helm"
-	"github.com/Aptomi/aptomi/pkg/plugin/k8s"
-	"github.com/Aptomi/aptomi/pkg/plugin/k8sraw"
 	"github.com/Aptomi/aptomi/pkg/runtime"
 	"github.com/Aptomi/aptomi/pkg/runtime/store"
 	"github.com/Aptomi/aptomi/pkg/runtime/store/core"
@@ -24,6 +20,7 @@ import (
 	"github.com/julienschmidt/httprouter"
 	"net/http"
 	"os"
+	"sync"
 	"time"
 )
 
@@ -38,8 +35,22 @@ type Server struct {
 
 	httpServer *http.Server
 
-	policyChanged  chan bool
-	enforcementIdx uint
+	policyChanged   chan bool
+	revisionPlanned chan bool
+	planIdx         uint
+	enforcementIdx  uint
+	driftIdx        uint
+
+	// stateLock guards actual state from being concurrently changed by enforcer, drift detector and API
+	stateLock sync.Mutex
+
+	// revisionLock guards pending revisions from being superseded by planner while enforcer takes them for apply
+	revisionLock sync.Mutex
+
+	// applyLock guards the revision being applied by enforcer, so it could be aborted via API
+	applyLock        sync.Mutex
+	applyingRevision runtime.Generation
+	applyCancel      context.CancelFunc
 }
 
 // NewServer creates a new Aptomi Server
@@ -48,6 +59,7 @@ func NewServer(cfg *config.Server) *Server {
 		cfg:              cfg,
 		backgroundErrors: make(chan string),
 		policyChanged:    make(chan bool, 2048),
+		revisionPlanned:  make(chan bool, 1),
 	}
 
 	return s
@@ -63,9 +75,10 @@ func (server *Server) Start() {
 	// See if policy initialization needs to happen on the first run
 	server.initPolicyOnFirstRun()
 
-	// Start API, UI and Enforcer
+	// Start API, UI, Enforcer and Drift detector
 	server.startHTTPServer()
 	server.startEnforcer()
+	server.startDriftDetector()
 
 	// Wait for jobs to complete (it essentially hangs forever)
 	server.wait()
@@ -111,40 +124,6 @@ func (server *Server) initStore() {
 	server.store = core.NewStore(b)
 }
 
-func (server *Server) initPluginRegistryFactory() {
-	server.pluginRegistryFactory = func() plugin.Registry {
-		clusterTypes := make(map[string]plugin.ClusterPluginConstructor)
-		codeTypes := make(map[string]map[string]plugin.CodePluginConstructor)
-
-		if !server.cfg.Enforcer.Noop {
-			clusterTypes["kubernetes"] = func(cluster *lang.Cluster, cfg config.Plugins) (plugin.ClusterPlugin, error) {
-				return k8s.New(cluster, cfg)
-			}
-
-			codeTypes["kubernetes"] = make(map[string]plugin.CodePluginConstructor)
-			codeTypes["kubernetes"]["helm"] = func(cluster plugin.ClusterPlugin, cfg config.Plugins) (plugin.CodePlugin, error) {
-				return helm.New(cluster, cfg)
-			}
-			codeTypes["kubernetes"]["raw"] = func(cluster plugin.ClusterPlugin, cfg config.Plugins) (plugin.CodePlugin, error) {
-				return k8sraw.New(cluster, cfg)
-			}
-		} else {
-			sleepTime := server.cfg.Enforcer.NoopSleep
-
-			clusterTypes["kubernetes"] = func(cluster *lang.Cluster, cfg config.Plugins) (plugin.ClusterPlugin, error) {
-				return fake.NewNoOpClusterPlugin(sleepTime), nil
-			}
-
-			codeTypes["kubernetes"] = make(map[string]plugin.CodePluginConstructor)
-			codeTypes["kubernetes"]["helm"] = func(cluster plugin.ClusterPlugin, cfg config.Plugins) (plugin.CodePlugin, error) {
-				return fake.NewNoOpCodePlugin(sleepTime), nil
-			}
-		}
-
-		return plugin.NewRegistry(server.cfg.Plugins, clusterTypes, codeTypes)
-	}
-}
-
 func (server *Server) startHTTPServer() {
 	router := httprouter.New()
 
@@ -155,7 +134,7 @@ func (server *Server) startHTTPServer() {
 		log.Warnf("The auth.secret not specified in config, using insecure default one")
 	}
 
-	api.Serve(router, server.store, server.externalData, server.pluginRegistryFactory, server.cfg.Auth.Secret, server.policyChanged)
+	api.Serve(router, server.store, server.externalData, server.pluginRegistryFactory, server.cfg.Auth.Secret, server.policyChanged, server.abortRevision, server.overrideRevision, &server.stateLock)
 	server.serveUI(router)
 
 	var handler http.Handler = router
@@ -204,10 +183,22 @@ func (server *Server) serveUI(router *httprouter.Router) {
 }
 
 func (server *Server) startEnforcer() {
-	// Start policy enforcement job
+	// Start policy planning and enforcement jobs
 	if !server.cfg.Enforcer.Disabled {
+		server.runInBackground("Policy Planner", true, func() {
+			panic(server.planLoop())
+		})
 		server.runInBackground("Policy Enforcer", true, func() {
 			panic(server.enforceLoop())
 		})
 	}
 }
+
+func (server *Server) startDriftDetector() {
+	// Start drift detection job
+	if !server.cfg.Drift.Disabled && server.cfg.Drift.Interval > 0 {
+		server.runInBackground("Drift Detector", true, func() {
+			panic(server.driftLoop())
+		})
+	}
+}
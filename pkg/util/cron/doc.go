@@ -0,0 +1,3 @@
+// Package cron implements parsing of cron-like schedule expressions, which could be used to check whether a given
+// time matches the schedule and to find the next matching time.
+package cron
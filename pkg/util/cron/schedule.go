@@ -0,0 +1,159 @@
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron-like schedule expression with 5 fields: minute, hour, day of month, month and day of
+// week (e.g. "0 2 * * 6" means "every Saturday at 2am"). Each field could be '*', a number, a range 'a-b', a step
+// '*/n' or 'a-b/n', or a comma-separated list of those. Day of week is 0-7, where both 0 and 7 mean Sunday.
+// Same as in cron, if both day of month and day of week are restricted, time matches if either of them matches
+type Schedule struct {
+	minute     map[int]bool
+	hour       map[int]bool
+	dayOfMonth map[int]bool
+	month      map[int]bool
+	dayOfWeek  map[int]bool
+
+	dayOfMonthAny bool
+	dayOfWeekAny  bool
+}
+
+// field describes bounds of a single schedule field
+type field struct {
+	name string
+	min  int
+	max  int
+}
+
+var fields = []field{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// Parse parses a given cron-like schedule expression
+func Parse(expr string) (*Schedule, error) {
+	parts := strings.Fields(expr)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("schedule '%s' should have %d fields, but found %d", expr, len(fields), len(parts))
+	}
+
+	values := make([]map[int]bool, len(fields))
+	for idx, part := range parts {
+		var err error
+		values[idx], err = parseField(part, fields[idx])
+		if err != nil {
+			return nil, fmt.Errorf("schedule '%s' has invalid %s: %s", expr, fields[idx].name, err)
+		}
+	}
+
+	// Sunday could be specified both as 0 and 7
+	if values[4][7] {
+		values[4][0] = true
+	}
+
+	return &Schedule{
+		minute:        values[0],
+		hour:          values[1],
+		dayOfMonth:    values[2],
+		month:         values[3],
+		dayOfWeek:     values[4],
+		dayOfMonthAny: strings.HasPrefix(parts[2], "*"),
+		dayOfWeekAny:  strings.HasPrefix(parts[4], "*"),
+	}, nil
+}
+
+// Matches returns true if a given time matches the schedule (with minute precision)
+func (schedule *Schedule) Matches(t time.Time) bool {
+	return schedule.minute[t.Minute()] && schedule.hour[t.Hour()] && schedule.month[int(t.Month())] && schedule.matchesDay(t)
+}
+
+// Next returns the first time after a given one, which matches the schedule. If nothing matches within the next
+// 5 years (e.g. for "0 0 31 2 *"), zero time is returned
+func (schedule *Schedule) Next(t time.Time) time.Time {
+	// truncation is done via time.Date in the time zone of t, as Truncate works on absolute time and doesn't take
+	// zone offset into account (e.g. it's wrong for +05:30)
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, t.Location())
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if !schedule.month[int(t.Month())] {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !schedule.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !schedule.hour[t.Hour()] {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if !schedule.minute[t.Minute()] {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (schedule *Schedule) matchesDay(t time.Time) bool {
+	dayOfMonth := schedule.dayOfMonth[t.Day()]
+	dayOfWeek := schedule.dayOfWeek[int(t.Weekday())]
+	if schedule.dayOfMonthAny || schedule.dayOfWeekAny {
+		return dayOfMonth && dayOfWeek
+	}
+	return dayOfMonth || dayOfWeek
+}
+
+// parseField parses a single schedule field into the set of values
+func parseField(value string, f field) (map[int]bool, error) {
+	result := make(map[int]bool)
+	for _, item := range strings.Split(value, ",") {
+		step := 1
+		hasStep := false
+		if idx := strings.Index(item, "/"); idx >= 0 {
+			var err error
+			step, err = strconv.Atoi(item[idx+1:])
+			if err != nil || step <= 0 {
+				return nil, fmt.Errorf("invalid step in '%s'", item)
+			}
+			item = item[:idx]
+			hasStep = true
+		}
+
+		from, to := f.min, f.max
+		if item != "*" {
+			bounds := strings.SplitN(item, "-", 2)
+			var err error
+			from, err = strconv.Atoi(bounds[0])
+			if err != nil {
+				return nil, fmt.Errorf("invalid value '%s'", item)
+			}
+			to = from
+			if hasStep {
+				to = f.max
+			}
+			if len(bounds) > 1 {
+				to, err = strconv.Atoi(bounds[1])
+				if err != nil {
+					return nil, fmt.Errorf("invalid value '%s'", item)
+				}
+			}
+			if from < f.min || to > f.max || from > to {
+				return nil, fmt.Errorf("value '%s' is out of range %d-%d", item, f.min, f.max)
+			}
+		}
+
+		for v := from; v <= to; v += step {
+			result[v] = true
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,82 @@
+package cron
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestScheduleParse(t *testing.T) {
+	valid := []string{
+		"* * * * *",
+		"0 2 * * 6",
+		"*/15 0-6 1,15 * 1-5",
+		"30 22 * 1-12/2 7",
+	}
+	for _, expr := range valid {
+		_, err := Parse(expr)
+		assert.NoError(t, err, "Schedule '%s' should be valid", expr)
+	}
+
+	invalid := []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"a * * * *",
+		"5-1 * * * *",
+	}
+	for _, expr := range invalid {
+		_, err := Parse(expr)
+		assert.Error(t, err, "Schedule '%s' should be invalid", expr)
+	}
+}
+
+func TestScheduleMatches(t *testing.T) {
+	// every Saturday at 2am
+	schedule, err := Parse("0 2 * * 6")
+	assert.NoError(t, err, "Schedule should be valid")
+
+	assert.True(t, schedule.Matches(time.Date(2017, 11, 4, 2, 0, 0, 0, time.UTC)), "Saturday 2am should match")
+	assert.False(t, schedule.Matches(time.Date(2017, 11, 4, 2, 1, 0, 0, time.UTC)), "Saturday 2:01am should not match")
+	assert.False(t, schedule.Matches(time.Date(2017, 11, 5, 2, 0, 0, 0, time.UTC)), "Sunday 2am should not match")
+
+	// Sunday could be specified as 7
+	schedule, err = Parse("0 0 * * 7")
+	assert.NoError(t, err, "Schedule should be valid")
+	assert.True(t, schedule.Matches(time.Date(2017, 11, 5, 0, 0, 0, 0, time.UTC)), "Sunday should match")
+
+	// either day of month or day of week should match, if both of them are restricted
+	schedule, err = Parse("0 0 1 * 1")
+	assert.NoError(t, err, "Schedule should be valid")
+	assert.True(t, schedule.Matches(time.Date(2017, 11, 1, 0, 0, 0, 0, time.UTC)), "First day of month should match")
+	assert.True(t, schedule.Matches(time.Date(2017, 11, 6, 0, 0, 0, 0, time.UTC)), "Monday should match")
+	assert.False(t, schedule.Matches(time.Date(2017, 11, 7, 0, 0, 0, 0, time.UTC)), "Tuesday should not match")
+}
+
+func TestScheduleNext(t *testing.T) {
+	schedule, err := Parse("0 2 * * 6")
+	assert.NoError(t, err, "Schedule should be valid")
+
+	// Wednesday -> Saturday
+	next := schedule.Next(time.Date(2017, 11, 1, 15, 30, 0, 0, time.UTC))
+	assert.Equal(t, time.Date(2017, 11, 4, 2, 0, 0, 0, time.UTC), next, "Next time should be on Saturday")
+
+	// exact match should not be returned
+	next = schedule.Next(time.Date(2017, 11, 4, 2, 0, 0, 0, time.UTC))
+	assert.Equal(t, time.Date(2017, 11, 11, 2, 0, 0, 0, time.UTC), next, "Next time should be on the next Saturday")
+
+	// time zone with non-hour offset
+	zone := time.FixedZone("IST", 5*60*60+30*60)
+	next = schedule.Next(time.Date(2017, 11, 1, 15, 30, 0, 0, zone))
+	assert.Equal(t, time.Date(2017, 11, 4, 2, 0, 0, 0, zone), next, "Next time should be on Saturday in the same time zone")
+
+	// never matches
+	schedule, err = Parse("0 0 31 2 *")
+	assert.NoError(t, err, "Schedule should be valid")
+	assert.True(t, schedule.Next(time.Now()).IsZero(), "Next time should not be found for schedule which never matches")
+}
@@ -0,0 +1,33 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+)
+
+// RunWithContext runs provided function and waits until it's completed or context is done, whichever comes first. If
+// context is done before function completion, context error is returned, while function keeps running in background
+// and its result is discarded. It's useful for calling blocking code which doesn't support cancellation by itself.
+// Function should still check context between its steps, so it won't keep changing anything after the caller has
+// moved on. If function panics, it'll be recovered and returned as error.
+func RunWithContext(ctx context.Context, fn func() error) error {
+	// buffered channel, so go routine will not leak if nobody waits for the result
+	result := make(chan error, 1)
+
+	go func() {
+		defer func() {
+			if err := recover(); err != nil {
+				result <- fmt.Errorf("panic: %s", err)
+			}
+		}()
+
+		result <- fn()
+	}()
+
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,40 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestRunWithContextCompleted(t *testing.T) {
+	err := RunWithContext(context.Background(), func() error {
+		return nil
+	})
+	assert.NoError(t, err, "Function should complete successfully")
+
+	err = RunWithContext(context.Background(), func() error {
+		return errors.New("test error")
+	})
+	assert.EqualError(t, err, "test error", "Function error should be returned")
+
+	err = RunWithContext(context.Background(), func() error {
+		panic("test panic")
+	})
+	assert.EqualError(t, err, "panic: test panic", "Function panic should be returned as error")
+}
+
+func TestRunWithContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	release := make(chan bool)
+	defer close(release)
+
+	err := RunWithContext(ctx, func() error {
+		<-release
+		return nil
+	})
+	assert.Equal(t, context.DeadlineExceeded, err, "Context error should be returned when function hangs")
+}